@@ -1,29 +1,62 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 type Values struct {
 	// Documented variables.
 	PrivateKey              string
+	KeystoreFile            string
+	KeystorePassphrase      string
+	RemoteSignerUrl         string
+	RemoteSignerAddress     common.Address
+	Signers                 []signer.Source
 	EthClientUrl            string
 	Port                    int
 	DataDirectory           string
 	SupportedEntryPoints    []common.Address
+	EntryPointVersions      map[common.Address]userop.Version
 	MaxVerificationGas      *big.Int
 	MaxBatchGasLimit        *big.Int
 	MaxOpTTL                time.Duration
+	OpStatusTTL             time.Duration
 	MaxOpsForUnstakedSender int
+	MinStake                *big.Int
+	MinUnstakeDelay         time.Duration
+	MaxMempoolSize          int
+	MaxOpsPerPaymaster      int
+	MaxOpsPerFactory        int
+	BundleTrigger           bundler.TriggerType
+	BundleInterval          time.Duration
+	BundleMinOps            int
+	BundleMaxWait           time.Duration
+	TxResubmitAfterBlocks   uint64
+	TxFinalityBlocks        uint64
 	Beneficiary             string
+	BeneficiaryPrivateKey   string
+	MinSignerBalance        *big.Int
+	RefillFromDeposit       bool
+	EntryPointSimulations   common.Address
+	TracingLevel            simulation.TracingLevel
+	TracerMode              tracer.Mode
+	AdminApiKey             string
+	AdminKeystoreDir        string
 
 	// Searcher mode variables.
 	EthBuilderUrl     string
@@ -52,6 +85,17 @@ func envKeyValStringToMap(s string) map[string]string {
 	return out
 }
 
+func envArrayToStringSlice(s string) []string {
+	slc := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			slc = append(slc, v)
+		}
+	}
+
+	return slc
+}
+
 func envArrayToAddressSlice(s string) []common.Address {
 	env := strings.Split(s, ",")
 	slc := []common.Address{}
@@ -66,6 +110,91 @@ func variableNotSetOrIsNil(env string) bool {
 	return !viper.IsSet(env) || viper.GetString(env) == ""
 }
 
+// getSignerAddress returns the address of the bundler's signer from whichever source is configured. The
+// keystore file is not decrypted since the address is stored in plain text.
+func getSignerAddress() (common.Address, error) {
+	if !variableNotSetOrIsNil("erc4337_bundler_private_key") {
+		s, err := signer.New(viper.GetString("erc4337_bundler_private_key"))
+		if err != nil {
+			return common.Address{}, err
+		}
+		return s.Address, nil
+	}
+
+	if !variableNotSetOrIsNil("erc4337_bundler_keystore_file") {
+		data, err := os.ReadFile(viper.GetString("erc4337_bundler_keystore_file"))
+		if err != nil {
+			return common.Address{}, err
+		}
+		var ks struct {
+			Address string `json:"address"`
+		}
+		if err := json.Unmarshal(data, &ks); err != nil {
+			return common.Address{}, err
+		}
+		return common.HexToAddress(ks.Address), nil
+	}
+
+	return common.HexToAddress(viper.GetString("erc4337_bundler_remote_signer_address")), nil
+}
+
+// getSignerSources returns the sources of additional signers for the pool. Keystore files are decrypted with
+// the same passphrase as the primary signer. Remote addresses use the primary remote signer URL if a separate
+// one is not set.
+func getSignerSources() []signer.Source {
+	srcs := []signer.Source{}
+	for _, pk := range envArrayToStringSlice(viper.GetString("erc4337_bundler_signer_private_keys")) {
+		srcs = append(srcs, signer.Source{PrivateKey: pk})
+	}
+	for _, file := range envArrayToStringSlice(viper.GetString("erc4337_bundler_signer_keystore_files")) {
+		srcs = append(srcs, signer.Source{
+			KeystoreFile:       file,
+			KeystorePassphrase: viper.GetString("erc4337_bundler_keystore_passphrase"),
+		})
+	}
+
+	url := viper.GetString("erc4337_bundler_signer_remote_url")
+	if url == "" {
+		url = viper.GetString("erc4337_bundler_remote_signer_url")
+	}
+	for _, addr := range envArrayToStringSlice(viper.GetString("erc4337_bundler_signer_remote_addresses")) {
+		srcs = append(srcs, signer.Source{
+			RemoteSignerUrl:     url,
+			RemoteSignerAddress: common.HexToAddress(addr),
+		})
+	}
+	return srcs
+}
+
+// getEntryPointVersions returns the version of every supported EntryPoint. Versions can be set for
+// non-canonical deployments as "<address>=<version>" pairs separated by "&". An error is returned if the
+// version of a supported EntryPoint is unknown.
+func getEntryPointVersions(eps []common.Address) (map[common.Address]userop.Version, error) {
+	configured := make(map[common.Address]userop.Version)
+	for addr, ver := range envKeyValStringToMap(viper.GetString("erc4337_bundler_entry_point_versions")) {
+		v, err := userop.ParseVersion(strings.TrimSpace(ver))
+		if err != nil {
+			return nil, err
+		}
+		configured[common.HexToAddress(strings.TrimSpace(addr))] = v
+	}
+
+	out := make(map[common.Address]userop.Version)
+	for _, ep := range eps {
+		if v, ok := configured[ep]; ok {
+			out[ep] = v
+		} else if v, ok := entrypoint.LookupVersion(ep); ok {
+			out[ep] = v
+		} else {
+			return nil, fmt.Errorf(
+				"version of EntryPoint %s is unknown, set it with erc4337_bundler_entry_point_versions",
+				ep,
+			)
+		}
+	}
+	return out, nil
+}
+
 // GetValues returns config for the bundler that has been read in from env vars. See
 // https://docs.stackup.sh/docs/packages/bundler/configure for details.
 func GetValues() *Values {
@@ -76,7 +205,25 @@ func GetValues() *Values {
 	viper.SetDefault("erc4337_bundler_max_verification_gas", 4000000)
 	viper.SetDefault("erc4337_bundler_max_batch_gas_limit", 30000000)
 	viper.SetDefault("erc4337_bundler_max_op_ttl_seconds", 180)
+	viper.SetDefault("erc4337_bundler_op_status_ttl_seconds", 86400)
 	viper.SetDefault("erc4337_bundler_max_ops_for_unstaked_sender", 4)
+	viper.SetDefault("erc4337_bundler_max_mempool_size", 10000)
+	viper.SetDefault("erc4337_bundler_min_stake", "0")
+	viper.SetDefault("erc4337_bundler_min_unstake_delay_seconds", 0)
+	viper.SetDefault("erc4337_bundler_max_ops_per_paymaster", 0)
+	viper.SetDefault("erc4337_bundler_max_ops_per_factory", 0)
+	viper.SetDefault("erc4337_bundler_bundle_trigger", string(bundler.IntervalTrigger))
+	viper.SetDefault("erc4337_bundler_bundle_interval_ms", 1000)
+	viper.SetDefault("erc4337_bundler_bundle_min_ops", 0)
+	viper.SetDefault("erc4337_bundler_bundle_max_wait_ms", 0)
+	viper.SetDefault("erc4337_bundler_tx_resubmit_after_blocks", 3)
+	viper.SetDefault("erc4337_bundler_tx_finality_blocks", 0)
+	viper.SetDefault("erc4337_bundler_min_signer_balance", "0")
+	viper.SetDefault("erc4337_bundler_refill_from_deposit", false)
+	// Full ERC-7562 validation is enforced by default. Previous releases skipped tracing altogether which is
+	// equivalent to "off". Use "relaxed" to skip storage and paymaster context rules on constrained nodes.
+	viper.SetDefault("erc4337_bundler_tracing_level", string(simulation.TracingFull))
+	viper.SetDefault("erc4337_bundler_tracer_mode", string(tracer.JSMode))
 	viper.SetDefault("erc4337_bundler_blocks_in_the_future", 25)
 	viper.SetDefault("erc4337_bundler_otel_insecure_mode", false)
 	viper.SetDefault("erc4337_bundler_debug_mode", false)
@@ -98,14 +245,43 @@ func GetValues() *Values {
 	// Read in from environment variables
 	_ = viper.BindEnv("erc4337_bundler_eth_client_url")
 	_ = viper.BindEnv("erc4337_bundler_private_key")
+	_ = viper.BindEnv("erc4337_bundler_keystore_file")
+	_ = viper.BindEnv("erc4337_bundler_keystore_passphrase")
+	_ = viper.BindEnv("erc4337_bundler_remote_signer_url")
+	_ = viper.BindEnv("erc4337_bundler_remote_signer_address")
+	_ = viper.BindEnv("erc4337_bundler_signer_private_keys")
+	_ = viper.BindEnv("erc4337_bundler_signer_keystore_files")
+	_ = viper.BindEnv("erc4337_bundler_signer_remote_url")
+	_ = viper.BindEnv("erc4337_bundler_signer_remote_addresses")
 	_ = viper.BindEnv("erc4337_bundler_port")
 	_ = viper.BindEnv("erc4337_bundler_data_directory")
 	_ = viper.BindEnv("erc4337_bundler_supported_entry_points")
+	_ = viper.BindEnv("erc4337_bundler_entry_point_versions")
 	_ = viper.BindEnv("erc4337_bundler_beneficiary")
+	_ = viper.BindEnv("erc4337_bundler_entry_point_simulations")
 	_ = viper.BindEnv("erc4337_bundler_max_verification_gas")
 	_ = viper.BindEnv("erc4337_bundler_max_batch_gas_limit")
 	_ = viper.BindEnv("erc4337_bundler_max_op_ttl_seconds")
+	_ = viper.BindEnv("erc4337_bundler_op_status_ttl_seconds")
 	_ = viper.BindEnv("erc4337_bundler_max_ops_for_unstaked_sender")
+	_ = viper.BindEnv("erc4337_bundler_min_stake")
+	_ = viper.BindEnv("erc4337_bundler_min_unstake_delay_seconds")
+	_ = viper.BindEnv("erc4337_bundler_max_mempool_size")
+	_ = viper.BindEnv("erc4337_bundler_max_ops_per_paymaster")
+	_ = viper.BindEnv("erc4337_bundler_max_ops_per_factory")
+	_ = viper.BindEnv("erc4337_bundler_bundle_trigger")
+	_ = viper.BindEnv("erc4337_bundler_bundle_interval_ms")
+	_ = viper.BindEnv("erc4337_bundler_bundle_min_ops")
+	_ = viper.BindEnv("erc4337_bundler_bundle_max_wait_ms")
+	_ = viper.BindEnv("erc4337_bundler_tx_resubmit_after_blocks")
+	_ = viper.BindEnv("erc4337_bundler_tx_finality_blocks")
+	_ = viper.BindEnv("erc4337_bundler_beneficiary_private_key")
+	_ = viper.BindEnv("erc4337_bundler_min_signer_balance")
+	_ = viper.BindEnv("erc4337_bundler_refill_from_deposit")
+	_ = viper.BindEnv("erc4337_bundler_tracing_level")
+	_ = viper.BindEnv("erc4337_bundler_tracer_mode")
+	_ = viper.BindEnv("erc4337_bundler_admin_api_key")
+	_ = viper.BindEnv("erc4337_bundler_admin_keystore_dir")
 	_ = viper.BindEnv("erc4337_bundler_eth_builder_url")
 	_ = viper.BindEnv("erc4337_bundler_blocks_in_the_future")
 	_ = viper.BindEnv("erc4337_bundler_otel_service_name")
@@ -120,16 +296,89 @@ func GetValues() *Values {
 		panic("Fatal config error: erc4337_bundler_eth_client_url not set")
 	}
 
-	if variableNotSetOrIsNil("erc4337_bundler_private_key") {
+	signerSources := 0
+	for _, env := range []string{
+		"erc4337_bundler_private_key",
+		"erc4337_bundler_keystore_file",
+		"erc4337_bundler_remote_signer_url",
+	} {
+		if !variableNotSetOrIsNil(env) {
+			signerSources++
+		}
+	}
+	if signerSources == 0 {
 		panic("Fatal config error: erc4337_bundler_private_key not set")
+	} else if signerSources > 1 {
+		panic(
+			"Fatal config error: only one of erc4337_bundler_private_key, erc4337_bundler_keystore_file, or " +
+				"erc4337_bundler_remote_signer_url can be set",
+		)
+	}
+
+	if !variableNotSetOrIsNil("erc4337_bundler_remote_signer_url") &&
+		!common.IsHexAddress(viper.GetString("erc4337_bundler_remote_signer_address")) {
+		panic("Fatal config error: erc4337_bundler_remote_signer_address not set")
+	}
+
+	if !variableNotSetOrIsNil("erc4337_bundler_signer_remote_addresses") &&
+		variableNotSetOrIsNil("erc4337_bundler_signer_remote_url") &&
+		variableNotSetOrIsNil("erc4337_bundler_remote_signer_url") {
+		panic("Fatal config error: erc4337_bundler_signer_remote_url not set")
 	}
 
 	if !viper.IsSet("erc4337_bundler_beneficiary") {
-		s, err := signer.New(viper.GetString("erc4337_bundler_private_key"))
+		addr, err := getSignerAddress()
 		if err != nil {
 			panic(err)
 		}
-		viper.SetDefault("erc4337_bundler_beneficiary", s.Address.String())
+		viper.SetDefault("erc4337_bundler_beneficiary", addr.String())
+	}
+
+	entryPointVersions, err := getEntryPointVersions(
+		envArrayToAddressSlice(viper.GetString("erc4337_bundler_supported_entry_points")),
+	)
+	if err != nil {
+		panic(fmt.Errorf("fatal config error: %w", err))
+	}
+	for _, v := range entryPointVersions {
+		if v == userop.V07 && variableNotSetOrIsNil("erc4337_bundler_entry_point_simulations") {
+			panic("Fatal config error: erc4337_bundler_entry_point_simulations is required for EntryPoint v0.7")
+		}
+	}
+
+	tracingLevel, err := simulation.ParseTracingLevel(viper.GetString("erc4337_bundler_tracing_level"))
+	if err != nil {
+		panic(fmt.Errorf("fatal config error: %w", err))
+	}
+
+	tracerMode, err := tracer.ParseMode(viper.GetString("erc4337_bundler_tracer_mode"))
+	if err != nil {
+		panic(fmt.Errorf("fatal config error: %w", err))
+	}
+
+	bundleTrigger, err := bundler.ParseTriggerType(viper.GetString("erc4337_bundler_bundle_trigger"))
+	if err != nil {
+		panic(fmt.Errorf("fatal config error: %w", err))
+	}
+
+	minSignerBalance, ok := big.NewInt(0).SetString(viper.GetString("erc4337_bundler_min_signer_balance"), 10)
+	if !ok {
+		panic("Fatal config error: erc4337_bundler_min_signer_balance is not a valid integer")
+	}
+
+	minStake, ok := big.NewInt(0).SetString(viper.GetString("erc4337_bundler_min_stake"), 10)
+	if !ok {
+		panic("Fatal config error: erc4337_bundler_min_stake is not a valid integer")
+	}
+
+	if !variableNotSetOrIsNil("erc4337_bundler_beneficiary_private_key") {
+		s, err := signer.New(viper.GetString("erc4337_bundler_beneficiary_private_key"))
+		if err != nil {
+			panic(err)
+		}
+		if s.Address != common.HexToAddress(viper.GetString("erc4337_bundler_beneficiary")) {
+			panic("Fatal config error: erc4337_bundler_beneficiary_private_key does not match beneficiary")
+		}
 	}
 
 	switch viper.GetString("mode") {
@@ -147,15 +396,35 @@ func GetValues() *Values {
 
 	// Return Values
 	privateKey := viper.GetString("erc4337_bundler_private_key")
+	keystoreFile := viper.GetString("erc4337_bundler_keystore_file")
+	keystorePassphrase := viper.GetString("erc4337_bundler_keystore_passphrase")
+	remoteSignerUrl := viper.GetString("erc4337_bundler_remote_signer_url")
+	remoteSignerAddress := common.HexToAddress(viper.GetString("erc4337_bundler_remote_signer_address"))
+	signers := getSignerSources()
 	ethClientUrl := viper.GetString("erc4337_bundler_eth_client_url")
 	port := viper.GetInt("erc4337_bundler_port")
 	dataDirectory := viper.GetString("erc4337_bundler_data_directory")
 	supportedEntryPoints := envArrayToAddressSlice(viper.GetString("erc4337_bundler_supported_entry_points"))
 	beneficiary := viper.GetString("erc4337_bundler_beneficiary")
+	beneficiaryPrivateKey := viper.GetString("erc4337_bundler_beneficiary_private_key")
+	refillFromDeposit := viper.GetBool("erc4337_bundler_refill_from_deposit")
+	adminApiKey := viper.GetString("erc4337_bundler_admin_api_key")
+	adminKeystoreDir := viper.GetString("erc4337_bundler_admin_keystore_dir")
+	entryPointSimulations := common.HexToAddress(viper.GetString("erc4337_bundler_entry_point_simulations"))
 	maxVerificationGas := big.NewInt(int64(viper.GetInt("erc4337_bundler_max_verification_gas")))
 	maxBatchGasLimit := big.NewInt(int64(viper.GetInt("erc4337_bundler_max_batch_gas_limit")))
 	maxOpTTL := time.Second * viper.GetDuration("erc4337_bundler_max_op_ttl_seconds")
+	opStatusTTL := time.Second * viper.GetDuration("erc4337_bundler_op_status_ttl_seconds")
 	maxOpsForUnstakedSender := viper.GetInt("erc4337_bundler_max_ops_for_unstaked_sender")
+	minUnstakeDelay := time.Second * viper.GetDuration("erc4337_bundler_min_unstake_delay_seconds")
+	maxMempoolSize := viper.GetInt("erc4337_bundler_max_mempool_size")
+	maxOpsPerPaymaster := viper.GetInt("erc4337_bundler_max_ops_per_paymaster")
+	maxOpsPerFactory := viper.GetInt("erc4337_bundler_max_ops_per_factory")
+	bundleInterval := time.Millisecond * viper.GetDuration("erc4337_bundler_bundle_interval_ms")
+	bundleMinOps := viper.GetInt("erc4337_bundler_bundle_min_ops")
+	bundleMaxWait := time.Millisecond * viper.GetDuration("erc4337_bundler_bundle_max_wait_ms")
+	txResubmitAfterBlocks := viper.GetUint64("erc4337_bundler_tx_resubmit_after_blocks")
+	txFinalityBlocks := viper.GetUint64("erc4337_bundler_tx_finality_blocks")
 	ethBuilderUrl := viper.GetString("erc4337_bundler_eth_builder_url")
 	blocksInTheFuture := viper.GetInt("erc4337_bundler_blocks_in_the_future")
 	otelServiceName := viper.GetString("erc4337_bundler_otel_service_name")
@@ -166,15 +435,41 @@ func GetValues() *Values {
 	ginMode := viper.GetString("erc4337_bundler_gin_mode")
 	return &Values{
 		PrivateKey:              privateKey,
+		KeystoreFile:            keystoreFile,
+		KeystorePassphrase:      keystorePassphrase,
+		RemoteSignerUrl:         remoteSignerUrl,
+		RemoteSignerAddress:     remoteSignerAddress,
+		Signers:                 signers,
 		EthClientUrl:            ethClientUrl,
 		Port:                    port,
 		DataDirectory:           dataDirectory,
 		SupportedEntryPoints:    supportedEntryPoints,
+		EntryPointVersions:      entryPointVersions,
 		Beneficiary:             beneficiary,
+		BeneficiaryPrivateKey:   beneficiaryPrivateKey,
+		MinSignerBalance:        minSignerBalance,
+		RefillFromDeposit:       refillFromDeposit,
+		EntryPointSimulations:   entryPointSimulations,
+		TracingLevel:            tracingLevel,
+		TracerMode:              tracerMode,
+		AdminApiKey:             adminApiKey,
+		AdminKeystoreDir:        adminKeystoreDir,
 		MaxVerificationGas:      maxVerificationGas,
 		MaxBatchGasLimit:        maxBatchGasLimit,
 		MaxOpTTL:                maxOpTTL,
+		OpStatusTTL:             opStatusTTL,
 		MaxOpsForUnstakedSender: maxOpsForUnstakedSender,
+		MinStake:                minStake,
+		MinUnstakeDelay:         minUnstakeDelay,
+		MaxMempoolSize:          maxMempoolSize,
+		MaxOpsPerPaymaster:      maxOpsPerPaymaster,
+		MaxOpsPerFactory:        maxOpsPerFactory,
+		BundleTrigger:           bundleTrigger,
+		BundleInterval:          bundleInterval,
+		BundleMinOps:            bundleMinOps,
+		BundleMaxWait:           bundleMaxWait,
+		TxResubmitAfterBlocks:   txResubmitAfterBlocks,
+		TxFinalityBlocks:        txFinalityBlocks,
 		EthBuilderUrl:           ethBuilderUrl,
 		BlocksInTheFuture:       blocksInTheFuture,
 		OTELServiceName:         otelServiceName,
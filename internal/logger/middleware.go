@@ -52,6 +52,14 @@ func WithLogr(logger logr.Logger) gin.HandlerFunc {
 			json := req.(map[string]any)
 			logEvent = logEvent.WithValues("rpc_method", json["method"])
 		}
+		batch, exists := c.Get("json-rpc-batch")
+		if exists {
+			methods := []any{}
+			for _, json := range batch.([]map[string]any) {
+				methods = append(methods, json["method"])
+			}
+			logEvent = logEvent.WithValues("rpc_batch_methods", methods)
+		}
 
 		// Log using the params
 		if c.Writer.Status() >= 500 {
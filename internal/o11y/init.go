@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -13,6 +14,7 @@ import (
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/credentials"
 
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
@@ -31,6 +33,14 @@ type Opts struct {
 	Address common.Address
 }
 
+var (
+	mu             sync.Mutex
+	tracerOpts     *Opts
+	tracerProvider *sdktrace.TracerProvider
+	meterOpts      *Opts
+	meterProvider  *sdkmetric.MeterProvider
+)
+
 func initResources(opts *Opts) *resource.Resource {
 	resources, err := resource.New(
 		context.Background(),
@@ -70,17 +80,23 @@ func InitTracer(opts *Opts) func() {
 		log.Fatal(err)
 	}
 
-	otel.SetTracerProvider(
-		sdktrace.NewTracerProvider(
-			sdktrace.WithSampler(sdktrace.AlwaysSample()),
-			sdktrace.WithBatcher(exporter),
-			sdktrace.WithResource(initResources(opts)),
-		),
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(initResources(opts)),
 	)
+	otel.SetTracerProvider(tp)
 	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
 	otel.SetTextMapPropagator(propagator)
+
+	mu.Lock()
+	defer mu.Unlock()
+	tracerOpts = opts
+	tracerProvider = tp
 	return func() {
-		_ = exporter.Shutdown(context.Background())
+		mu.Lock()
+		defer mu.Unlock()
+		_ = tracerProvider.Shutdown(context.Background())
 	}
 }
 
@@ -100,15 +116,71 @@ func InitMetrics(opts *Opts) func() {
 		log.Fatal(err)
 	}
 
-	otel.SetMeterProvider(
-		sdkmetric.NewMeterProvider(
-			sdkmetric.WithResource(initResources(opts)),
-			sdkmetric.WithReader(
-				sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(30*time.Second)),
-			),
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(initResources(opts)),
+		sdkmetric.WithReader(
+			sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(30*time.Second)),
 		),
 	)
+	otel.SetMeterProvider(mp)
+
+	mu.Lock()
+	defer mu.Unlock()
+	meterOpts = opts
+	meterProvider = mp
 	return func() {
-		_ = exporter.Shutdown(context.Background())
+		mu.Lock()
+		defer mu.Unlock()
+		_ = meterProvider.Shutdown(context.Background())
+	}
+}
+
+// SetAddress re-initializes the tracer and meter providers with a new bundler.address resource attribute
+// since resources are immutable once a provider is created. The previous providers are flushed and shut down.
+// Instruments created from the previous meter provider will stop reporting and must be created again with a
+// meter from otel.GetMeterProvider().
+func SetAddress(addr common.Address) {
+	mu.Lock()
+	prevTracer, prevMeter := tracerProvider, meterProvider
+	tOpts, mOpts := tracerOpts, meterOpts
+	mu.Unlock()
+
+	if prevTracer != nil {
+		opts := *tOpts
+		opts.Address = addr
+		InitTracer(&opts)
+		_ = prevTracer.Shutdown(context.Background())
+	}
+	if prevMeter != nil {
+		opts := *mOpts
+		opts.Address = addr
+		InitMetrics(&opts)
+		_ = prevMeter.Shutdown(context.Background())
 	}
 }
+
+// GlobalTracerProvider returns a TracerProvider that always starts spans from the current global provider.
+// This should be used by instrumentation that holds on to a Tracer so that spans continue to be exported
+// after SetAddress is called.
+func GlobalTracerProvider() trace.TracerProvider {
+	return globalTracerProvider{}
+}
+
+type globalTracerProvider struct{}
+
+func (globalTracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return globalTracer{name, opts}
+}
+
+type globalTracer struct {
+	name string
+	opts []trace.TracerOption
+}
+
+func (t globalTracer) Start(
+	ctx context.Context,
+	spanName string,
+	opts ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	return otel.GetTracerProvider().Tracer(t.name, t.opts...).Start(ctx, spanName, opts...)
+}
@@ -0,0 +1,93 @@
+package start
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/stackup-wallet/stackup-bundler/internal/o11y"
+	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/relay"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// withApiKey returns a Gin middleware that rejects requests without the given key as a bearer token.
+func withApiKey(key string) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		token := strings.TrimPrefix(g.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) != 1 {
+			g.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		g.Next()
+	}
+}
+
+// resolveKeystoreFile returns the path of a keystore file name within dir. Names that are not a plain file
+// name or that resolve outside of dir through a symlink are rejected.
+func resolveKeystoreFile(dir, name string) (string, error) {
+	if dir == "" {
+		return "", errors.New("keystore files are disabled, set erc4337_bundler_admin_keystore_dir to enable")
+	}
+	if name == "." || name == ".." || filepath.Base(name) != name {
+		return "", fmt.Errorf("keystoreFile must be a file name within the keystore directory: %s", name)
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(root, name))
+	if err != nil {
+		return "", err
+	}
+	if filepath.Dir(path) != root {
+		return "", fmt.Errorf("keystoreFile must be a file name within the keystore directory: %s", name)
+	}
+	return path, nil
+}
+
+// rotateSigner returns a RotateSignerFunc that replaces the relayer's signer. Keystore files are only loaded
+// from keystoreDir and decrypted with the configured passphrase. If o11y is enabled, the providers are
+// re-initialized with the new address and useMeters is called to register all instruments again.
+func rotateSigner(
+	relayer *relay.Relayer,
+	keystoreDir string,
+	keystorePassphrase string,
+	o11yEnabled bool,
+	useMeters func() error,
+) client.RotateSignerFunc {
+	return func(src signer.Source, sweep bool) (common.Address, error) {
+		src.PrivateKey = ""
+		src.KeystorePassphrase = ""
+		if src.KeystoreFile != "" {
+			path, err := resolveKeystoreFile(keystoreDir, src.KeystoreFile)
+			if err != nil {
+				return common.Address{}, err
+			}
+			src.KeystoreFile = path
+			src.KeystorePassphrase = keystorePassphrase
+		}
+
+		next, err := signer.NewFromSource(src)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if _, err := relayer.RotateSigner(next, sweep); err != nil {
+			return common.Address{}, err
+		}
+
+		if o11yEnabled {
+			o11y.SetAddress(next.Address)
+			if err := useMeters(); err != nil {
+				return common.Address{}, err
+			}
+		}
+		return next.Address, nil
+	}
+}
@@ -15,18 +15,26 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/internal/config"
 	"github.com/stackup-wallet/stackup-bundler/internal/logger"
 	"github.com/stackup-wallet/stackup-bundler/internal/o11y"
+	"github.com/stackup-wallet/stackup-bundler/pkg/aggregator"
+	"github.com/stackup-wallet/stackup-bundler/pkg/balance"
 	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
 	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/events"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
 	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/access"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/batch"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/checks"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/expire"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/paymaster"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/relay"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/status"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel"
 )
@@ -38,12 +46,24 @@ func PrivateMode() {
 		WithName("stackup_bundler").
 		WithValues("bundler_mode", "private")
 
-	eoa, err := signer.New(conf.PrivateKey)
+	eoa, err := newSigner(conf)
 	if err != nil {
 		log.Fatal(err)
 	}
+	// Rotating the signer through the admin API updates the current EOA for every component.
+	current := signer.NewProvider(eoa)
 	beneficiary := common.HexToAddress(conf.Beneficiary)
 
+	signers := []*signer.EOA{eoa}
+	for _, src := range conf.Signers {
+		s, err := signer.NewFromSource(src)
+		if err != nil {
+			log.Fatal(err)
+		}
+		signers = append(signers, s)
+	}
+	pool := signer.NewPool(signers...)
+
 	db, err := badger.Open(badger.DefaultOptions(conf.DataDirectory))
 	if err != nil {
 		log.Fatal(err)
@@ -63,6 +83,14 @@ func PrivateMode() {
 		log.Fatal(err)
 	}
 
+	for ep, v := range conf.EntryPointVersions {
+		entrypoint.SetVersion(ep, v)
+	}
+	if err := loadEntryPointSimulations(eth, conf.SupportedEntryPoints, conf.EntryPointSimulations); err != nil {
+		log.Fatal(err)
+	}
+	tracer.SetMode(conf.TracerMode)
+
 	if o11y.IsEnabled(conf.OTELServiceName) {
 		o11yOpts := &o11y.Opts{
 			ServiceName:     conf.OTELServiceName,
@@ -101,7 +129,18 @@ func PrivateMode() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	mem.SetCapacity(mempool.Capacity{
+		MaxPoolSize:        conf.MaxMempoolSize,
+		MaxOpsPerPaymaster: conf.MaxOpsPerPaymaster,
+		MaxOpsPerFactory:   conf.MaxOpsPerFactory,
+	})
+	mem.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
+	mem.SetGetNonceFunc(mempool.GetNonceWithEthClient(eth))
 
+	stakeReq := stake.Requirement{
+		MinStake:           conf.MinStake,
+		MinUnstakeDelaySec: uint64(conf.MinUnstakeDelay.Seconds()),
+	}
 	check := checks.New(
 		db,
 		rpc,
@@ -109,20 +148,38 @@ func PrivateMode() {
 		conf.MaxVerificationGas,
 		conf.MaxBatchGasLimit,
 		conf.MaxOpsForUnstakedSender,
-		eoa,
+		current,
 	)
+	check.SetTracingLevel(probeTracingLevel(rpc, conf.SupportedEntryPoints, conf.TracingLevel, logr))
+	check.SetStakeRequirement(stakeReq)
 
 	exp := expire.New(conf.MaxOpTTL)
 
-	relayer := relay.New(eoa, eth, chain, beneficiary, logr)
+	relayer := relay.New(db, current, eth, chain, beneficiary, logr)
+	relayer.SetResubmitAfterBlocks(conf.TxResubmitAfterBlocks)
+	relayer.SetFinalityBlocks(conf.TxFinalityBlocks)
+	relayer.SetSignerPool(pool)
+	relayer.SetAggregateSignaturesFunc(aggregator.AggregateSignaturesWithEthClient(rpc))
+
+	acl := access.New(db)
 
-	paymaster := paymaster.New(db)
+	rep, err := entities.New(db, eth, mem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rep.SetStakeRequirement(stakeReq)
+	rep.SetIsAllowedFunc(acl.IsAllowed)
+
+	opStatus := status.New(db, conf.OpStatusTTL)
+
+	feed := events.New()
+	feed.SetGetUserOpReceiptFunc(client.GetUserOpReceiptWithEthClient(eth))
 
 	// Init Client
 	c := client.New(mem, ov, chain, conf.SupportedEntryPoints)
 	c.SetGetUserOpReceiptFunc(client.GetUserOpReceiptWithEthClient(eth))
 	c.SetGetGasEstimateFunc(client.GetGasEstimateNoTraceWithEthClient(
-		eoa,
+		current,
 		rpc,
 		ov,
 		chain,
@@ -131,12 +188,20 @@ func PrivateMode() {
 	))
 	// c.SetGetGasEstimateFunc(client.GetGasEstimateWithEthClient(rpc, ov, chain, conf.MaxBatchGasLimit))
 	c.SetGetUserOpByHashFunc(client.GetUserOpByHashWithEthClient(eth))
+	c.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
+	c.SetGetGasTipFunc(gasprice.GetGasTipWithEthClient(eth))
+	c.SetGetLegacyGasPriceFunc(gasprice.GetLegacyGasPriceWithEthClient(eth))
+	c.SetGetUserOpStatusFunc(opStatus.GetStatus)
+	c.SetMarkUserOpAddedFunc(opStatus.MarkAdded)
 	c.UseLogger(logr)
+	c.UseEventFeed(feed)
 	c.UseModules(
 		check.ValidateOpValues(),
-		paymaster.CheckStatus(),
+		acl.CheckDenied(),
+		rep.CheckStatus(),
 		check.SimulateOp(),
-		paymaster.IncOpsSeen(),
+		rep.CheckAggregatorStatus(),
+		rep.IncOpsSeen(),
 	)
 
 	// Init Bundler
@@ -144,32 +209,80 @@ func PrivateMode() {
 	b.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
 	b.SetGetGasTipFunc(gasprice.GetGasTipWithEthClient(eth))
 	b.SetGetLegacyGasPriceFunc(gasprice.GetLegacyGasPriceWithEthClient(eth))
-	b.UseLogger(logr)
-	if err := b.UserMeter(otel.GetMeterProvider().Meter("bundler")); err != nil {
-		log.Fatal(err)
+	b.SetTrigger(newBundlerTrigger(eth, conf.BundleTrigger, conf.BundleInterval))
+	b.SetMinBatch(conf.BundleMinOps)
+	b.SetMaxWait(conf.BundleMaxWait)
+	if conf.DebugMode {
+		b.SetMaxBatch(1)
+		relayer.SetWaitTimeout(0)
+	} else {
+		b.SetSignerPool(pool)
 	}
+	b.UseLogger(logr)
+	b.UseTrackerModules(
+		relayer.TrackTransactions(),
+		rep.IncOpsIncluded(),
+		opStatus.Track(),
+		feed.PublishRemovedOps(),
+	)
 	b.UseModules(
+		relayer.ExcludeInflight(),
 		exp.DropExpired(),
+		acl.DropDenied(),
 		gasprice.SortByGasPrice(),
 		// gasprice.FilterUnderpriced(),
 		batch.SortByNonce(),
 		batch.MaintainGasLimit(conf.MaxBatchGasLimit),
 		check.CodeHashes(),
 		check.PaymasterDeposit(),
+		check.Aggregators(),
+		rep.FilterByStatus(),
 		relayer.SendUserOperation(),
-		paymaster.IncOpsIncluded(),
+		rep.IncOpsIncluded(),
 		check.Clean(),
+		opStatus.Track(),
+		feed.PublishRemovedOps(),
+	)
+	b.UseQueuedModules(
+		exp.DropExpired(),
+		opStatus.Track(),
+		feed.PublishRemovedOps(),
 	)
 	if err := b.Run(); err != nil {
 		log.Fatal(err)
 	}
 
+	// Init balance monitor
+	mon := balance.New(eth, chain, pool, conf.MinSignerBalance)
+	mon.SetGetNonceFunc(relayer.GetNextNonce)
+	mon.UseLogger(logr)
+	if conf.BeneficiaryPrivateKey != "" {
+		sweep, err := signer.New(conf.BeneficiaryPrivateKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mon.SetSweepEOA(sweep)
+	}
+	if conf.RefillFromDeposit {
+		mon.SetWithdrawFromEntryPoints(conf.SupportedEntryPoints)
+	}
+	useMeters := func() error {
+		if err := b.UserMeter(otel.GetMeterProvider().Meter("bundler")); err != nil {
+			return err
+		}
+		return mon.UseMeter(otel.GetMeterProvider().Meter("bundler"))
+	}
+	if err := useMeters(); err != nil {
+		log.Fatal(err)
+	}
+	if err := mon.Run(); err != nil {
+		log.Fatal(err)
+	}
+
 	// init Debug
 	var d *client.Debug
 	if conf.DebugMode {
-		d = client.NewDebug(eoa, eth, mem, b, chain, conf.SupportedEntryPoints[0], beneficiary)
-		b.SetMaxBatch(1)
-		relayer.SetWaitTimeout(0)
+		d = client.NewDebug(current, eth, mem, rep, b, chain, conf.SupportedEntryPoints[0], beneficiary)
 	}
 
 	// Init HTTP server
@@ -179,7 +292,7 @@ func PrivateMode() {
 		log.Fatal(err)
 	}
 	if o11y.IsEnabled(conf.OTELServiceName) {
-		r.Use(otelgin.Middleware(conf.OTELServiceName))
+		r.Use(otelgin.Middleware(conf.OTELServiceName, otelgin.WithTracerProvider(o11y.GlobalTracerProvider())))
 	}
 	r.Use(
 		cors.Default(),
@@ -189,16 +302,43 @@ func PrivateMode() {
 	r.GET("/ping", func(g *gin.Context) {
 		g.Status(http.StatusOK)
 	})
+	r.GET("/health", func(g *gin.Context) {
+		if mon.IsPaused() {
+			g.JSON(http.StatusServiceUnavailable, gin.H{"status": "paused", "reason": "signer balance below floor"})
+			return
+		}
+		g.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
 	r.GET("/check", func(g *gin.Context) {
 		g.Writer.Write([]byte("Welcome EIP-4337"))
 		g.Status(http.StatusOK)
 	})
+	api := client.NewRpcAdapter(c, d)
 	handlers := []gin.HandlerFunc{
-		jsonrpc.Controller(client.NewRpcAdapter(c, d)),
+		jsonrpc.Controller(api),
 		jsonrpc.WithOTELTracerAttributes(),
 	}
 	r.POST("/", handlers...)
 	r.POST("/rpc", handlers...)
+	r.GET("/ws", jsonrpc.WebSocketController(api, feed.Subscribe))
+	if conf.AdminApiKey != "" {
+		a := client.NewAdmin()
+		a.SetRotateSignerFunc(rotateSigner(
+			relayer,
+			conf.AdminKeystoreDir,
+			conf.KeystorePassphrase,
+			o11y.IsEnabled(conf.OTELServiceName),
+			useMeters,
+		))
+		a.SetUpdateAccessListFunc(acl.Set)
+		a.SetDumpAccessListFunc(acl.Dump)
+		r.POST(
+			"/admin",
+			withApiKey(conf.AdminApiKey),
+			jsonrpc.Controller(client.NewAdminRpcAdapter(a)),
+			jsonrpc.WithOTELTracerAttributes(),
+		)
+	}
 
 	if err := r.Run(fmt.Sprintf(":%d", conf.Port)); err != nil {
 		log.Fatal(err)
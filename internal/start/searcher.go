@@ -16,18 +16,23 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/internal/config"
 	"github.com/stackup-wallet/stackup-bundler/internal/logger"
 	"github.com/stackup-wallet/stackup-bundler/internal/o11y"
+	"github.com/stackup-wallet/stackup-bundler/pkg/aggregator"
 	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
 	"github.com/stackup-wallet/stackup-bundler/pkg/client"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
 	"github.com/stackup-wallet/stackup-bundler/pkg/jsonrpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/access"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/batch"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/builder"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/checks"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/expire"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
-	"github.com/stackup-wallet/stackup-bundler/pkg/modules/paymaster"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel"
 )
@@ -39,10 +44,11 @@ func SearcherMode() {
 		WithName("stackup_bundler").
 		WithValues("bundler_mode", "searcher")
 
-	eoa, err := signer.New(conf.PrivateKey)
+	eoa, err := newSigner(conf)
 	if err != nil {
 		log.Fatal(err)
 	}
+	current := signer.NewProvider(eoa)
 	beneficiary := common.HexToAddress(conf.Beneficiary)
 
 	db, err := badger.Open(badger.DefaultOptions(conf.DataDirectory))
@@ -65,6 +71,14 @@ func SearcherMode() {
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	for ep, v := range conf.EntryPointVersions {
+		entrypoint.SetVersion(ep, v)
+	}
+	if err := loadEntryPointSimulations(eth, conf.SupportedEntryPoints, conf.EntryPointSimulations); err != nil {
+		log.Fatal(err)
+	}
+	tracer.SetMode(conf.TracerMode)
 	if !builder.CompatibleChainIDs.Contains(chain.Uint64()) {
 		log.Fatalf(
 			"error: network with chainID %d is not compatible with the Block Builder API.",
@@ -96,7 +110,18 @@ func SearcherMode() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	mem.SetCapacity(mempool.Capacity{
+		MaxPoolSize:        conf.MaxMempoolSize,
+		MaxOpsPerPaymaster: conf.MaxOpsPerPaymaster,
+		MaxOpsPerFactory:   conf.MaxOpsPerFactory,
+	})
+	mem.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
+	mem.SetGetNonceFunc(mempool.GetNonceWithEthClient(eth))
 
+	stakeReq := stake.Requirement{
+		MinStake:           conf.MinStake,
+		MinUnstakeDelaySec: uint64(conf.MinUnstakeDelay.Seconds()),
+	}
 	check := checks.New(
 		db,
 		rpc,
@@ -104,27 +129,42 @@ func SearcherMode() {
 		conf.MaxVerificationGas,
 		conf.MaxBatchGasLimit,
 		conf.MaxOpsForUnstakedSender,
-		eoa,
+		current,
 	)
+	check.SetTracingLevel(probeTracingLevel(rpc, conf.SupportedEntryPoints, conf.TracingLevel, logr))
+	check.SetStakeRequirement(stakeReq)
 
 	exp := expire.New(conf.MaxOpTTL)
 
 	// TODO: Create separate go-routine for tracking transactions sent to the block builder.
 	builder := builder.New(eoa, eth, fb, beneficiary, conf.BlocksInTheFuture)
-	paymaster := paymaster.New(db)
+	builder.SetAggregateSignaturesFunc(aggregator.AggregateSignaturesWithEthClient(rpc))
+	acl := access.New(db)
+
+	rep, err := entities.New(db, eth, mem)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rep.SetStakeRequirement(stakeReq)
+	rep.SetIsAllowedFunc(acl.IsAllowed)
 
 	// Init Client
 	c := client.New(mem, ov, chain, conf.SupportedEntryPoints)
 	c.SetGetUserOpReceiptFunc(client.GetUserOpReceiptWithEthClient(eth))
 	c.SetGetGasEstimateFunc(client.GetGasEstimateWithEthClient(rpc, ov, chain, conf.MaxBatchGasLimit))
 	c.SetGetUserOpByHashFunc(client.GetUserOpByHashWithEthClient(eth))
+	c.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
+	c.SetGetGasTipFunc(gasprice.GetGasTipWithEthClient(eth))
+	c.SetGetLegacyGasPriceFunc(gasprice.GetLegacyGasPriceWithEthClient(eth))
 	c.UseLogger(logr)
 	c.UseModules(
 		check.ValidateOpValues(),
-		paymaster.CheckStatus(),
+		acl.CheckDenied(),
+		rep.CheckStatus(),
 		check.SimulateOp(),
+		rep.CheckAggregatorStatus(),
 		// TODO: add p2p propagation module
-		paymaster.IncOpsSeen(),
+		rep.IncOpsSeen(),
 	)
 
 	// Init Bundler
@@ -132,22 +172,31 @@ func SearcherMode() {
 	b.SetGetBaseFeeFunc(gasprice.GetBaseFeeWithEthClient(eth))
 	b.SetGetGasTipFunc(gasprice.GetGasTipWithEthClient(eth))
 	b.SetGetLegacyGasPriceFunc(gasprice.GetLegacyGasPriceWithEthClient(eth))
+	b.SetTrigger(newBundlerTrigger(eth, conf.BundleTrigger, conf.BundleInterval))
+	b.SetMinBatch(conf.BundleMinOps)
+	b.SetMaxWait(conf.BundleMaxWait)
 	b.UseLogger(logr)
 	if err := b.UserMeter(otel.GetMeterProvider().Meter("bundler")); err != nil {
 		log.Fatal(err)
 	}
 	b.UseModules(
 		exp.DropExpired(),
+		acl.DropDenied(),
 		gasprice.SortByGasPrice(),
 		gasprice.FilterUnderpriced(),
 		batch.SortByNonce(),
 		batch.MaintainGasLimit(conf.MaxBatchGasLimit),
 		check.CodeHashes(),
 		check.PaymasterDeposit(),
+		check.Aggregators(),
+		rep.FilterByStatus(),
 		builder.SendUserOperation(),
-		paymaster.IncOpsIncluded(),
+		rep.IncOpsIncluded(),
 		check.Clean(),
 	)
+	b.UseQueuedModules(
+		exp.DropExpired(),
+	)
 	if err := b.Run(); err != nil {
 		log.Fatal(err)
 	}
@@ -155,7 +204,7 @@ func SearcherMode() {
 	// init Debug
 	var d *client.Debug
 	if conf.DebugMode {
-		d = client.NewDebug(eoa, eth, mem, b, chain, conf.SupportedEntryPoints[0], beneficiary)
+		d = client.NewDebug(current, eth, mem, rep, b, chain, conf.SupportedEntryPoints[0], beneficiary)
 		b.SetMaxBatch(1)
 	}
 
@@ -186,6 +235,19 @@ func SearcherMode() {
 	}
 	r.POST("/", handlers...)
 	r.POST("/rpc", handlers...)
+	if conf.AdminApiKey != "" {
+		// Signer rotation is not supported since the block builder is bound to a single EOA.
+		a := client.NewAdmin()
+		a.SetUpdateAccessListFunc(acl.Set)
+		a.SetDumpAccessListFunc(acl.Dump)
+		r.POST(
+			"/admin",
+			withApiKey(conf.AdminApiKey),
+			jsonrpc.Controller(client.NewAdminRpcAdapter(a)),
+			jsonrpc.WithOTELTracerAttributes(),
+		)
+	}
+
 	if err := r.Run(fmt.Sprintf(":%d", conf.Port)); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,17 @@
+package start
+
+import (
+	"github.com/stackup-wallet/stackup-bundler/internal/config"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// newSigner returns the bundler's EOA from whichever signer source is configured.
+func newSigner(conf *config.Values) (*signer.EOA, error) {
+	return signer.NewFromSource(signer.Source{
+		PrivateKey:          conf.PrivateKey,
+		KeystoreFile:        conf.KeystoreFile,
+		KeystorePassphrase:  conf.KeystorePassphrase,
+		RemoteSignerUrl:     conf.RemoteSignerUrl,
+		RemoteSignerAddress: conf.RemoteSignerAddress,
+	})
+}
@@ -0,0 +1,61 @@
+package start
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// loadEntryPointSimulations fetches the code of a deployed EntryPointSimulations contract if any of the
+// supported EntryPoints are v0.7. This code is used as a state override for all v0.7 simulations.
+func loadEntryPointSimulations(eth *ethclient.Client, eps []common.Address, sim common.Address) error {
+	for _, ep := range eps {
+		if entrypoint.GetVersion(ep) != userop.V07 {
+			continue
+		}
+
+		code, err := eth.CodeAt(context.Background(), sim, nil)
+		if err != nil {
+			return err
+		}
+		if len(code) == 0 {
+			return fmt.Errorf("EntryPointSimulations: code not deployed at %s", sim)
+		}
+
+		utils.SetSimulationsV07Code(code)
+		return nil
+	}
+
+	return nil
+}
+
+// probeTracingLevel checks if the node supports the tracing required for the configured level and logs the
+// effective level that the bundler will run with.
+func probeTracingLevel(
+	rpc *rpc.Client,
+	eps []common.Address,
+	level simulation.TracingLevel,
+	l logr.Logger,
+) simulation.TracingLevel {
+	effective, err := simulation.ProbeTracingLevel(rpc, eps[0], level)
+	if err != nil {
+		l.Error(err, "debug_traceCall not supported, ERC-7562 validation rules are disabled")
+	}
+
+	l.Info(
+		"tracing level",
+		"configured", string(level),
+		"effective", string(effective),
+		"tracer_mode", string(tracer.GetMode()),
+	)
+	return effective
+}
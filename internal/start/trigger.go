@@ -0,0 +1,16 @@
+package start
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
+)
+
+// newBundlerTrigger returns the TriggerFunc for the configured trigger type.
+func newBundlerTrigger(eth *ethclient.Client, t bundler.TriggerType, interval time.Duration) bundler.TriggerFunc {
+	if t == bundler.NewHeadsTrigger {
+		return bundler.NewHeadsTriggerWithEthClient(eth, interval)
+	}
+	return bundler.NewIntervalTrigger(interval)
+}
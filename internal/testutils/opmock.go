@@ -22,6 +22,23 @@ var (
 		"preVerificationGas":   "0xc539",
 		"signature":            "0xa925dcc5e5131636e244d4405334c25f034ebdd85c0cb12e8cdb13c15249c2d466d0bade18e2cafd3513497f7f968dcbb63e519acd9b76dcae7acd61f11aa8421b",
 	}
+	MockPackedUserOpData = map[string]any{
+		"sender":                        "0xa13D69573f994bf662C2714560c44dd7266FC547",
+		"nonce":                         "0x0",
+		"factory":                       "0xe19E9755942BB0bD0cCCCe25B1742596b8A8250b",
+		"factoryData":                   "0x3bf2c3e700000000000000000000000078d4f01f56b982a3b03c4e127a5d3afa8ebee6860000000000000000000000008b388a082f370d8ac2e2b3997e9151168bd09ff50000000000000000000000000000000000000000000000000000000000000000",
+		"callData":                      "0x80c5c7d0000000000000000000000000a13d69573f994bf662c2714560c44dd7266fc547000000000000000000000000000000000000000000000000016345785d8a000000000000000000000000000000000000000000000000000000000000000000600000000000000000000000000000000000000000000000000000000000000000",
+		"callGasLimit":                  "0x558c",
+		"verificationGasLimit":          "0x129727",
+		"maxFeePerGas":                  "0xa862145e",
+		"maxPriorityFeePerGas":          "0xa8621440",
+		"paymaster":                     "0x7357C8D931e8cde8ea1b777Cf8578f4A7071f100",
+		"paymasterVerificationGasLimit": "0x186a0",
+		"paymasterPostOpGasLimit":       "0xc350",
+		"paymasterData":                 "0x1234",
+		"preVerificationGas":            "0xc539",
+		"signature":                     "0xa925dcc5e5131636e244d4405334c25f034ebdd85c0cb12e8cdb13c15249c2d466d0bade18e2cafd3513497f7f968dcbb63e519acd9b76dcae7acd61f11aa8421b",
+	}
 	MockByteCode = common.Hex2Bytes("6080604052")
 )
 
@@ -31,11 +48,18 @@ func MockValidInitUserOp() *userop.UserOperation {
 	return op
 }
 
+// Returns a valid initial userOperation in the EntryPoint v0.7 format with a paymaster.
+func MockValidPackedUserOp() *userop.UserOperation {
+	op, _ := userop.New(MockPackedUserOpData)
+	return op
+}
+
 func IsOpsEqual(op1 *userop.UserOperation, op2 *userop.UserOperation) bool {
 	return cmp.Equal(
 		op1,
 		op2,
 		cmp.Comparer(func(a *big.Int, b *big.Int) bool { return a.Cmp(b) == 0 }),
+		cmp.AllowUnexported(userop.UserOperation{}),
 	)
 }
 
@@ -44,5 +68,6 @@ func GetOpsDiff(op1 *userop.UserOperation, op2 *userop.UserOperation) string {
 		op1,
 		op2,
 		cmp.Comparer(func(a *big.Int, b *big.Int) bool { return a.Cmp(b) == 0 }),
+		cmp.AllowUnexported(userop.UserOperation{}),
 	)
 }
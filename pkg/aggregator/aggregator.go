@@ -0,0 +1,131 @@
+// Package aggregator provides methods for interacting with EIP-4337 signature aggregator contracts.
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func toAbiType(ops []*userop.UserOperation) []entrypoint.UserOperation {
+	abiOps := []entrypoint.UserOperation{}
+	for _, op := range ops {
+		abiOps = append(abiOps, entrypoint.ToUserOperation(op))
+	}
+	return abiOps
+}
+
+func toPackedAbiType(ops []*userop.UserOperation) []userop.PackedUserOperation {
+	abiOps := []userop.PackedUserOperation{}
+	for _, op := range ops {
+		abiOps = append(abiOps, op.ToPacked())
+	}
+	return abiOps
+}
+
+// ErrReverted is wrapped by errors caused by a call to an aggregator that reverted. Any other error is caused
+// by the node or transport and the call can be retried.
+var ErrReverted = errors.New("call reverted")
+
+// revertErrorCode is the JSON-RPC error code returned by nodes for a call that reverted.
+const revertErrorCode = 3
+
+func isReverted(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == revertErrorCode {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+func call(rpc *rpc.Client, aggregator common.Address, method abi.Method, args ...any) ([]byte, error) {
+	data, err := method.Inputs.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var out hexutil.Bytes
+	req := utils.TraceCallReq{
+		To:   aggregator,
+		Data: append(method.ID, data...),
+	}
+	if err := rpc.CallContext(context.Background(), &out, "eth_call", &req, "latest"); isReverted(err) {
+		return nil, fmt.Errorf("%w: %s", ErrReverted, err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	ret, err := method.Outputs.Unpack(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", method.Name, err)
+	}
+	if len(ret) != 1 {
+		return nil, fmt.Errorf("%s: invalid output length: expected 1, got %d", method.Name, len(ret))
+	}
+
+	b, ok := ret[0].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%s: cannot assert type: output is not of type []byte", method.Name)
+	}
+	return b, nil
+}
+
+// ValidateUserOpSignature calls validateUserOpSignature on the aggregator to verify the signature of a single
+// UserOperation. It returns the value that the aggregator expects to be used as the signature of the
+// UserOperation in a bundle.
+func ValidateUserOpSignature(
+	rpc *rpc.Client,
+	aggregator common.Address,
+	op *userop.UserOperation,
+) ([]byte, error) {
+	if op.Version() == userop.V07 {
+		return call(rpc, aggregator, ValidateUserOpSignatureV07Method, op.ToPacked())
+	}
+	return call(rpc, aggregator, ValidateUserOpSignatureMethod, entrypoint.ToUserOperation(op))
+}
+
+// AggregateSignatures calls aggregateSignatures on the aggregator to combine the signatures of all
+// UserOperations in a batch into a single signature.
+func AggregateSignatures(
+	rpc *rpc.Client,
+	aggregator common.Address,
+	ops []*userop.UserOperation,
+) ([]byte, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("aggregateSignatures: batch is empty")
+	}
+
+	if ops[0].Version() == userop.V07 {
+		return call(rpc, aggregator, AggregateSignaturesV07Method, toPackedAbiType(ops))
+	}
+	return call(rpc, aggregator, AggregateSignaturesMethod, toAbiType(ops))
+}
+
+// AggregateSignaturesFunc provides a general interface for combining the signatures of a group of
+// UserOperations that share the same aggregator.
+type AggregateSignaturesFunc = func(aggregator common.Address, ops []*userop.UserOperation) ([]byte, error)
+
+// NoopAggregateSignaturesFunc returns an error for every call. It should be used when signature aggregation is
+// not supported.
+func NoopAggregateSignaturesFunc() AggregateSignaturesFunc {
+	return func(aggregator common.Address, ops []*userop.UserOperation) ([]byte, error) {
+		return nil, errors.New("aggregator: signature aggregation is not supported")
+	}
+}
+
+// AggregateSignaturesWithEthClient returns an implementation of AggregateSignaturesFunc that relies on an
+// eth client to call the aggregator contract.
+func AggregateSignaturesWithEthClient(rpc *rpc.Client) AggregateSignaturesFunc {
+	return func(aggregator common.Address, ops []*userop.UserOperation) ([]byte, error) {
+		return AggregateSignatures(rpc, aggregator, ops)
+	}
+}
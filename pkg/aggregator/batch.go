@@ -0,0 +1,68 @@
+package aggregator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// withSignature returns a shallow copy of op with the signature replaced.
+func withSignature(op *userop.UserOperation, sig []byte) *userop.UserOperation {
+	cp := *op
+	cp.Signature = sig
+	return &cp
+}
+
+// GetOpsPerAggregator splits a batch that has already been grouped by aggregator into the format expected by
+// handleAggregatedOps() and combines the signatures for each group. Signatures are aggregated from the
+// original ops and then each op is sent with the sigForUserOp returned during validation. If the aggregator
+// reverts for a group, all ops in that group are marked for removal and an error wrapping ErrReverted is
+// returned. Any other error is returned as is so that the batch can be retried.
+func GetOpsPerAggregator(
+	ctx *modules.BatchHandlerCtx,
+	aggregate AggregateSignaturesFunc,
+) ([]transaction.UserOpsPerAggregator, error) {
+	opa := []transaction.UserOpsPerAggregator{}
+	start := []int{}
+	for i, op := range ctx.Batch {
+		agg := ctx.GetAggregator(op)
+		if len(opa) == 0 || opa[len(opa)-1].Aggregator != agg {
+			opa = append(opa, transaction.UserOpsPerAggregator{Aggregator: agg})
+			start = append(start, i)
+		}
+		opa[len(opa)-1].UserOps = append(opa[len(opa)-1].UserOps, op)
+	}
+
+	for i, g := range opa {
+		if g.Aggregator == common.HexToAddress("0x") {
+			continue
+		}
+
+		sig, err := aggregate(g.Aggregator, g.UserOps)
+		if errors.Is(err, ErrReverted) {
+			err = fmt.Errorf("aggregator %s: %w", g.Aggregator, err)
+			for j := start[i] + len(g.UserOps) - 1; j >= start[i]; j-- {
+				ctx.MarkOpIndexForRemoval(j, err.Error())
+			}
+			return nil, err
+		} else if err != nil {
+			return nil, fmt.Errorf("aggregator %s: %w", g.Aggregator, err)
+		}
+		opa[i].Signature = sig
+
+		ops := []*userop.UserOperation{}
+		for _, op := range g.UserOps {
+			if sigForUserOp, ok := ctx.GetSigForUserOp(op); ok {
+				op = withSignature(op, sigForUserOp)
+			}
+			ops = append(ops, op)
+		}
+		opa[i].UserOps = ops
+	}
+
+	return opa, nil
+}
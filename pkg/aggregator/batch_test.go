@@ -0,0 +1,97 @@
+package aggregator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestGetOpsPerAggregatorUsesSigForUserOp calls aggregator.GetOpsPerAggregator and verifies that signatures
+// are aggregated from the original ops while the ops in the group carry the sigForUserOp from validation.
+func TestGetOpsPerAggregatorUsesSigForUserOp(t *testing.T) {
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(1)
+	op2.Signature = []byte{0x01}
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	ctx.SetAggregator(op2, testutils.ValidAddress2)
+	ctx.SetSigForUserOp(op2, []byte{})
+
+	aggregate := func(agg common.Address, ops []*userop.UserOperation) ([]byte, error) {
+		if len(ops) != 1 || !bytes.Equal(ops[0].Signature, []byte{0x01}) {
+			t.Fatalf("got unexpected ops for aggregation, want original op")
+		}
+		return []byte{0x02}, nil
+	}
+	opa, err := GetOpsPerAggregator(ctx, aggregate)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(opa) != 2 {
+		t.Fatalf("got %d groups, want 2", len(opa))
+	}
+	if opa[1].Aggregator != testutils.ValidAddress2 {
+		t.Fatalf("got aggregator %s, want %s", opa[1].Aggregator, testutils.ValidAddress2)
+	}
+	if !bytes.Equal(opa[1].Signature, []byte{0x02}) {
+		t.Fatalf("got signature %x, want 02", opa[1].Signature)
+	}
+	if len(opa[1].UserOps[0].Signature) != 0 {
+		t.Fatalf("got op signature %x, want sigForUserOp", opa[1].UserOps[0].Signature)
+	}
+	if !bytes.Equal(op2.Signature, []byte{0x01}) {
+		t.Fatal("got modified op in batch, want unchanged")
+	}
+}
+
+// TestGetOpsPerAggregatorOnlyDropsReverted calls aggregator.GetOpsPerAggregator and verifies that ops are
+// only marked for removal when the aggregator reverts and not on any other error.
+func TestGetOpsPerAggregatorOnlyDropsReverted(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		removed int
+	}{
+		{"transport error", errors.New("connection refused"), 0},
+		{"reverted", fmt.Errorf("%w: execution reverted", ErrReverted), 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			op := testutils.MockValidInitUserOp()
+			ctx := modules.NewBatchHandlerContext(
+				[]*userop.UserOperation{op},
+				testutils.ValidAddress1,
+				testutils.ChainID,
+				nil,
+				nil,
+				nil,
+			)
+			ctx.SetAggregator(op, testutils.ValidAddress2)
+
+			aggregate := func(agg common.Address, ops []*userop.UserOperation) ([]byte, error) {
+				return nil, c.err
+			}
+			if _, err := GetOpsPerAggregator(ctx, aggregate); !errors.Is(err, c.err) {
+				t.Fatalf("got %v, want %v", err, c.err)
+			}
+			if len(ctx.PendingRemoval) != c.removed {
+				t.Fatalf("got %d ops pending removal, want %d", len(ctx.PendingRemoval), c.removed)
+			}
+		})
+	}
+}
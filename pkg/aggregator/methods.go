@@ -0,0 +1,70 @@
+package aggregator
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	bytesT, _ = abi.NewType("bytes", "", nil)
+
+	ValidateUserOpSignatureMethod = abi.NewMethod(
+		"validateUserOpSignature",
+		"validateUserOpSignature",
+		abi.Function,
+		"view",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "userOp", Type: userop.UserOpType},
+		},
+		abi.Arguments{
+			{Name: "sigForUserOp", Type: bytesT},
+		},
+	)
+
+	ValidateUserOpSignatureV07Method = abi.NewMethod(
+		"validateUserOpSignature",
+		"validateUserOpSignature",
+		abi.Function,
+		"view",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "userOp", Type: userop.PackedUserOpType},
+		},
+		abi.Arguments{
+			{Name: "sigForUserOp", Type: bytesT},
+		},
+	)
+
+	AggregateSignaturesMethod = abi.NewMethod(
+		"aggregateSignatures",
+		"aggregateSignatures",
+		abi.Function,
+		"view",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "userOps", Type: userop.UserOpArr},
+		},
+		abi.Arguments{
+			{Name: "aggregatedSignature", Type: bytesT},
+		},
+	)
+
+	AggregateSignaturesV07Method = abi.NewMethod(
+		"aggregateSignatures",
+		"aggregateSignatures",
+		abi.Function,
+		"view",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "userOps", Type: userop.PackedUserOpArr},
+		},
+		abi.Arguments{
+			{Name: "aggregatedSignature", Type: bytesT},
+		},
+	)
+)
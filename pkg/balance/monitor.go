@@ -0,0 +1,301 @@
+// Package balance implements a background monitor for the native balance of the bundler's signer EOAs.
+package balance
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DefaultInterval is the default time between each balance check.
+var DefaultInterval = 10 * time.Second
+
+// GetNonceFunc returns the nonce for the next transaction from an EOA.
+type GetNonceFunc = func(addr common.Address) (*big.Int, error)
+
+// NoopGetNonceFunc returns a nil nonce so that the next pending nonce from the node is used.
+func NoopGetNonceFunc() GetNonceFunc {
+	return func(addr common.Address) (*big.Int, error) {
+		return nil, nil
+	}
+}
+
+// Monitor periodically checks the native balance of every EOA in a signer pool. An EOA with a balance below
+// the floor is paused so that no new batches are assigned to it. If a refill source is set, the monitor will
+// attempt to top up a paused EOA to twice the floor.
+type Monitor struct {
+	eth          *ethclient.Client
+	chainID      *big.Int
+	signers      *signer.Pool
+	floor        *big.Int
+	interval     time.Duration
+	sweep        *signer.EOA
+	withdrawFrom []common.Address
+	getNonce     GetNonceFunc
+	logger       logr.Logger
+	refills      map[common.Address]common.Hash
+	mu           sync.Mutex
+	isRunning    bool
+	done         chan bool
+}
+
+// New initializes a new balance monitor for all EOAs in the pool. A floor of 0 will never pause an EOA.
+func New(eth *ethclient.Client, chainID *big.Int, signers *signer.Pool, floor *big.Int) *Monitor {
+	return &Monitor{
+		eth:          eth,
+		chainID:      chainID,
+		signers:      signers,
+		floor:        floor,
+		interval:     DefaultInterval,
+		sweep:        nil,
+		withdrawFrom: []common.Address{},
+		getNonce:     NoopGetNonceFunc(),
+		logger:       logger.NewZeroLogr().WithName("balance"),
+		refills:      make(map[common.Address]common.Hash),
+		isRunning:    false,
+		done:         make(chan bool),
+	}
+}
+
+// SetInterval defines the time between each balance check. The default value is 10 seconds.
+func (m *Monitor) SetInterval(interval time.Duration) {
+	m.interval = interval
+}
+
+// SetSweepEOA defines an EOA that funds will be transferred from to refill a signer with a balance below the
+// floor. This is usually the beneficiary when its key is held by the bundler. The default is nil (i.e. no
+// sweeping).
+func (m *Monitor) SetSweepEOA(eoa *signer.EOA) {
+	m.sweep = eoa
+}
+
+// SetWithdrawFromEntryPoints defines the EntryPoints that a signer with a balance below the floor will
+// withdraw its own deposit from using withdrawTo(). This is attempted before sweeping. The default is to not
+// withdraw from any EntryPoint.
+func (m *Monitor) SetWithdrawFromEntryPoints(entryPoints []common.Address) {
+	m.withdrawFrom = entryPoints
+}
+
+// SetGetNonceFunc defines the function used to get the nonce of a signer withdrawing its deposit. This should
+// account for any batches from the signer that are still in flight. The default is to use the next pending
+// nonce from the node.
+func (m *Monitor) SetGetNonceFunc(fn GetNonceFunc) {
+	m.getNonce = fn
+}
+
+// UseLogger defines the logger object used by the Monitor instance based on the go-logr/logr interface.
+func (m *Monitor) UseLogger(logger logr.Logger) {
+	m.logger = logger.WithName("balance")
+}
+
+// UseMeter defines an opentelemetry meter object used by the Monitor instance to report the balance of each
+// signer in ether.
+func (m *Monitor) UseMeter(meter metric.Meter) error {
+	_, err := meter.Float64ObservableGauge(
+		"bundler_signer_balance",
+		metric.WithFloat64Callback(func(ctx context.Context, o metric.Float64Observer) error {
+			for _, eoa := range m.signers.EOAs() {
+				bal := m.signers.GetBalance(eoa.Address)
+				if bal == nil {
+					continue
+				}
+
+				eth, _ := big.NewFloat(0).Quo(new(big.Float).SetInt(bal), big.NewFloat(params.Ether)).Float64()
+				o.Observe(eth, metric.WithAttributes(attribute.String("address", eoa.Address.Hex())))
+			}
+			return nil
+		}),
+	)
+	return err
+}
+
+// IsPaused returns true if every signer is paused due to a low balance.
+func (m *Monitor) IsPaused() bool {
+	return m.signers.AllPaused()
+}
+
+// Check fetches the balance of every signer and updates its paused status. A refill is attempted for every
+// signer below the floor that has not been retired.
+func (m *Monitor) Check() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, eoa := range m.signers.EOAs() {
+		bal, err := m.eth.BalanceAt(context.Background(), eoa.Address, nil)
+		if err != nil {
+			return err
+		}
+		m.signers.SetBalance(eoa.Address, bal)
+
+		l := m.logger.WithValues("signer", eoa.Address.String(), "balance", bal.String(), "floor", m.floor.String())
+		below := bal.Cmp(m.floor) < 0
+		if below && !m.signers.IsPaused(eoa.Address) {
+			l.Error(errors.New("balance below floor"), "signer paused")
+		} else if !below && m.signers.IsPaused(eoa.Address) {
+			l.Info("signer resumed")
+		}
+		m.signers.SetPaused(eoa.Address, below)
+
+		if below && !m.signers.IsRetired(eoa.Address) {
+			if err := m.refill(eoa, bal); err != nil {
+				l.Error(err, "signer refill error")
+			}
+		}
+	}
+	return nil
+}
+
+// isRefillPending returns true if a previous refill transaction for the signer has not been included yet.
+func (m *Monitor) isRefillPending(addr common.Address) (bool, error) {
+	hash, ok := m.refills[addr]
+	if !ok {
+		return false, nil
+	}
+
+	_, err := m.eth.TransactionReceipt(context.Background(), hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	delete(m.refills, addr)
+	return false, nil
+}
+
+func (m *Monitor) refill(eoa *signer.EOA, bal *big.Int) error {
+	if pending, err := m.isRefillPending(eoa.Address); err != nil || pending {
+		return err
+	}
+
+	target := big.NewInt(0).Mul(m.floor, common.Big2)
+	needed := big.NewInt(0).Sub(target, bal)
+	// A busy signer may be sending a batch. Withdrawing at the same time would race for the same nonce.
+	withdrawFrom := m.withdrawFrom
+	if m.signers.IsBusy(eoa.Address) {
+		withdrawFrom = []common.Address{}
+	}
+	for _, ep := range withdrawFrom {
+		txn, err := m.withdraw(eoa, ep)
+		if err != nil {
+			return err
+		} else if txn != nil {
+			m.refills[eoa.Address] = txn.Hash()
+			m.logger.Info("signer refill sent", "signer", eoa.Address.String(), "entrypoint", ep.String(),
+				"txn_hash", txn.Hash().String())
+			return nil
+		}
+	}
+
+	if m.sweep == nil || m.sweep.Address == eoa.Address {
+		return nil
+	}
+	fees, err := transaction.SuggestFees(m.eth)
+	if err != nil {
+		return err
+	}
+	available, err := m.eth.BalanceAt(context.Background(), m.sweep.Address, nil)
+	if err != nil {
+		return err
+	}
+	available = available.Sub(available, fees.MaxCost(transaction.TransferGasLimit))
+	amount := needed
+	if available.Cmp(amount) < 0 {
+		amount = available
+	}
+	if amount.Sign() <= 0 {
+		return errors.New("balance: sweep EOA has insufficient funds")
+	}
+
+	txn, err := transaction.Transfer(m.sweep, m.eth, m.chainID, eoa.Address, amount, fees)
+	if err != nil {
+		return err
+	}
+	m.refills[eoa.Address] = txn.Hash()
+	m.logger.Info("signer refill sent", "signer", eoa.Address.String(), "from", m.sweep.Address.String(),
+		"amount", amount.String(), "txn_hash", txn.Hash().String())
+	return nil
+}
+
+// withdraw sends a transaction for the signer to withdraw its full deposit on the EntryPoint to itself. The
+// next nonce of the signer is recorded in the pool once sent. If the signer has no deposit, a nil transaction
+// is returned.
+func (m *Monitor) withdraw(eoa *signer.EOA, entryPoint common.Address) (*types.Transaction, error) {
+	ep, err := entrypoint.NewEntrypoint(entryPoint, m.eth)
+	if err != nil {
+		return nil, err
+	}
+	dep, err := ep.BalanceOf(nil, eoa.Address)
+	if err != nil {
+		return nil, err
+	} else if dep.Sign() == 0 {
+		return nil, nil
+	}
+
+	auth, err := eoa.NewTransactor(m.chainID)
+	if err != nil {
+		return nil, err
+	}
+	auth.Nonce, err = m.getNonce(eoa.Address)
+	if err != nil {
+		return nil, err
+	}
+	txn, err := ep.WithdrawTo(auth, eoa.Address, dep)
+	if err != nil {
+		return nil, err
+	}
+	m.signers.SetNonce(eoa.Address, txn.Nonce()+1)
+	return txn, nil
+}
+
+// Run starts a goroutine that will continuously check the balance of every signer.
+func (m *Monitor) Run() error {
+	if m.isRunning {
+		return nil
+	}
+
+	if err := m.Check(); err != nil {
+		return err
+	}
+	go func(m *Monitor) {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.done:
+				return
+			case <-ticker.C:
+				if err := m.Check(); err != nil {
+					m.logger.Error(err, "balance check error")
+				}
+			}
+		}
+	}(m)
+
+	m.isRunning = true
+	return nil
+}
+
+// Stop signals the monitor to stop checking balances.
+func (m *Monitor) Stop() {
+	if !m.isRunning {
+		return
+	}
+
+	m.isRunning = false
+	m.done <- true
+}
@@ -0,0 +1,118 @@
+package balance
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+func newTestPool(t *testing.T) (*signer.Pool, *signer.EOA) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	eoa := signer.NewEOA(signer.NewLocalSigner(pk))
+	return signer.NewPool(eoa), eoa
+}
+
+func newTestEthClient(t *testing.T, mocks testutils.MethodMocks) *ethclient.Client {
+	srv := testutils.EthMock(mocks)
+	t.Cleanup(srv.Close)
+	eth, err := ethclient.Dial(srv.URL)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return eth
+}
+
+// withdrawMocks returns the JSON-RPC mocks needed for a signer with a balance of 1 wei to withdraw a deposit
+// of 1 ETH.
+func withdrawMocks() testutils.MethodMocks {
+	head := &types.Header{Difficulty: big.NewInt(0), Number: big.NewInt(1), BaseFee: big.NewInt(1)}
+	return testutils.MethodMocks{
+		"eth_getBalance":           "0x1",
+		"eth_call":                 hexutil.Encode(common.LeftPadBytes(testutils.OneETH.Bytes(), 32)),
+		"eth_getCode":              "0x60806040",
+		"eth_getBlockByNumber":     head,
+		"eth_maxPriorityFeePerGas": "0x1",
+		"eth_estimateGas":          "0x5208",
+		"eth_sendRawTransaction":   common.HexToHash("0x01").String(),
+	}
+}
+
+// TestCheckPausesBelowFloor calls (*Monitor).Check with a signer balance below and then above the floor.
+// Expects the signer to be paused and then resumed.
+func TestCheckPausesBelowFloor(t *testing.T) {
+	pool, eoa := newTestPool(t)
+
+	eth := newTestEthClient(t, testutils.MethodMocks{"eth_getBalance": "0x1"})
+	below := New(eth, testutils.ChainID, pool, big.NewInt(2))
+	if err := below.Check(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !pool.IsPaused(eoa.Address) || !below.IsPaused() {
+		t.Fatal("got signer not paused, want paused")
+	}
+	if bal := pool.GetBalance(eoa.Address); bal.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("got balance %s, want 1", bal)
+	}
+
+	eth = newTestEthClient(t, testutils.MethodMocks{"eth_getBalance": "0x3"})
+	above := New(eth, testutils.ChainID, pool, big.NewInt(2))
+	if err := above.Check(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if pool.IsPaused(eoa.Address) || above.IsPaused() {
+		t.Fatal("got signer paused, want resumed")
+	}
+}
+
+// TestRefillWithdrawsWithNonceFunc calls (*Monitor).Check with a signer below the floor that has a deposit on
+// the EntryPoint. Expects a withdrawal to be sent with the nonce from the GetNonceFunc and the next nonce to
+// be recorded in the pool.
+func TestRefillWithdrawsWithNonceFunc(t *testing.T) {
+	pool, eoa := newTestPool(t)
+	m := New(newTestEthClient(t, withdrawMocks()), testutils.ChainID, pool, big.NewInt(2))
+	m.SetWithdrawFromEntryPoints([]common.Address{testutils.ValidAddress1})
+	m.SetGetNonceFunc(func(addr common.Address) (*big.Int, error) {
+		return big.NewInt(7), nil
+	})
+	if err := m.Check(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if _, ok := m.refills[eoa.Address]; !ok {
+		t.Fatal("got no refill, want refill sent")
+	}
+	if nonce, ok := pool.GetNonce(eoa.Address); !ok || nonce != 8 {
+		t.Fatalf("got nonce %d, want 8", nonce)
+	}
+}
+
+// TestRefillSkipsBusySigner calls (*Monitor).Check with a signer below the floor that is currently sending a
+// batch. Expects no withdrawal to be sent.
+func TestRefillSkipsBusySigner(t *testing.T) {
+	pool, eoa := newTestPool(t)
+	m := New(newTestEthClient(t, withdrawMocks()), testutils.ChainID, pool, big.NewInt(2))
+	m.SetWithdrawFromEntryPoints([]common.Address{testutils.ValidAddress1})
+	if pool.Acquire() == nil {
+		t.Fatal("got nil, want signer acquired")
+	}
+	if err := m.Check(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if _, ok := m.refills[eoa.Address]; ok {
+		t.Fatal("got refill sent, want none")
+	}
+	if _, ok := pool.GetNonce(eoa.Address); ok {
+		t.Fatal("got nonce recorded, want none")
+	}
+}
@@ -3,7 +3,9 @@ package bundler
 
 import (
 	"context"
+	"errors"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -13,11 +15,29 @@ import (
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/noop"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/metric"
 )
 
+type nonceLane struct {
+	entryPoint common.Address
+	sender     common.Address
+	key        string
+}
+
+func getNonceLane(ep common.Address, op *userop.UserOperation) nonceLane {
+	return nonceLane{ep, op.Sender, op.GetNonceKey().String()}
+}
+
+// gasPrices are the fee estimates fetched once at the start of each run and shared by every batch.
+type gasPrices struct {
+	baseFee  *big.Int
+	tip      *big.Int
+	gasPrice *big.Int
+}
+
 // Bundler controls the end to end process of creating a batch of UserOperations from the mempool and sending
 // it to the EntryPoint.
 type Bundler struct {
@@ -25,12 +45,23 @@ type Bundler struct {
 	chainID              *big.Int
 	supportedEntryPoints []common.Address
 	batchHandler         modules.BatchHandlerFunc
+	queuedHandler        modules.BatchHandlerFunc
+	trackerHandler       modules.BatchHandlerFunc
 	logger               logr.Logger
 	meter                metric.Meter
 	isRunning            bool
 	done                 chan bool
 	stop                 func()
+	trigger              TriggerFunc
 	maxBatch             int
+	minBatch             int
+	maxWait              time.Duration
+	wake                 chan struct{}
+	wakeAt               time.Time
+	signers              *signer.Pool
+	claimed              map[nonceLane]bool
+	nextEP               int
+	mu                   sync.Mutex
 	gbf                  gasprice.GetBaseFeeFunc
 	ggt                  gasprice.GetGasTipFunc
 	ggp                  gasprice.GetLegacyGasPriceFunc
@@ -44,12 +75,22 @@ func New(mempool *mempool.Mempool, chainID *big.Int, supportedEntryPoints []comm
 		chainID:              chainID,
 		supportedEntryPoints: supportedEntryPoints,
 		batchHandler:         noop.BatchHandler,
+		queuedHandler:        noop.BatchHandler,
+		trackerHandler:       noop.BatchHandler,
 		logger:               logger.NewZeroLogr().WithName("bundler"),
 		meter:                otel.GetMeterProvider().Meter("bundler"),
 		isRunning:            false,
 		done:                 make(chan bool),
 		stop:                 func() {},
+		trigger:              NewIntervalTrigger(1 * time.Second),
 		maxBatch:             0,
+		minBatch:             0,
+		maxWait:              0,
+		wake:                 make(chan struct{}, 1),
+		wakeAt:               time.Time{},
+		signers:              nil,
+		claimed:              make(map[nonceLane]bool),
+		nextEP:               0,
 		gbf:                  gasprice.NoopGetBaseFeeFunc(),
 		ggt:                  gasprice.NoopGetGasTipFunc(),
 		ggp:                  gasprice.NoopGetLegacyGasPriceFunc(),
@@ -61,6 +102,33 @@ func (i *Bundler) SetMaxBatch(max int) {
 	i.maxBatch = max
 }
 
+// SetTrigger defines the function used to signal when the bundler should attempt a run. The default is to
+// attempt a run every second.
+func (i *Bundler) SetTrigger(fn TriggerFunc) {
+	i.trigger = fn
+}
+
+// SetMinBatch defines the min number of UserOperations that must be ready in the mempool before a run is
+// allowed to proceed. The default value is 0 (i.e. run on every trigger).
+func (i *Bundler) SetMinBatch(min int) {
+	i.minBatch = min
+}
+
+// SetMaxWait defines the max duration the oldest pending UserOperation will wait in the mempool for the min
+// batch size to be reached. Once it is exceeded, a run is attempted without waiting for the next trigger. The
+// default value is 0 (i.e. wait indefinitely).
+func (i *Bundler) SetMaxWait(max time.Duration) {
+	i.maxWait = max
+}
+
+// SetSignerPool defines a pool of EOAs for sending batches in parallel. On each run, every batch is assigned
+// to an idle EOA from the pool and entry points are processed in round-robin order. Batches for the same
+// entry point that are processed concurrently will not share any sender and nonce key. The default is nil
+// (i.e. batches are processed one at a time and modules use their own EOA).
+func (i *Bundler) SetSignerPool(pool *signer.Pool) {
+	i.signers = pool
+}
+
 // SetGetBaseFeeFunc defines the function used to retrieve an estimate for basefee during each bundler run.
 func (i *Bundler) SetGetBaseFeeFunc(gbf gasprice.GetBaseFeeFunc) {
 	i.gbf = gbf
@@ -91,11 +159,11 @@ func (i *Bundler) UserMeter(meter metric.Meter) error {
 		metric.WithInt64Callback(func(ctx context.Context, io metric.Int64Observer) error {
 			size := 0
 			for _, ep := range i.supportedEntryPoints {
-				batch, err := i.mempool.Dump(ep)
+				ops, err := i.mempool.DumpAll(ep)
 				if err != nil {
 					return err
 				}
-				size += len(batch)
+				size += len(ops)
 			}
 			io.Observe(int64(size))
 			return nil
@@ -109,13 +177,47 @@ func (i *Bundler) UseModules(handlers ...modules.BatchHandlerFunc) {
 	i.batchHandler = modules.ComposeBatchHandlerFunc(handlers...)
 }
 
+// UseQueuedModules defines the BatchHandlers to process UserOperations that are queued behind a nonce gap on
+// each run. Only ops marked for removal are dropped from the mempool. All other ops stay queued regardless of
+// whether they remain in the batch.
+func (i *Bundler) UseQueuedModules(handlers ...modules.BatchHandlerFunc) {
+	i.queuedHandler = modules.ComposeBatchHandlerFunc(handlers...)
+}
+
+// UseTrackerModules defines the BatchHandlers to follow up on transactions sent by previous runs (e.g.
+// reconciling receipts, bumping fees, or cancelling). They are executed on every run regardless of whether a
+// batch is ready and before any batch is processed. The context starts with an empty batch and any ops
+// marked for removal are dropped from the mempool.
+func (i *Bundler) UseTrackerModules(handlers ...modules.BatchHandlerFunc) {
+	i.trackerHandler = modules.ComposeBatchHandlerFunc(handlers...)
+}
+
 // Process will create a batch from the mempool and send it through to the EntryPoint.
 func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
-	// Init logger
-	start := time.Now()
+	prices, err := i.getGasPrices()
+	if err != nil {
+		return nil, err
+	}
+	if err := i.processTracker(ep, prices); err != nil {
+		return nil, err
+	}
+
+	if i.signers == nil {
+		return i.process(ep, true, nil, prices)
+	}
+
+	eoa := i.signers.Acquire()
+	if eoa == nil {
+		return nil, errors.New("bundler: no idle signer")
+	}
+	defer i.signers.Release(eoa)
+	return i.process(ep, true, eoa, prices)
+}
+
+// getGasPrices returns the current basefee, gas tip, and gas price estimates.
+func (i *Bundler) getGasPrices() (*gasPrices, error) {
 	l := i.logger.
 		WithName("run").
-		WithValues("entrypoint", ep.String()).
 		WithValues("chain_id", i.chainID.String())
 
 	// Get current block basefee
@@ -142,6 +244,109 @@ func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
 		return nil, err
 	}
 
+	return &gasPrices{bf, gt, gp}, nil
+}
+
+// claimBatch removes UserOperations with a sender and nonce key that is already being processed by another
+// run from the batch. If the batch is ready to proceed, the sender and nonce key of each remaining op is
+// claimed by the current run. This ensures that ops in the same nonce lane are never split across concurrent
+// runs.
+func (i *Bundler) claimBatch(
+	ep common.Address,
+	batch []*userop.UserOperation,
+	force bool,
+) []*userop.UserOperation {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	unclaimed := []*userop.UserOperation{}
+	for _, op := range batch {
+		if !i.claimed[getNonceLane(ep, op)] {
+			unclaimed = append(unclaimed, op)
+		}
+	}
+	if len(unclaimed) == 0 || (!force && !i.isBatchReady(ep, unclaimed)) {
+		return []*userop.UserOperation{}
+	}
+
+	unclaimed = adjustBatchSize(i.maxBatch, unclaimed)
+	for _, op := range unclaimed {
+		i.claimed[getNonceLane(ep, op)] = true
+	}
+	return unclaimed
+}
+
+// unclaimBatch releases the nonce lanes claimed by a run.
+func (i *Bundler) unclaimBatch(ep common.Address, batch []*userop.UserOperation) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, op := range batch {
+		delete(i.claimed, getNonceLane(ep, op))
+	}
+}
+
+// isBatchReady returns true if the min batch size has been reached or the oldest pending UserOperation has
+// been in the mempool longer than the max wait duration. If the batch is held back, a run is scheduled for
+// when the max wait duration will be exceeded.
+func (i *Bundler) isBatchReady(ep common.Address, batch []*userop.UserOperation) bool {
+	if len(batch) >= i.minBatch {
+		return true
+	}
+	if i.maxWait <= 0 {
+		return false
+	}
+
+	oldest := time.Now()
+	for _, op := range batch {
+		if at := i.mempool.GetAddedAt(ep, op); at.Before(oldest) {
+			oldest = at
+		}
+	}
+	deadline := oldest.Add(i.maxWait)
+	if !time.Now().Before(deadline) {
+		return true
+	}
+
+	i.scheduleWake(deadline)
+	return false
+}
+
+// scheduleWake signals the run loop to attempt a run at the given time. A new timer is only started if there
+// is no earlier run already scheduled.
+func (i *Bundler) scheduleWake(at time.Time) {
+	if !i.wakeAt.IsZero() && !at.Before(i.wakeAt) {
+		return
+	}
+
+	i.wakeAt = at
+	time.AfterFunc(time.Until(at), func() {
+		i.mu.Lock()
+		if i.wakeAt.Equal(at) {
+			i.wakeAt = time.Time{}
+		}
+		i.mu.Unlock()
+
+		select {
+		case i.wake <- struct{}{}:
+		default:
+		}
+	})
+}
+
+func (i *Bundler) process(
+	ep common.Address,
+	force bool,
+	eoa *signer.EOA,
+	prices *gasPrices,
+) (*modules.BatchHandlerCtx, error) {
+	// Init logger
+	start := time.Now()
+	l := i.logger.
+		WithName("run").
+		WithValues("entrypoint", ep.String()).
+		WithValues("chain_id", i.chainID.String())
+
 	// Get all pending userOps from the mempool. This will be in FIFO order. Downstream modules should sort it
 	// based on more specific strategies.
 	batch, err := i.mempool.Dump(ep)
@@ -149,19 +354,22 @@ func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
 		l.Error(err, "bundler run error")
 		return nil, err
 	}
+	batch = i.claimBatch(ep, batch, force)
 	if len(batch) == 0 {
 		return nil, nil
 	}
-	batch = adjustBatchSize(i.maxBatch, batch)
+	defer i.unclaimBatch(ep, batch)
 
 	// Create context and execute modules.
-	ctx := modules.NewBatchHandlerContext(batch, ep, i.chainID, bf, gt, gp)
+	ctx := modules.NewBatchHandlerContext(batch, ep, i.chainID, prices.baseFee, prices.tip, prices.gasPrice)
+	ctx.Signer = eoa
 	if err := i.batchHandler(ctx); err != nil {
 		l.Error(err, "bundler run error")
 		return nil, err
 	}
 
-	// Remove userOps that remain in the context from mempool.
+	// Remove userOps that remain in the context from mempool. Ops pending inclusion stay in the mempool until
+	// their transaction is final.
 	rmOps := append([]*userop.UserOperation{}, ctx.Batch...)
 	rmOps = append(rmOps, ctx.PendingRemoval...)
 	if err := i.mempool.RemoveOps(ep, rmOps...); err != nil {
@@ -182,42 +390,158 @@ func (i *Bundler) Process(ep common.Address) (*modules.BatchHandlerCtx, error) {
 	}
 	l = l.WithValues("dropped_userop_hashes", drp)
 
+	pnd := []string{}
+	for _, op := range ctx.PendingInclusion {
+		pnd = append(pnd, op.GetUserOpHash(ep, i.chainID).String())
+	}
+	l = l.WithValues("pending_inclusion_userop_hashes", pnd)
+
 	for k, v := range ctx.Data {
 		l = l.WithValues(k, v)
 	}
+	if eoa != nil {
+		l = l.WithValues("signer", eoa.Address.String())
+	}
 	l = l.WithValues("duration", time.Since(start))
 	return ctx, nil
 }
 
-// Run starts a goroutine that will continuously process batches from the mempool.
+// processTracker runs the tracker BatchHandlers for transactions sent by previous runs and drops any ops
+// that were marked for removal from the mempool.
+func (i *Bundler) processTracker(ep common.Address, prices *gasPrices) error {
+	l := i.logger.
+		WithName("tracker").
+		WithValues("entrypoint", ep.String()).
+		WithValues("chain_id", i.chainID.String())
+
+	ctx := modules.NewBatchHandlerContext(nil, ep, i.chainID, prices.baseFee, prices.tip, prices.gasPrice)
+	if err := i.trackerHandler(ctx); err != nil {
+		l.Error(err, "bundler tracker run error")
+		return err
+	}
+	if err := i.mempool.RemoveOps(ep, ctx.PendingRemoval...); err != nil {
+		l.Error(err, "bundler tracker run error")
+		return err
+	}
+	return nil
+}
+
+// processQueued runs UserOperations that are queued behind a nonce gap through the queued BatchHandlers and
+// drops any ops that were marked for removal from the mempool.
+func (i *Bundler) processQueued(ep common.Address) error {
+	l := i.logger.
+		WithName("queued").
+		WithValues("entrypoint", ep.String()).
+		WithValues("chain_id", i.chainID.String())
+
+	queued, err := i.mempool.DumpQueued(ep)
+	if err != nil {
+		l.Error(err, "bundler queued run error")
+		return err
+	}
+	if len(queued) == 0 {
+		return nil
+	}
+
+	ctx := modules.NewBatchHandlerContext(queued, ep, i.chainID, nil, nil, nil)
+	if err := i.queuedHandler(ctx); err != nil {
+		l.Error(err, "bundler queued run error")
+		return err
+	}
+	if err := i.mempool.RemoveOps(ep, ctx.PendingRemoval...); err != nil {
+		l.Error(err, "bundler queued run error")
+		return err
+	}
+
+	if len(ctx.PendingRemoval) > 0 {
+		drp := []string{}
+		for _, op := range ctx.PendingRemoval {
+			drp = append(drp, op.GetUserOpHash(ep, i.chainID).String())
+		}
+		l.Info("dropped queued userOps", "dropped_userop_hashes", drp)
+	}
+	return nil
+}
+
+// dispatch assigns a batch for each entry point to an idle EOA from the signer pool and processes them in
+// parallel. The starting entry point is rotated on each call so that no entry point is starved when there are
+// fewer idle EOAs than entry points.
+func (i *Bundler) dispatch(prices *gasPrices) {
+	n := len(i.supportedEntryPoints)
+	for k := 0; k < n; k++ {
+		eoa := i.signers.Acquire()
+		if eoa == nil {
+			break
+		}
+
+		ep := i.supportedEntryPoints[(i.nextEP+k)%n]
+		go func(ep common.Address, eoa *signer.EOA) {
+			defer i.signers.Release(eoa)
+			_, _ = i.process(ep, false, eoa, prices)
+		}(ep, eoa)
+	}
+	i.nextEP = (i.nextEP + 1) % n
+}
+
+// Run starts a goroutine that will continuously process batches from the mempool each time the trigger
+// fires or a held back batch exceeds the max wait duration.
 func (i *Bundler) Run() error {
 	if i.isRunning {
 		return nil
 	}
 
-	ticker := time.NewTicker(1 * time.Second)
+	stop := make(chan struct{})
+	trigger, err := i.trigger(stop)
+	if err != nil {
+		return err
+	}
 	go func(i *Bundler) {
 		for {
 			select {
 			case <-i.done:
 				return
-			case <-ticker.C:
-				for _, ep := range i.supportedEntryPoints {
-					_, err := i.Process(ep)
-					if err != nil {
-						// Already logged.
-						continue
-					}
-				}
+			case <-trigger:
+				i.run()
+			case <-i.wake:
+				i.run()
 			}
 		}
 	}(i)
 
 	i.isRunning = true
-	i.stop = ticker.Stop
+	i.stop = func() { close(stop) }
 	return nil
 }
 
+// run follows up on transactions from previous runs and processes batches for every supported entry point
+// once.
+func (i *Bundler) run() {
+	prices, err := i.getGasPrices()
+	if err != nil {
+		// Already logged.
+		return
+	}
+
+	for _, ep := range i.supportedEntryPoints {
+		// Already logged.
+		_ = i.processTracker(ep, prices)
+		_ = i.processQueued(ep)
+	}
+
+	if i.signers != nil {
+		i.dispatch(prices)
+		return
+	}
+
+	for _, ep := range i.supportedEntryPoints {
+		_, err := i.process(ep, false, nil, prices)
+		if err != nil {
+			// Already logged.
+			continue
+		}
+	}
+}
+
 // Stop signals the bundler to stop continuously processing batches from the mempool.
 func (i *Bundler) Stop() {
 	if !i.isRunning {
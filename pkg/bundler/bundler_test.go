@@ -0,0 +1,195 @@
+package bundler
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestProcessQueuedDropsMarkedOps calls (*Bundler).processQueued and verifies that queued ops marked for
+// removal by the queued modules are dropped from the mempool while the rest stay queued.
+func TestProcessQueuedDropsMarkedOps(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	mem.SetGetNonceFunc(func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		return big.NewInt(0), nil
+	})
+	ep := testutils.ValidAddress1
+
+	op1 := testutils.MockValidInitUserOp()
+	op1.Nonce = big.NewInt(2)
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	op2.Nonce = big.NewInt(2)
+	for _, op := range []*userop.UserOperation{op1, op2} {
+		if _, err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	b := New(mem, testutils.ChainID, []common.Address{ep})
+	b.UseQueuedModules(func(ctx *modules.BatchHandlerCtx) error {
+		for i, op := range ctx.Batch {
+			if op.Sender == op1.Sender {
+				ctx.MarkOpIndexForRemoval(i, "expired")
+				break
+			}
+		}
+		return nil
+	})
+	if err := b.processQueued(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	queued, err := mem.DumpQueued(ep)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(queued) != 1 || !testutils.IsOpsEqual(queued[0], op2) {
+		t.Fatalf("got %d queued ops, want op2 only", len(queued))
+	}
+}
+
+// TestTrackerRunsWithoutReadyBatch calls (*Bundler).run with fewer ops than the min batch size and verifies
+// that the tracker modules still run and that ops they mark for removal are dropped from the mempool.
+func TestTrackerRunsWithoutReadyBatch(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	ep := testutils.ValidAddress1
+
+	op := testutils.MockValidInitUserOp()
+	if _, err := mem.AddOp(ep, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	b := New(mem, testutils.ChainID, []common.Address{ep})
+	b.SetMinBatch(2)
+	b.UseTrackerModules(func(ctx *modules.BatchHandlerCtx) error {
+		ctx.PendingRemoval = append(ctx.PendingRemoval, op)
+		return nil
+	})
+	b.UseModules(func(ctx *modules.BatchHandlerCtx) error {
+		t.Fatal("got batch, want no batch")
+		return nil
+	})
+	b.run()
+
+	if ops, err := mem.DumpAll(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ops) != 0 {
+		t.Fatalf("got %d ops in mempool, want 0", len(ops))
+	}
+}
+
+// TestMaxWaitTriggersRun calls (*Bundler).Run with a trigger that fires once and a min batch size that is
+// never reached. Expects the held back op to be processed once it has been in the mempool longer than the
+// max wait duration.
+func TestMaxWaitTriggersRun(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	ep := testutils.ValidAddress1
+	op := testutils.MockValidInitUserOp()
+	if _, err := mem.AddOp(ep, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	b := New(mem, testutils.ChainID, []common.Address{ep})
+	b.SetTrigger(func(done <-chan struct{}) (<-chan struct{}, error) {
+		out := make(chan struct{}, 1)
+		out <- struct{}{}
+		return out, nil
+	})
+	b.SetMinBatch(2)
+	b.SetMaxWait(50 * time.Millisecond)
+	processed := make(chan int, 1)
+	b.UseModules(func(ctx *modules.BatchHandlerCtx) error {
+		processed <- len(ctx.Batch)
+		return nil
+	})
+	if err := b.Run(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	defer b.Stop()
+
+	select {
+	case n := <-processed:
+		if n != 1 {
+			t.Fatalf("got batch length %d, want 1", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("got no run, want run after max wait")
+	}
+}
+
+// TestConcurrentRunsDoNotSplitNonceLane calls (*Bundler).process from several pool signers at once with ops
+// from a single sender and nonce key. Expects only the first run to get a batch since the nonce lane is
+// claimed by it for the duration of the run.
+func TestConcurrentRunsDoNotSplitNonceLane(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := mempool.New(db)
+	ep := testutils.ValidAddress1
+	for i := 0; i < 3; i++ {
+		op := testutils.MockValidInitUserOp()
+		op.Nonce = big.NewInt(int64(i))
+		if _, err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	eoas := []*signer.EOA{}
+	for i := 0; i < 3; i++ {
+		pk, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		eoas = append(eoas, signer.NewEOA(signer.NewLocalSigner(pk)))
+	}
+	pool := signer.NewPool(eoas...)
+
+	b := New(mem, testutils.ChainID, []common.Address{ep})
+	b.SetSignerPool(pool)
+	b.SetMaxBatch(1)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	b.UseModules(func(ctx *modules.BatchHandlerCtx) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	first := pool.Acquire()
+	done := make(chan *modules.BatchHandlerCtx)
+	go func() {
+		ctx, _ := b.process(ep, false, first, &gasPrices{})
+		done <- ctx
+	}()
+	<-started
+
+	for eoa := pool.Acquire(); eoa != nil; eoa = pool.Acquire() {
+		ctx, err := b.process(ep, false, eoa, &gasPrices{})
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if ctx != nil {
+			t.Fatalf("got batch length %d from %s, want no batch", len(ctx.Batch), eoa.Address)
+		}
+	}
+
+	close(release)
+	ctx := <-done
+	if ctx == nil || len(ctx.Batch) != 1 || ctx.Batch[0].Nonce.Cmp(big.NewInt(0)) != 0 {
+		t.Fatal("got no batch, want batch with the first op in the lane")
+	}
+}
@@ -0,0 +1,122 @@
+package bundler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// TriggerFunc starts a source of signals for the bundler to attempt a run. A run is attempted each time a
+// value is received on the returned channel. The source must release all its resources once the done channel
+// is closed.
+type TriggerFunc = func(done <-chan struct{}) (<-chan struct{}, error)
+
+// TriggerType is the kind of signal used to start a bundler run.
+type TriggerType string
+
+const (
+	// IntervalTrigger attempts a run on a fixed interval.
+	IntervalTrigger TriggerType = "interval"
+
+	// NewHeadsTrigger attempts a run each time a new block is received.
+	NewHeadsTrigger TriggerType = "newHeads"
+)
+
+// ParseTriggerType returns the TriggerType for a given string value.
+func ParseTriggerType(t string) (TriggerType, error) {
+	switch TriggerType(t) {
+	case IntervalTrigger, NewHeadsTrigger:
+		return TriggerType(t), nil
+	default:
+		return "", fmt.Errorf("bundler trigger: unknown value %s", t)
+	}
+}
+
+// NewIntervalTrigger returns a TriggerFunc that signals a run every interval.
+func NewIntervalTrigger(interval time.Duration) TriggerFunc {
+	return func(done <-chan struct{}) (<-chan struct{}, error) {
+		out := make(chan struct{})
+		ticker := time.NewTicker(interval)
+		go func() {
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					select {
+					case out <- struct{}{}:
+					case <-done:
+						return
+					}
+				}
+			}
+		}()
+
+		return out, nil
+	}
+}
+
+// NewHeadsTriggerWithEthClient returns a TriggerFunc that signals a run each time a new block is received.
+// New blocks are received through an eth_subscribe newHeads subscription if the client is connected over a
+// websocket. Otherwise, or if the subscription fails, the block number is polled every interval instead.
+func NewHeadsTriggerWithEthClient(eth *ethclient.Client, interval time.Duration) TriggerFunc {
+	return func(done <-chan struct{}) (<-chan struct{}, error) {
+		out := make(chan struct{})
+		signal := func() bool {
+			select {
+			case out <- struct{}{}:
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		go func() {
+			heads := make(chan *types.Header)
+			sub, err := eth.SubscribeNewHead(context.Background(), heads)
+			if err == nil {
+				func() {
+					defer sub.Unsubscribe()
+					for {
+						select {
+						case <-done:
+							return
+						case <-sub.Err():
+							// Fallback to polling.
+							return
+						case <-heads:
+							if !signal() {
+								return
+							}
+						}
+					}
+				}()
+			}
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			var last uint64
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					bn, err := eth.BlockNumber(context.Background())
+					if err != nil || bn <= last {
+						continue
+					}
+					last = bn
+					if !signal() {
+						return
+					}
+				}
+			}
+		}()
+
+		return out, nil
+	}
+}
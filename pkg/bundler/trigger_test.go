@@ -0,0 +1,110 @@
+package bundler
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+type mockHeadsService struct{}
+
+func (s *mockHeadsService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+
+	sub := notifier.CreateSubscription()
+	go func() {
+		head := &types.Header{Difficulty: big.NewInt(0), Number: big.NewInt(1)}
+		_ = notifier.Notify(sub.ID, head)
+	}()
+	return sub, nil
+}
+
+func expectSignal(t *testing.T, ch <-chan struct{}, timeout time.Duration) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(timeout):
+		t.Fatalf("got no signal, want signal within %s", timeout)
+	}
+}
+
+func expectNoSignal(t *testing.T, ch <-chan struct{}, timeout time.Duration) {
+	t.Helper()
+	select {
+	case <-ch:
+		t.Fatalf("got signal, want no signal within %s", timeout)
+	case <-time.After(timeout):
+	}
+}
+
+// TestIntervalTrigger calls NewIntervalTrigger and verifies that a signal is sent on every interval until the
+// done channel is closed.
+func TestIntervalTrigger(t *testing.T) {
+	done := make(chan struct{})
+	out, err := NewIntervalTrigger(10 * time.Millisecond)(done)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	expectSignal(t, out, time.Second)
+	expectSignal(t, out, time.Second)
+	close(done)
+	expectNoSignal(t, out, 50*time.Millisecond)
+}
+
+// TestNewHeadsTriggerWithSubscription calls NewHeadsTriggerWithEthClient with a client that supports
+// subscriptions and verifies that a signal is sent when a new head is received.
+func TestNewHeadsTriggerWithSubscription(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", &mockHeadsService{}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	eth := ethclient.NewClient(rpc.DialInProc(server))
+	defer eth.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	// A long interval ensures that the signal can only come from the subscription.
+	out, err := NewHeadsTriggerWithEthClient(eth, time.Hour)(done)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	expectSignal(t, out, time.Second)
+}
+
+// TestNewHeadsTriggerFallbackToPolling calls NewHeadsTriggerWithEthClient with a client that does not
+// support subscriptions and verifies that the block number is polled instead. A signal is only sent when the
+// block number increases.
+func TestNewHeadsTriggerFallbackToPolling(t *testing.T) {
+	srv := testutils.EthMock(testutils.MethodMocks{
+		"eth_blockNumber": "0x1",
+	})
+	defer srv.Close()
+	rpcClient, err := rpc.Dial(srv.URL)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	eth := ethclient.NewClient(rpcClient)
+	defer eth.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	out, err := NewHeadsTriggerWithEthClient(eth, 10*time.Millisecond)(done)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	expectSignal(t, out, time.Second)
+	expectNoSignal(t, out, 100*time.Millisecond)
+}
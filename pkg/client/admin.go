@@ -0,0 +1,110 @@
+package client
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/mitchellh/mapstructure"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/access"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// Admin exposes methods for operating a running bundler. These should only be made available to trusted
+// callers.
+type Admin struct {
+	rotateSigner     RotateSignerFunc
+	updateAccessList UpdateAccessListFunc
+	dumpAccessList   DumpAccessListFunc
+}
+
+// NewAdmin initializes a new Admin with no-op implementations for all operations.
+func NewAdmin() *Admin {
+	return &Admin{
+		rotateSigner:     rotateSignerNoop(),
+		updateAccessList: updateAccessListNoop(),
+		dumpAccessList:   dumpAccessListNoop(),
+	}
+}
+
+// SetRotateSignerFunc defines a general function for replacing the bundler's signer.
+func (a *Admin) SetRotateSignerFunc(fn RotateSignerFunc) {
+	a.rotateSigner = fn
+}
+
+// SetUpdateAccessListFunc defines a general function for adding and removing entities from the allowlist and
+// denylist.
+func (a *Admin) SetUpdateAccessListFunc(fn UpdateAccessListFunc) {
+	a.updateAccessList = fn
+}
+
+// SetDumpAccessListFunc defines a general function for fetching all entities on the allowlist and denylist.
+func (a *Admin) SetDumpAccessListFunc(fn DumpAccessListFunc) {
+	a.dumpAccessList = fn
+}
+
+type rotateSignerRequest struct {
+	KeystoreFile        string `mapstructure:"keystoreFile"`
+	RemoteSignerUrl     string `mapstructure:"remoteSignerUrl"`
+	RemoteSignerAddress string `mapstructure:"remoteSignerAddress"`
+	Sweep               bool   `mapstructure:"sweep"`
+}
+
+// RotateSigner loads a new signer from the key source in params and stops assigning new bundles to the
+// current one. The previous signer is retired in the background once its pending transactions are confirmed.
+// It returns the address of the new signer.
+//
+// Only a keystore file name or a remote signer can be given. Raw private keys and passphrases are never
+// accepted over RPC and are rejected as unknown fields.
+func (a *Admin) RotateSigner(params map[string]any) (string, error) {
+	var req rotateSignerRequest
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ErrorUnused: true,
+		Result:      &req,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := decoder.Decode(params); err != nil {
+		return "", err
+	}
+
+	addr, err := a.rotateSigner(signer.Source{
+		KeystoreFile:        req.KeystoreFile,
+		RemoteSignerUrl:     req.RemoteSignerUrl,
+		RemoteSignerAddress: common.HexToAddress(req.RemoteSignerAddress),
+	}, req.Sweep)
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+// AllowEntities adds senders, paymasters, or factories to the allowlist. Allowlisted entities are never
+// throttled or banned by reputation. Any entity previously on the denylist is removed from it.
+func (a *Admin) AllowEntities(entities []common.Address) (string, error) {
+	if err := a.updateAccessList(access.Allow, entities...); err != nil {
+		return "", err
+	}
+	return "ok", nil
+}
+
+// DenyEntities adds senders, paymasters, or factories to the denylist. New userOps from denylisted entities
+// are rejected and existing ones are dropped from the mempool. Any entity previously on the allowlist is
+// removed from it.
+func (a *Admin) DenyEntities(entities []common.Address) (string, error) {
+	if err := a.updateAccessList(access.Deny, entities...); err != nil {
+		return "", err
+	}
+	return "ok", nil
+}
+
+// RemoveEntities removes senders, paymasters, or factories from both the allowlist and denylist.
+func (a *Admin) RemoveEntities(entities []common.Address) (string, error) {
+	if err := a.updateAccessList(access.None, entities...); err != nil {
+		return "", err
+	}
+	return "ok", nil
+}
+
+// DumpAccessList returns all entities on the allowlist and denylist.
+func (a *Admin) DumpAccessList() (*access.Dump, error) {
+	return a.dumpAccessList()
+}
@@ -8,13 +8,20 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/go-logr/logr"
 	"github.com/stackup-wallet/stackup-bundler/internal/logger"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	rpcErrors "github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/events"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules/noop"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/status"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
@@ -30,6 +37,12 @@ type Client struct {
 	getUserOpReceipt     GetUserOpReceiptFunc
 	getGasEstimate       GetGasEstimateFunc
 	getUserOpByHash      GetUserOpByHashFunc
+	getUserOpStatus      GetUserOpStatusFunc
+	markUserOpAdded      MarkUserOpAddedFunc
+	gbf                  gasprice.GetBaseFeeFunc
+	ggt                  gasprice.GetGasTipFunc
+	ggp                  gasprice.GetLegacyGasPriceFunc
+	events               *events.Feed
 }
 
 // New initializes a new ERC-4337 client which can be extended with modules for validating UserOperations
@@ -50,6 +63,12 @@ func New(
 		getUserOpReceipt:     getUserOpReceiptNoop(),
 		getGasEstimate:       getGasEstimateNoop(),
 		getUserOpByHash:      getUserOpByHashNoop(),
+		getUserOpStatus:      getUserOpStatusNoop(),
+		markUserOpAdded:      markUserOpAddedNoop(),
+		gbf:                  gasprice.NoopGetBaseFeeFunc(),
+		ggt:                  gasprice.NoopGetGasTipFunc(),
+		ggp:                  gasprice.NoopGetLegacyGasPriceFunc(),
+		events:               nil,
 	}
 }
 
@@ -63,6 +82,20 @@ func (i *Client) parseEntryPointAddress(ep string) (common.Address, error) {
 	return common.Address{}, errors.New("entryPoint: Implementation not supported")
 }
 
+// parseUserOp decodes a userOp and checks that it is formatted for the version of the given EntryPoint.
+func parseUserOp(op map[string]any, ep common.Address) (*userop.UserOperation, error) {
+	userOp, err := userop.New(op)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := entrypoint.GetVersion(ep); userOp.Version() != v {
+		msg := fmt.Sprintf("userOp: format is %s but EntryPoint %s expects %s", userOp.Version(), ep, v)
+		return nil, rpcErrors.NewRPCError(rpcErrors.INVALID_FIELDS, msg, msg)
+	}
+	return userOp, nil
+}
+
 // UseLogger defines the logger object used by the Client instance based on the go-logr/logr interface.
 func (i *Client) UseLogger(logger logr.Logger) {
 	i.logger = logger.WithName("client")
@@ -73,6 +106,12 @@ func (i *Client) UseModules(handlers ...modules.UserOpHandlerFunc) {
 	i.userOpHandler = modules.ComposeUserOpHandlerFunc(handlers...)
 }
 
+// UseEventFeed defines the Feed that a NewUserOps event is published to for every UserOperation added to the
+// mempool. A DroppedUserOps event is also published for every op that was replaced or evicted by it.
+func (i *Client) UseEventFeed(feed *events.Feed) {
+	i.events = feed
+}
+
 // SetGetUserOpReceiptFunc defines a general function for fetching a UserOpReceipt given a userOpHash and
 // EntryPoint address. This function is called in *Client.GetUserOperationReceipt.
 func (i *Client) SetGetUserOpReceiptFunc(fn GetUserOpReceiptFunc) {
@@ -92,6 +131,51 @@ func (i *Client) SetGetUserOpByHashFunc(fn GetUserOpByHashFunc) {
 	i.getUserOpByHash = fn
 }
 
+// SetGetUserOpStatusFunc defines a general function for fetching the last known status of a UserOperation
+// given a userOpHash. This function is called in *Client.GetUserOperationStatus.
+func (i *Client) SetGetUserOpStatusFunc(fn GetUserOpStatusFunc) {
+	i.getUserOpStatus = fn
+}
+
+// SetMarkUserOpAddedFunc defines a general function for recording a UserOperation once it has been added to
+// the mempool along with any ops that it replaced or evicted. This function is called in
+// *Client.SendUserOperation.
+func (i *Client) SetMarkUserOpAddedFunc(fn MarkUserOpAddedFunc) {
+	i.markUserOpAdded = fn
+}
+
+// SetGetBaseFeeFunc defines the function used to retrieve an estimate for basefee when suggesting fees in
+// *Client.SuggestUserOperationFees.
+func (i *Client) SetGetBaseFeeFunc(gbf gasprice.GetBaseFeeFunc) {
+	i.gbf = gbf
+}
+
+// SetGetGasTipFunc defines the function used to retrieve an estimate for gas tip when suggesting fees in
+// *Client.SuggestUserOperationFees.
+func (i *Client) SetGetGasTipFunc(ggt gasprice.GetGasTipFunc) {
+	i.ggt = ggt
+}
+
+// SetGetLegacyGasPriceFunc defines the function used to retrieve an estimate for gas price when suggesting
+// fees in *Client.SuggestUserOperationFees on networks that do not support EIP-1559.
+func (i *Client) SetGetLegacyGasPriceFunc(ggp gasprice.GetLegacyGasPriceFunc) {
+	i.ggp = ggp
+}
+
+// calcPreVerificationGas returns the estimated PVG for a userOp with an added buffer for ops without a
+// signature.
+func (i *Client) calcPreVerificationGas(userOp *userop.UserOperation) (*big.Int, error) {
+	pvg, err := i.ov.CalcPreVerificationGasWithBuffer(userOp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userOp.Signature) == 0 {
+		pvg = new(big.Int).Add(pvg, big.NewInt(8000))
+	}
+	return pvg, nil
+}
+
 // SendUserOperation implements the method call for eth_sendUserOperation.
 // It returns true if userOp was accepted otherwise returns an error.
 func (i *Client) SendUserOperation(op map[string]any, ep string) (string, error) {
@@ -108,7 +192,7 @@ func (i *Client) SendUserOperation(op map[string]any, ep string) (string, error)
 		WithValues("entrypoint", epAddr.String()).
 		WithValues("chain_id", i.chainID.String())
 
-	userOp, err := userop.New(op)
+	userOp, err := parseUserOp(op, epAddr)
 	if err != nil {
 		l.Error(err, "eth_sendUserOperation error")
 		return "", err
@@ -126,18 +210,59 @@ func (i *Client) SendUserOperation(op map[string]any, ep string) (string, error)
 		return "", err
 	}
 
+	// Fetch any pending UserOperations in the mempool sponsored by the same paymaster
+	pmOps := []*userop.UserOperation{}
+	if pm := userOp.GetPaymaster(); pm != common.HexToAddress("0x") {
+		pmOps, err = i.mempool.GetOpsByPaymaster(epAddr, pm)
+		if err != nil {
+			l.Error(err, "eth_sendUserOperation error")
+			return "", err
+		}
+	}
+
 	// Run through client module stack.
 	ctx := modules.NewUserOpHandlerContext(userOp, penOps, epAddr, i.chainID)
+	ctx.SetPendingPaymasterOps(pmOps)
 	if err := i.userOpHandler(ctx); err != nil {
 		l.Error(err, "eth_sendUserOperation userOphandler error")
 		return "", err
 	}
 
-	// Add userOp to mempool.
-	if err := i.mempool.AddOp(epAddr, ctx.UserOp); err != nil {
+	// Add userOp to mempool. A pending op with the same sender and nonce is replaced.
+	replaced := []*userop.UserOperation{}
+	for _, op := range penOps {
+		if op.Nonce.Cmp(ctx.UserOp.Nonce) == 0 && op.GetUserOpHash(epAddr, i.chainID) != hash {
+			replaced = append(replaced, op)
+		}
+	}
+	evicted, err := i.mempool.AddOp(epAddr, ctx.UserOp)
+	if err != nil {
 		l.Error(err, "eth_sendUserOperation error")
 		return "", err
 	}
+	for _, e := range evicted {
+		l.Info("eth_sendUserOperation evicted userOp", "evicted_userop_hash", e.GetUserOpHash(epAddr, i.chainID))
+	}
+
+	// Record the new op and every op it removed from the mempool.
+	dropped := make(map[common.Hash]string)
+	for _, op := range replaced {
+		dropped[op.GetUserOpHash(epAddr, i.chainID)] = "replaced by " + hash.String()
+	}
+	for _, op := range evicted {
+		dropped[op.GetUserOpHash(epAddr, i.chainID)] = "evicted by " + hash.String()
+	}
+	if err := i.markUserOpAdded(epAddr, hash, dropped); err != nil {
+		// The op is already in the mempool so the request still succeeds.
+		l.Error(err, "eth_sendUserOperation status error")
+	}
+	if i.events != nil {
+		i.events.PublishUserOpAdded(epAddr, hash, ctx.UserOp)
+		for _, op := range append(append([]*userop.UserOperation{}, replaced...), evicted...) {
+			h := op.GetUserOpHash(epAddr, i.chainID)
+			i.events.PublishUserOpDropped(epAddr, h, op, dropped[h])
+		}
+	}
 
 	l.Info("eth_sendUserOperation ok")
 	return hash.String(), nil
@@ -147,7 +272,16 @@ func (i *Client) SendUserOperation(op map[string]any, ep string) (string, error)
 // a UserOperation and EntryPoint address. The signature field and current gas values will not be validated
 // although there should be dummy values in place for the most reliable results (e.g. a signature with the
 // correct length).
-func (i *Client) EstimateUserOperationGas(op map[string]any, ep string) (*gas.GasEstimates, error) {
+//
+// Optional state overrides are applied to every simulation. This allows estimating ops that depend on state
+// that does not exist yet (e.g. a counterfactual account that is not funded or a pending token approval). An
+// optional block can also be given to simulate against (e.g. pending). It defaults to latest.
+func (i *Client) EstimateUserOperationGas(
+	op map[string]any,
+	ep string,
+	overrides utils.StateOverrides,
+	block *rpc.BlockNumberOrHash,
+) (*gas.GasEstimates, error) {
 	// Init logger
 	l := i.logger.WithName("eth_estimateUserOperationGas")
 
@@ -161,7 +295,7 @@ func (i *Client) EstimateUserOperationGas(op map[string]any, ep string) (*gas.Ga
 		WithValues("entrypoint", epAddr.String()).
 		WithValues("chain_id", i.chainID.String())
 
-	userOp, err := userop.New(op)
+	userOp, err := parseUserOp(op, epAddr)
 	if err != nil {
 		l.Error(err, "eth_estimateUserOperationGas error")
 		return nil, err
@@ -170,20 +304,16 @@ func (i *Client) EstimateUserOperationGas(op map[string]any, ep string) (*gas.Ga
 	l = l.WithValues("userop_hash", hash)
 
 	// Calculate PreVerificationGas
-	pvg, err := i.ov.CalcPreVerificationGasWithBuffer(userOp)
+	pvg, err := i.calcPreVerificationGas(userOp)
 	if err != nil {
 		l.Error(err, "eth_estimateUserOperationGas error")
 		return nil, err
 	}
 
-	if len(userOp.Signature) == 0 {
-		pvg = new(big.Int).Add(pvg, big.NewInt(8000))
-	}
-
 	userOp.PreVerificationGas = pvg
 
 	// Estimate gas limits
-	vg, cg, err := i.getGasEstimate(epAddr, userOp)
+	vg, cg, err := i.getGasEstimate(epAddr, userOp, overrides, block)
 	if err != nil {
 		l.Error(err, "eth_estimateUserOperationGas error")
 		return nil, err
@@ -208,7 +338,13 @@ func (i *Client) GetUserOperationReceipt(
 	// Init logger
 	l := i.logger.WithName("eth_getUserOperationReceipt").WithValues("userop_hash", hash)
 
-	ev, err := i.getUserOpReceipt(hash, i.supportedEntryPoints[0])
+	var ev *filter.UserOperationReceipt
+	var err error
+	for _, ep := range i.supportedEntryPoints {
+		if ev, err = i.getUserOpReceipt(hash, ep); err == nil {
+			break
+		}
+	}
 	if err != nil {
 		l.Error(err, "eth_getUserOperationReceipt error")
 		return nil, err
@@ -224,7 +360,13 @@ func (i *Client) GetUserOperationByHash(hash string) (*filter.HashLookupResult,
 	// Init logger
 	l := i.logger.WithName("eth_getUserOperationByHash").WithValues("userop_hash", hash)
 
-	res, err := i.getUserOpByHash(hash, i.supportedEntryPoints[0], i.chainID)
+	var res *filter.HashLookupResult
+	var err error
+	for _, ep := range i.supportedEntryPoints {
+		if res, err = i.getUserOpByHash(hash, ep, i.chainID); err == nil {
+			break
+		}
+	}
 	if err != nil {
 		l.Error(err, "eth_getUserOperationByHash error")
 		return nil, err
@@ -233,6 +375,105 @@ func (i *Client) GetUserOperationByHash(hash string) (*filter.HashLookupResult,
 	return res, nil
 }
 
+// GetUserOperationStatus returns the last known status of a UserOperation based on a given userOpHash returned
+// by *Client.SendUserOperation. Unlike a receipt, the status is also available for ops that are still pending
+// or were dropped from the mempool. A nil result is returned if the userOpHash is unknown or the record has
+// expired.
+func (i *Client) GetUserOperationStatus(hash string) (*status.Record, error) {
+	// Init logger
+	l := i.logger.WithName("eth_getUserOperationStatus").WithValues("userop_hash", hash)
+
+	if b, err := hexutil.Decode(hash); err != nil || len(b) != common.HashLength {
+		//lint:ignore ST1005 This needs to match the bundler test spec.
+		err := errors.New("Missing/invalid userOpHash")
+		l.Error(err, "eth_getUserOperationStatus error")
+		return nil, err
+	}
+
+	res, err := i.getUserOpStatus(common.HexToHash(hash))
+	if err != nil {
+		l.Error(err, "eth_getUserOperationStatus error")
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// suggestFees returns the fee suggestion based on the current network conditions.
+func (i *Client) suggestFees() (*gasprice.FeeSuggestion, error) {
+	bf, err := i.gbf()
+	if err != nil {
+		return nil, err
+	}
+
+	var tip, gp *big.Int
+	if bf != nil {
+		if tip, err = i.ggt(); err != nil {
+			return nil, err
+		}
+	} else {
+		if gp, err = i.ggp(); err != nil {
+			return nil, err
+		}
+	}
+	return gasprice.SuggestFees(bf, tip, gp)
+}
+
+// SuggestUserOperationFees returns slow, standard, and fast values for maxFeePerGas and maxPriorityFeePerGas
+// that will be accepted by the bundler. If a UserOperation is given, the preVerificationGas required for each
+// tier is also returned. On rollups this accounts for the L1 data cost which varies with the fees of the op.
+// If the EntryPoint is not given, the preferred EntryPoint is used.
+func (i *Client) SuggestUserOperationFees(op map[string]any, ep *string) (*gasprice.FeeSuggestion, error) {
+	// Init logger
+	l := i.logger.WithName("eth_suggestUserOperationFees")
+
+	fees, err := i.suggestFees()
+	if err != nil {
+		l.Error(err, "eth_suggestUserOperationFees error")
+		return nil, err
+	}
+	if op == nil {
+		return fees, nil
+	}
+
+	epAddr := i.supportedEntryPoints[0]
+	if ep != nil {
+		if epAddr, err = i.parseEntryPointAddress(*ep); err != nil {
+			l.Error(err, "eth_suggestUserOperationFees error")
+			return nil, err
+		}
+	}
+	userOp, err := parseUserOp(op, epAddr)
+	if err != nil {
+		l.Error(err, "eth_suggestUserOperationFees error")
+		return nil, err
+	}
+
+	for _, f := range fees.Tiers() {
+		tmp := *userOp
+		tmp.MaxFeePerGas = f.MaxFeePerGas
+		tmp.MaxPriorityFeePerGas = f.MaxPriorityFeePerGas
+		if f.PreVerificationGas, err = i.calcPreVerificationGas(&tmp); err != nil {
+			l.Error(err, "eth_suggestUserOperationFees error")
+			return nil, err
+		}
+	}
+
+	return fees, nil
+}
+
+// MaxPriorityFeePerGas implements the method call for eth_maxPriorityFeePerGas. It returns the
+// maxPriorityFeePerGas of the standard tier from *Client.SuggestUserOperationFees.
+func (i *Client) MaxPriorityFeePerGas() (string, error) {
+	fees, err := i.suggestFees()
+	if err != nil {
+		i.logger.WithName("eth_maxPriorityFeePerGas").Error(err, "eth_maxPriorityFeePerGas error")
+		return "", err
+	}
+
+	return hexutil.EncodeBig(fees.Standard.MaxPriorityFeePerGas), nil
+}
+
 // SupportedEntryPoints implements the method call for eth_supportedEntryPoints. It returns the array of
 // EntryPoint addresses that is supported by the client. The first address in the array is the preferred
 // EntryPoint.
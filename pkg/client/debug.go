@@ -10,14 +10,16 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/stackup-wallet/stackup-bundler/pkg/bundler"
 	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 )
 
 // Debug exposes methods used for testing the bundler. These should not be made available in production.
 type Debug struct {
-	eoa         *signer.EOA
+	signers     *signer.Provider
 	eth         *ethclient.Client
 	mempool     *mempool.Mempool
+	reputation  *entities.Reputation
 	bundler     *bundler.Bundler
 	chainID     *big.Int
 	entrypoint  common.Address
@@ -25,15 +27,24 @@ type Debug struct {
 }
 
 func NewDebug(
-	eoa *signer.EOA,
+	signers *signer.Provider,
 	eth *ethclient.Client,
 	mempool *mempool.Mempool,
+	reputation *entities.Reputation,
 	bundler *bundler.Bundler,
 	chainID *big.Int,
 	entrypoint common.Address,
 	beneficiary common.Address,
 ) *Debug {
-	return &Debug{eoa, eth, mempool, bundler, chainID, entrypoint, beneficiary}
+	return &Debug{signers, eth, mempool, reputation, bundler, chainID, entrypoint, beneficiary}
+}
+
+func (d *Debug) parseEntryPointAddress(ep string) (common.Address, error) {
+	if common.HexToAddress(ep) != d.entrypoint {
+		return common.Address{}, errors.New("entryPoint: Implementation not supported")
+	}
+
+	return d.entrypoint, nil
 }
 
 // ClearState clears the bundler mempool and reputation data of paymasters/accounts/factories/aggregators.
@@ -41,13 +52,16 @@ func (d *Debug) ClearState() (string, error) {
 	if err := d.mempool.Clear(); err != nil {
 		return "", err
 	}
+	if err := d.reputation.Clear(); err != nil {
+		return "", err
+	}
 
 	return "ok", nil
 }
 
 // DumpMempool dumps the current UserOperations mempool in order of arrival.
 func (d *Debug) DumpMempool(ep string) ([]map[string]any, error) {
-	ops, err := d.mempool.Dump(common.HexToAddress(ep))
+	ops, err := d.mempool.DumpAll(common.HexToAddress(ep))
 	if err != nil {
 		return []map[string]any{}, err
 	}
@@ -103,3 +117,35 @@ func (d *Debug) SetBundlingMode(mode string) (string, error) {
 
 	return "ok", nil
 }
+
+// DumpReputation returns the reputation of all entities seen by the bundler for the given EntryPoint. Only
+// the EntryPoint used for debugging is supported.
+func (d *Debug) DumpReputation(ep string) ([]*entities.Entry, error) {
+	if _, err := d.parseEntryPointAddress(ep); err != nil {
+		return nil, err
+	}
+
+	return d.reputation.Dump()
+}
+
+// SetReputation overrides the opsSeen and opsIncluded counters of the given entities for the given
+// EntryPoint. Only the EntryPoint used for debugging is supported.
+func (d *Debug) SetReputation(entries []*entities.Entry, ep string) (string, error) {
+	if _, err := d.parseEntryPointAddress(ep); err != nil {
+		return "", err
+	}
+	if err := d.reputation.SetEntries(entries...); err != nil {
+		return "", err
+	}
+
+	return "ok", nil
+}
+
+// ClearReputation clears the reputation data of all paymasters/accounts/factories/aggregators.
+func (d *Debug) ClearReputation() (string, error) {
+	if err := d.reputation.Clear(); err != nil {
+		return "", err
+	}
+
+	return "ok", nil
+}
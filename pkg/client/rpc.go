@@ -3,8 +3,15 @@ package client
 import (
 	"errors"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/access"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/entities"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/status"
 )
 
 // RpcAdapter is an adapter for routing JSON-RPC method calls to the correct client functions.
@@ -23,9 +30,15 @@ func (r *RpcAdapter) Eth_sendUserOperation(op map[string]any, ep string) (string
 	return r.client.SendUserOperation(op, ep)
 }
 
-// Eth_estimateUserOperationGas routes method calls to *Client.EstimateUserOperationGas.
-func (r *RpcAdapter) Eth_estimateUserOperationGas(op map[string]any, ep string) (*gas.GasEstimates, error) {
-	return r.client.EstimateUserOperationGas(op, ep)
+// Eth_estimateUserOperationGas routes method calls to *Client.EstimateUserOperationGas. The state overrides
+// and block params are optional.
+func (r *RpcAdapter) Eth_estimateUserOperationGas(
+	op map[string]any,
+	ep string,
+	overrides utils.StateOverrides,
+	block *rpc.BlockNumberOrHash,
+) (*gas.GasEstimates, error) {
+	return r.client.EstimateUserOperationGas(op, ep, overrides, block)
 }
 
 // Eth_getUserOperationReceipt routes method calls to *Client.GetUserOperationReceipt.
@@ -42,6 +55,25 @@ func (r *RpcAdapter) Eth_getUserOperationByHash(
 	return r.client.GetUserOperationByHash(userOpHash)
 }
 
+// Eth_getUserOperationStatus routes method calls to *Client.GetUserOperationStatus.
+func (r *RpcAdapter) Eth_getUserOperationStatus(userOpHash string) (*status.Record, error) {
+	return r.client.GetUserOperationStatus(userOpHash)
+}
+
+// Eth_suggestUserOperationFees routes method calls to *Client.SuggestUserOperationFees. Both params are
+// optional.
+func (r *RpcAdapter) Eth_suggestUserOperationFees(
+	op map[string]any,
+	ep *string,
+) (*gasprice.FeeSuggestion, error) {
+	return r.client.SuggestUserOperationFees(op, ep)
+}
+
+// Eth_maxPriorityFeePerGas routes method calls to *Client.MaxPriorityFeePerGas.
+func (r *RpcAdapter) Eth_maxPriorityFeePerGas() (string, error) {
+	return r.client.MaxPriorityFeePerGas()
+}
+
 // Eth_supportedEntryPoints routes method calls to *Client.SupportedEntryPoints.
 func (r *RpcAdapter) Eth_supportedEntryPoints() ([]string, error) {
 	return r.client.SupportedEntryPoints()
@@ -87,3 +119,66 @@ func (r *RpcAdapter) Debug_bundler_setBundlingMode(mode string) (string, error)
 
 	return r.debug.SetBundlingMode(mode)
 }
+
+// Debug_bundler_dumpReputation routes method calls to *Debug.DumpReputation.
+func (r *RpcAdapter) Debug_bundler_dumpReputation(ep string) ([]*entities.Entry, error) {
+	if r.debug == nil {
+		return []*entities.Entry{}, errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.DumpReputation(ep)
+}
+
+// Debug_bundler_setReputation routes method calls to *Debug.SetReputation.
+func (r *RpcAdapter) Debug_bundler_setReputation(entries []*entities.Entry, ep string) (string, error) {
+	if r.debug == nil {
+		return "", errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.SetReputation(entries, ep)
+}
+
+// Debug_bundler_clearReputation routes method calls to *Debug.ClearReputation.
+func (r *RpcAdapter) Debug_bundler_clearReputation() (string, error) {
+	if r.debug == nil {
+		return "", errors.New("rpc: debug mode is not enabled")
+	}
+
+	return r.debug.ClearReputation()
+}
+
+// AdminRpcAdapter is an adapter for routing admin JSON-RPC method calls to the correct Admin functions. It
+// should be served separately from the RpcAdapter behind authentication.
+type AdminRpcAdapter struct {
+	admin *Admin
+}
+
+// NewAdminRpcAdapter initializes a new AdminRpcAdapter which can be used with a JSON-RPC server.
+func NewAdminRpcAdapter(admin *Admin) *AdminRpcAdapter {
+	return &AdminRpcAdapter{admin}
+}
+
+// Admin_rotateSigner routes method calls to *Admin.RotateSigner.
+func (r *AdminRpcAdapter) Admin_rotateSigner(params map[string]any) (string, error) {
+	return r.admin.RotateSigner(params)
+}
+
+// Admin_allowEntities routes method calls to *Admin.AllowEntities.
+func (r *AdminRpcAdapter) Admin_allowEntities(entities []common.Address) (string, error) {
+	return r.admin.AllowEntities(entities)
+}
+
+// Admin_denyEntities routes method calls to *Admin.DenyEntities.
+func (r *AdminRpcAdapter) Admin_denyEntities(entities []common.Address) (string, error) {
+	return r.admin.DenyEntities(entities)
+}
+
+// Admin_removeEntities routes method calls to *Admin.RemoveEntities.
+func (r *AdminRpcAdapter) Admin_removeEntities(entities []common.Address) (string, error) {
+	return r.admin.RemoveEntities(entities)
+}
+
+// Admin_dumpAccessList routes method calls to *Admin.DumpAccessList.
+func (r *AdminRpcAdapter) Admin_dumpAccessList() (*access.Dump, error) {
+	return r.admin.DumpAccessList()
+}
@@ -6,9 +6,12 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rpc"
+	ethRpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/access"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/status"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
@@ -33,11 +36,22 @@ func GetUserOpReceiptWithEthClient(eth *ethclient.Client) GetUserOpReceiptFunc {
 }
 
 // GetGasEstimateFunc is a general interface for fetching an estimate for verificationGasLimit and
-// callGasLimit given a userOp and EntryPoint address.
-type GetGasEstimateFunc = func(ep common.Address, op *userop.UserOperation) (verificationGas uint64, callGas uint64, err error)
+// callGasLimit given a userOp, EntryPoint address, optional state overrides, and an optional block to
+// simulate against.
+type GetGasEstimateFunc = func(
+	ep common.Address,
+	op *userop.UserOperation,
+	overrides utils.StateOverrides,
+	block *ethRpc.BlockNumberOrHash,
+) (verificationGas uint64, callGas uint64, err error)
 
 func getGasEstimateNoop() GetGasEstimateFunc {
-	return func(ep common.Address, op *userop.UserOperation) (verificationGas uint64, callGas uint64, err error) {
+	return func(
+		ep common.Address,
+		op *userop.UserOperation,
+		overrides utils.StateOverrides,
+		block *ethRpc.BlockNumberOrHash,
+	) (verificationGas uint64, callGas uint64, err error) {
 		//lint:ignore ST1005 This needs to match the bundler test spec.
 		return 0, 0, errors.New("Missing/invalid userOpHash")
 	}
@@ -46,12 +60,17 @@ func getGasEstimateNoop() GetGasEstimateFunc {
 // GetGasEstimateWithEthClient returns an implementation of GetGasEstimateFunc that relies on an eth client to
 // fetch an estimate for verificationGasLimit and callGasLimit.
 func GetGasEstimateWithEthClient(
-	rpc *rpc.Client,
+	rpc *ethRpc.Client,
 	ov *gas.Overhead,
 	chain *big.Int,
 	maxGasLimit *big.Int,
 ) GetGasEstimateFunc {
-	return func(ep common.Address, op *userop.UserOperation) (verificationGas uint64, callGas uint64, err error) {
+	return func(
+		ep common.Address,
+		op *userop.UserOperation,
+		overrides utils.StateOverrides,
+		block *ethRpc.BlockNumberOrHash,
+	) (verificationGas uint64, callGas uint64, err error) {
 		return gas.EstimateGas(&gas.EstimateInput{
 			Rpc:         rpc,
 			EntryPoint:  ep,
@@ -59,19 +78,26 @@ func GetGasEstimateWithEthClient(
 			Ov:          ov,
 			ChainID:     chain,
 			MaxGasLimit: maxGasLimit,
+			Overrides:   overrides,
+			Block:       block,
 		})
 	}
 }
 
 func GetGasEstimateNoTraceWithEthClient(
-	eoa *signer.EOA,
-	rpc *rpc.Client,
+	signers *signer.Provider,
+	rpc *ethRpc.Client,
 	ov *gas.Overhead,
 	chain *big.Int,
 	maxGasLimit *big.Int,
 	verificationGasLimit *big.Int,
 ) GetGasEstimateFunc {
-	return func(ep common.Address, op *userop.UserOperation) (verificationGas uint64, callGas uint64, err error) {
+	return func(
+		ep common.Address,
+		op *userop.UserOperation,
+		overrides utils.StateOverrides,
+		block *ethRpc.BlockNumberOrHash,
+	) (verificationGas uint64, callGas uint64, err error) {
 		return gas.EstimateGasNoTrace(&gas.EstimateInput{
 			Rpc:                  rpc,
 			EntryPoint:           ep,
@@ -80,7 +106,9 @@ func GetGasEstimateNoTraceWithEthClient(
 			ChainID:              chain,
 			MaxGasLimit:          maxGasLimit,
 			VerificationGasLimit: verificationGasLimit,
-			Signer:               eoa,
+			Signer:               signers.Get(),
+			Overrides:            overrides,
+			Block:                block,
 		})
 	}
 }
@@ -103,3 +131,53 @@ func GetUserOpByHashWithEthClient(eth *ethclient.Client) GetUserOpByHashFunc {
 		return filter.GetUserOperationByHash(eth, hash, ep, chain)
 	}
 }
+
+// GetUserOpStatusFunc is a general interface for fetching the last known status of a UserOperation given a
+// userOpHash.
+type GetUserOpStatusFunc = func(hash common.Hash) (*status.Record, error)
+
+func getUserOpStatusNoop() GetUserOpStatusFunc {
+	return func(hash common.Hash) (*status.Record, error) {
+		return nil, errors.New("status: userOp status is not supported")
+	}
+}
+
+// MarkUserOpAddedFunc is a general interface for recording a UserOperation that has been added to the mempool
+// given its userOpHash and EntryPoint address. Dropped maps the userOpHash of every op that was removed from
+// the mempool to make room for it to the reason it was removed.
+type MarkUserOpAddedFunc = func(ep common.Address, hash common.Hash, dropped map[common.Hash]string) error
+
+func markUserOpAddedNoop() MarkUserOpAddedFunc {
+	return func(ep common.Address, hash common.Hash, dropped map[common.Hash]string) error {
+		return nil
+	}
+}
+
+// RotateSignerFunc is a general interface for replacing the bundler's signer with an EOA loaded from a new key
+// source. If sweep is true, the remaining balance of the previous signer is transferred to the new one once
+// it has no pending transactions.
+type RotateSignerFunc = func(src signer.Source, sweep bool) (common.Address, error)
+
+func rotateSignerNoop() RotateSignerFunc {
+	return func(src signer.Source, sweep bool) (common.Address, error) {
+		return common.Address{}, errors.New("admin: signer rotation is not supported")
+	}
+}
+
+// UpdateAccessListFunc is a general interface for applying an access rule to a list of entities.
+type UpdateAccessListFunc = func(rule access.Rule, entities ...common.Address) error
+
+func updateAccessListNoop() UpdateAccessListFunc {
+	return func(rule access.Rule, entities ...common.Address) error {
+		return errors.New("admin: access lists are not supported")
+	}
+}
+
+// DumpAccessListFunc is a general interface for fetching all entities on the allowlist and denylist.
+type DumpAccessListFunc = func() (*access.Dump, error)
+
+func dumpAccessListNoop() DumpAccessListFunc {
+	return func() (*access.Dump, error) {
+		return nil, errors.New("admin: access lists are not supported")
+	}
+}
@@ -7,17 +7,21 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
+// SimulateHandleOp makes a static call to Entrypoint.simulateHandleOp(userop) at the given block and returns
+// the execution result. Any given state overrides are applied to the call. If block is nil, the latest block
+// is used.
 func SimulateHandleOp(
 	signer *signer.EOA,
 	chainId *big.Int,
@@ -27,39 +31,47 @@ func SimulateHandleOp(
 	target common.Address,
 	data []byte,
 	maxGasLimit *big.Int,
+	overrides utils.StateOverrides,
+	block *rpc.BlockNumberOrHash,
 ) (*reverts.ExecutionResultRevert, error) {
+	if op.Version() == userop.V07 {
+		return simulateHandleOpV07(signer, rpc, entryPoint, op, target, data, overrides, block)
+	}
+
 	ethClient := ethclient.NewClient(rpc)
 	ep, err := entrypoint.NewEntrypoint(entryPoint, ethClient)
 	if err != nil {
 		return nil, err
 	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(signer.PrivateKey, chainId)
-	if err != nil {
-		return nil, err
-	}
+	auth := signer.NewCallTransactor()
 	auth.GasLimit = math.MaxUint64
-	auth.NoSend = true
 
 	op.MaxFeePerGas = big.NewInt(1)
 	op.MaxPriorityFeePerGas = big.NewInt(1)
 
-	tx, err := ep.SimulateHandleOp(auth, entrypoint.UserOperation(*op), target, data)
+	tx, err := ep.SimulateHandleOp(auth, entrypoint.ToUserOperation(op), target, data)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = ethClient.CallContract(context.Background(), ethereum.CallMsg{
-		From:       signer.Address,
-		To:         tx.To(),
-		Gas:        tx.Gas(),
-		GasPrice:   nil,
-		GasFeeCap:  nil,
-		GasTipCap:  nil,
-		Value:      big.NewInt(0),
-		Data:       tx.Data(),
-		AccessList: tx.AccessList(),
-	}, nil)
+	if len(overrides) == 0 && block == nil {
+		_, err = ethClient.CallContract(context.Background(), ethereum.CallMsg{
+			From:       signer.Address,
+			To:         tx.To(),
+			Gas:        tx.Gas(),
+			GasPrice:   nil,
+			GasFeeCap:  nil,
+			GasTipCap:  nil,
+			Value:      big.NewInt(0),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}, nil)
+	} else {
+		var out hexutil.Bytes
+		req := utils.TraceCallReq{From: signer.Address, To: *tx.To(), Data: tx.Data()}
+		err = rpc.CallContext(context.Background(), &out, "eth_call", &req, utils.BlockParam(block), overrides)
+	}
 
 	sim, simErr := reverts.NewExecutionResult(err)
 	if simErr != nil {
@@ -77,11 +89,53 @@ func SimulateHandleOp(
 	return sim, nil
 }
 
+// simulateHandleOpV07 makes a static call to EntryPointSimulations.simulateHandleOp(userop) with the
+// simulation code overriding the EntryPoint.
+func simulateHandleOpV07(
+	signer *signer.EOA,
+	rpc *rpc.Client,
+	entryPoint common.Address,
+	op *userop.UserOperation,
+	target common.Address,
+	data []byte,
+	extra utils.StateOverrides,
+	block *rpc.BlockNumberOrHash,
+) (*reverts.ExecutionResultRevert, error) {
+	op.MaxFeePerGas = big.NewInt(1)
+	op.MaxPriorityFeePerGas = big.NewInt(1)
+
+	req, overrides, err := simulateHandleOpCallData(entryPoint, op, target, data)
+	if err != nil {
+		return nil, err
+	}
+	req.From = signer.Address
+
+	var out hexutil.Bytes
+	overrides = extra.Merge(overrides)
+	err = rpc.CallContext(context.Background(), &out, "eth_call", req, utils.BlockParam(block), overrides)
+	if err != nil {
+		fo, foErr := reverts.NewFailedOp(err)
+		if foErr != nil {
+			fs, fsErr := reverts.NewFailedStr(err)
+			if fsErr != nil {
+				return nil, fmt.Errorf("%s, %s, %s", err, foErr, fsErr)
+			}
+			return nil, errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, fs.Reason, fs)
+		}
+		return nil, errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, fo.Reason, fo)
+	}
+
+	return reverts.NewExecutionResultV07(out)
+}
+
+// EstimateCreationGas returns the gas used by the factory to deploy the sender at the given block. If block is
+// nil, the latest block is used. Zero is returned if the op has no initCode.
 func EstimateCreationGas(
 	signer *signer.EOA,
 	rpc *rpc.Client,
 	op *userop.UserOperation,
 	maxGasLimit *big.Int,
+	block *rpc.BlockNumberOrHash,
 ) (uint64, error) {
 	// if wallet inited
 	if len(op.InitCode) == 0 {
@@ -91,6 +145,13 @@ func EstimateCreationGas(
 	factoryAddress := common.BytesToAddress(op.InitCode[:20])
 	walletCreateCallData := op.InitCode[20:]
 
+	if block != nil {
+		var gas hexutil.Uint64
+		req := utils.TraceCallReq{From: signer.Address, To: factoryAddress, Data: walletCreateCallData}
+		err := rpc.CallContext(context.Background(), &gas, "eth_estimateGas", &req, utils.BlockParam(block))
+		return uint64(gas), err
+	}
+
 	ethClient := ethclient.NewClient(rpc)
 
 	return ethClient.EstimateGas(context.Background(), ethereum.CallMsg{
@@ -1,12 +1,9 @@
 package execution
 
 import (
-	"context"
 	"fmt"
-	"math"
 	"math/big"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -27,8 +24,10 @@ type TraceInput struct {
 	ChainID    *big.Int
 
 	// Optional params for simulateHandleOps
-	Target common.Address
-	Data   []byte
+	Target    common.Address
+	Data      []byte
+	Overrides utils.StateOverrides
+	Block     *ethRpc.BlockNumberOrHash
 }
 
 type TraceOutput struct {
@@ -67,51 +66,57 @@ func parseUserOperationEvent(
 	return ev, nil
 }
 
-func TraceSimulateHandleOp(in *TraceInput) (*TraceOutput, error) {
-	ep, err := entrypoint.NewEntrypoint(in.EntryPoint, ethclient.NewClient(in.Rpc))
+// decodeExecutionResult parses the output of a simulateHandleOp trace. In v0.6 the result is always a
+// revert, whereas in v0.7 a successful result is returned and only failures revert.
+func decodeExecutionResult(op *userop.UserOperation, output string) (*reverts.ExecutionResultRevert, error) {
+	if op.Version() == userop.V07 {
+		data, err := hexutil.Decode(output)
+		if err != nil {
+			return nil, err
+		}
+		if sim, simErr := reverts.NewExecutionResultV07(data); simErr == nil {
+			return sim, nil
+		}
+	}
+
+	outErr, err := errors.ParseHexToRpcDataError(output)
 	if err != nil {
 		return nil, err
 	}
-	auth, err := bind.NewKeyedTransactorWithChainID(utils.DummyPk, in.ChainID)
+	sim, simErr := reverts.NewExecutionResult(outErr)
+	if simErr != nil {
+		fo, foErr := reverts.NewFailedOp(outErr)
+		if foErr != nil {
+			return nil, fmt.Errorf("%s, %s", simErr, foErr)
+		}
+		return nil, errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, fo.Reason, fo)
+	}
+	return sim, nil
+}
+
+func TraceSimulateHandleOp(in *TraceInput) (*TraceOutput, error) {
+	ep, err := entrypoint.NewEntrypoint(in.EntryPoint, ethclient.NewClient(in.Rpc))
 	if err != nil {
 		return nil, err
 	}
-	auth.GasLimit = math.MaxUint64
-	auth.NoSend = true
-	tx, err := ep.SimulateHandleOp(auth, entrypoint.UserOperation(*in.Op), in.Target, in.Data)
+	req, overrides, err := simulateHandleOpCallData(in.EntryPoint, in.Op, in.Target, in.Data)
 	if err != nil {
 		return nil, err
 	}
 	out := &TraceOutput{}
 
-	var res tracer.BundlerExecutionReturn
-	req := utils.TraceCallReq{
-		From: common.HexToAddress("0x"),
-		To:   in.EntryPoint,
-		Data: tx.Data(),
-	}
-	opts := utils.TraceCallOpts{
-		Tracer: tracer.Loaded.BundlerExecutionTracer,
-	}
-	if err := in.Rpc.CallContext(context.Background(), &res, "debug_traceCall", &req, "latest", &opts); err != nil {
-		return nil, err
-	}
-	outErr, err := errors.ParseHexToRpcDataError(res.Output)
+	res, err := utils.TraceCallExecution(in.Rpc, req, in.Overrides.Merge(overrides), in.Block)
 	if err != nil {
 		return nil, err
 	}
 	if res.ValidationOOG {
 		return nil, errors.NewRPCError(errors.EXECUTION_REVERTED, "validation OOG", nil)
 	}
-	out.Trace = &res
+	out.Trace = res
 
-	sim, simErr := reverts.NewExecutionResult(outErr)
-	if simErr != nil {
-		fo, foErr := reverts.NewFailedOp(outErr)
-		if foErr != nil {
-			return nil, fmt.Errorf("%s, %s", simErr, foErr)
-		}
-		return nil, errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, fo.Reason, fo)
+	sim, err := decodeExecutionResult(in.Op, res.Output)
+	if err != nil {
+		return nil, err
 	}
 	out.Result = sim
 
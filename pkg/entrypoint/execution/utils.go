@@ -0,0 +1,48 @@
+package execution
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// simulateHandleOpCallData returns a call request and any required state overrides for calling
+// simulateHandleOp on the given EntryPoint.
+func simulateHandleOpCallData(
+	entryPoint common.Address,
+	op *userop.UserOperation,
+	target common.Address,
+	data []byte,
+) (*utils.TraceCallReq, utils.StateOverrides, error) {
+	if op.Version() == userop.V07 {
+		overrides, err := utils.SimulationsV07Overrides(entryPoint)
+		if err != nil {
+			return nil, nil, err
+		}
+		args, err := methods.SimulateHandleOpV07Method.Inputs.Pack(op.ToPacked(), target, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &utils.TraceCallReq{
+			From: common.HexToAddress("0x"),
+			To:   entryPoint,
+			Data: append(methods.SimulateHandleOpV07Method.ID, args...),
+		}, overrides, nil
+	}
+
+	epAbi, err := entrypoint.EntrypointMetaData.GetAbi()
+	if err != nil {
+		return nil, nil, err
+	}
+	args, err := epAbi.Pack("simulateHandleOp", entrypoint.ToUserOperation(op), target, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &utils.TraceCallReq{
+		From: common.HexToAddress("0x"),
+		To:   entryPoint,
+		Data: args,
+	}, nil, nil
+}
@@ -102,6 +102,58 @@ func GetUserOperationByHash(
 					}, nil
 				}
 			}
+		} else if strings.HasPrefix(hex, methods.HandleAggregatedOpsSelector) ||
+			strings.HasPrefix(hex, methods.HandleAggregatedOpsV07Selector) {
+			ops, err := decodeAggregatedOps(hex)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, op := range ops {
+				if op.GetUserOpHash(entryPoint, chainID).String() == userOpHash {
+					return &HashLookupResult{
+						UserOperation:   op,
+						EntryPoint:      entryPoint.String(),
+						BlockNumber:     receipt.BlockNumber,
+						BlockHash:       receipt.BlockHash,
+						TransactionHash: it.Event.Raw.TxHash,
+					}, nil
+				}
+			}
+		} else if strings.HasPrefix(hex, methods.HandleOpsV07Selector) {
+			data := common.Hex2Bytes(hex[len(methods.HandleOpsV07Selector):])
+			args, err := methods.HandleOpsV07Method.Inputs.Unpack(data)
+			if err != nil {
+				return nil, err
+			}
+			if len(args) != 2 {
+				return nil, fmt.Errorf(
+					"handleOps: invalid input length: expected 2, got %d",
+					len(args),
+				)
+			}
+
+			raw, err := json.Marshal(args[0])
+			if err != nil {
+				return nil, err
+			}
+			var ops []userop.PackedUserOperation
+			if err = json.Unmarshal(raw, &ops); err != nil {
+				return nil, err
+			}
+
+			for i := range ops {
+				op := userop.NewFromPacked(&ops[i])
+				if op.GetUserOpHash(entryPoint, chainID).String() == userOpHash {
+					return &HashLookupResult{
+						UserOperation:   op,
+						EntryPoint:      entryPoint.String(),
+						BlockNumber:     receipt.BlockNumber,
+						BlockHash:       receipt.BlockHash,
+						TransactionHash: it.Event.Raw.TxHash,
+					}, nil
+				}
+			}
 		}
 
 	}
@@ -109,3 +161,58 @@ func GetUserOperationByHash(
 	//lint:ignore ST1005 This needs to match the bundler test spec.
 	return nil, errors.New("Missing/invalid userOpHash")
 }
+
+// decodeAggregatedOps returns a flattened array of all UserOperations from handleAggregatedOps() calldata.
+func decodeAggregatedOps(hex string) ([]*userop.UserOperation, error) {
+	isV07 := strings.HasPrefix(hex, methods.HandleAggregatedOpsV07Selector)
+	method := methods.HandleAggregatedOpsMethod
+	if isV07 {
+		method = methods.HandleAggregatedOpsV07Method
+	}
+
+	data := common.Hex2Bytes(hex[len(methods.HandleAggregatedOpsSelector):])
+	args, err := method.Inputs.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 2 {
+		return nil, fmt.Errorf(
+			"handleAggregatedOps: invalid input length: expected 2, got %d",
+			len(args),
+		)
+	}
+
+	raw, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	ops := []*userop.UserOperation{}
+	if isV07 {
+		var opa []struct {
+			UserOps []userop.PackedUserOperation `json:"userOps"`
+		}
+		if err := json.Unmarshal(raw, &opa); err != nil {
+			return nil, err
+		}
+		for _, g := range opa {
+			for i := range g.UserOps {
+				ops = append(ops, userop.NewFromPacked(&g.UserOps[i]))
+			}
+		}
+		return ops, nil
+	}
+
+	var opa []struct {
+		UserOps []userop.UserOperation `json:"userOps"`
+	}
+	if err := json.Unmarshal(raw, &opa); err != nil {
+		return nil, err
+	}
+	for _, g := range opa {
+		for i := range g.UserOps {
+			ops = append(ops, &g.UserOps[i])
+		}
+	}
+	return ops, nil
+}
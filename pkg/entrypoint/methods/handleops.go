@@ -21,4 +21,63 @@ var (
 		nil,
 	)
 	HandleOpsSelector = hexutil.Encode(HandleOpsMethod.ID)
+
+	HandleOpsV07Method = abi.NewMethod(
+		"handleOps",
+		"handleOps",
+		abi.Function,
+		"",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "ops", Type: userop.PackedUserOpArr},
+			{Name: "beneficiary", Type: address},
+		},
+		nil,
+	)
+	HandleOpsV07Selector = hexutil.Encode(HandleOpsV07Method.ID)
+)
+
+var (
+	opsPerAggregatorType, _ = abi.NewType("tuple[]", "opsPerAggregator", []abi.ArgumentMarshaling{
+		{Name: "userOps", Type: "tuple[]", Components: userop.UserOpPrimitives},
+		{Name: "aggregator", Type: "address"},
+		{Name: "signature", Type: "bytes"},
+	})
+
+	opsPerAggregatorV07Type, _ = abi.NewType("tuple[]", "opsPerAggregator", []abi.ArgumentMarshaling{
+		{Name: "userOps", Type: "tuple[]", Components: userop.PackedUserOpPrimitives},
+		{Name: "aggregator", Type: "address"},
+		{Name: "signature", Type: "bytes"},
+	})
+
+	HandleAggregatedOpsMethod = abi.NewMethod(
+		"handleAggregatedOps",
+		"handleAggregatedOps",
+		abi.Function,
+		"",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "opsPerAggregator", Type: opsPerAggregatorType},
+			{Name: "beneficiary", Type: address},
+		},
+		nil,
+	)
+	HandleAggregatedOpsSelector = hexutil.Encode(HandleAggregatedOpsMethod.ID)
+
+	HandleAggregatedOpsV07Method = abi.NewMethod(
+		"handleAggregatedOps",
+		"handleAggregatedOps",
+		abi.Function,
+		"",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "opsPerAggregator", Type: opsPerAggregatorV07Type},
+			{Name: "beneficiary", Type: address},
+		},
+		nil,
+	)
+	HandleAggregatedOpsV07Selector = hexutil.Encode(HandleAggregatedOpsV07Method.ID)
 )
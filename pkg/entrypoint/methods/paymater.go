@@ -28,6 +28,25 @@ var (
 		},
 	)
 	ValidatePaymasterUserOpSelector = hexutil.Encode(ValidatePaymasterUserOpMethod.ID)
+
+	ValidatePaymasterUserOpV07Method = abi.NewMethod(
+		"validatePaymasterUserOp",
+		"validatePaymasterUserOp",
+		abi.Function,
+		"",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "userOp", Type: userop.PackedUserOpType},
+			{Name: "userOpHash", Type: bytes32},
+			{Name: "maxCost", Type: uint256},
+		},
+		abi.Arguments{
+			{Name: "context", Type: bytes},
+			{Name: "validationData", Type: uint256},
+		},
+	)
+	ValidatePaymasterUserOpV07Selector = hexutil.Encode(ValidatePaymasterUserOpV07Method.ID)
 )
 
 type validatePaymasterUserOpOutput struct {
@@ -0,0 +1,40 @@
+package methods
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// SimulateValidationV07Method is the ABI for simulateValidation on the v0.7 EntryPointSimulations
+	// contract.
+	SimulateValidationV07Method = abi.NewMethod(
+		"simulateValidation",
+		"simulateValidation",
+		abi.Function,
+		"",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "userOp", Type: userop.PackedUserOpType},
+		},
+		reverts.ValidationResultV07Outputs,
+	)
+
+	// SimulateHandleOpV07Method is the ABI for simulateHandleOp on the v0.7 EntryPointSimulations contract.
+	SimulateHandleOpV07Method = abi.NewMethod(
+		"simulateHandleOp",
+		"simulateHandleOp",
+		abi.Function,
+		"",
+		false,
+		false,
+		abi.Arguments{
+			{Name: "op", Type: userop.PackedUserOpType},
+			{Name: "target", Type: address},
+			{Name: "targetCallData", Type: bytes},
+		},
+		reverts.ExecutionResultV07Outputs,
+	)
+)
@@ -1,12 +1,14 @@
 package reverts
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -68,3 +70,60 @@ func NewExecutionResult(err error) (*ExecutionResultRevert, error) {
 		TargetResult:  args[5].([]byte),
 	}, nil
 }
+
+var (
+	// ExecutionResultV07Outputs is the ABI for the return value of EntryPointSimulations.simulateHandleOp in
+	// v0.7.
+	ExecutionResultV07Outputs = func() abi.Arguments {
+		t, _ := abi.NewType("tuple", "ExecutionResult", []abi.ArgumentMarshaling{
+			{Name: "preOpGas", Type: "uint256"},
+			{Name: "paid", Type: "uint256"},
+			{Name: "accountValidationData", Type: "uint256"},
+			{Name: "paymasterValidationData", Type: "uint256"},
+			{Name: "targetSuccess", Type: "bool"},
+			{Name: "targetResult", Type: "bytes"},
+		})
+		return abi.Arguments{{Name: "result", Type: t}}
+	}()
+)
+
+// NewExecutionResultV07 decodes the return value of EntryPointSimulations.simulateHandleOp in v0.7 into the
+// same format as the ExecutionResult revert in v0.6.
+func NewExecutionResultV07(data []byte) (*ExecutionResultRevert, error) {
+	ret, err := ExecutionResultV07Outputs.Unpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("executionResult: %s, data: %s", err, hexutil.Encode(data))
+	}
+	if len(ret) != 1 {
+		return nil, fmt.Errorf("executionResult: invalid args length: expected 1, got %d", len(ret))
+	}
+
+	raw, err := json.Marshal(ret[0])
+	if err != nil {
+		return nil, fmt.Errorf("executionResult: %s", err)
+	}
+	var res struct {
+		PreOpGas                *big.Int `json:"preOpGas"`
+		Paid                    *big.Int `json:"paid"`
+		AccountValidationData   *big.Int `json:"accountValidationData"`
+		PaymasterValidationData *big.Int `json:"paymasterValidationData"`
+		TargetSuccess           bool     `json:"targetSuccess"`
+		TargetResult            []byte   `json:"targetResult"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, fmt.Errorf("executionResult: %s", err)
+	}
+
+	validAfter, validUntil := intersectValidationData(
+		ParseValidationData(res.AccountValidationData),
+		ParseValidationData(res.PaymasterValidationData),
+	)
+	return &ExecutionResultRevert{
+		PreOpGas:      res.PreOpGas,
+		Paid:          res.Paid,
+		ValidAfter:    validAfter,
+		ValidUntil:    validUntil,
+		TargetSuccess: res.TargetSuccess,
+		TargetResult:  res.TargetResult,
+	}, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -24,6 +25,54 @@ func failedOp() abi.Error {
 	})
 }
 
+func failedOpWithRevert() abi.Error {
+	opIndex, _ := abi.NewType("uint256", "uint256", nil)
+	reason, _ := abi.NewType("string", "string", nil)
+	inner, _ := abi.NewType("bytes", "bytes", nil)
+	return abi.NewError("FailedOpWithRevert", abi.Arguments{
+		{Name: "opIndex", Type: opIndex},
+		{Name: "reason", Type: reason},
+		{Name: "inner", Type: inner},
+	})
+}
+
+// newFailedOpWithRevert decodes the v0.7 FailedOpWithRevert error. The inner revert data is decoded and
+// appended to the reason if possible.
+func newFailedOpWithRevert(data []byte) (*FailedOpRevert, error) {
+	fowr := failedOpWithRevert()
+	revert, err := fowr.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	args, ok := revert.([]any)
+	if !ok || len(args) != 3 {
+		return nil, errors.New("failedOpWithRevert: cannot assert type: args is not of type []any")
+	}
+	opIndex, ok := args[0].(*big.Int)
+	if !ok {
+		return nil, errors.New("failedOpWithRevert: cannot assert type: opIndex is not of type *big.Int")
+	}
+	reason, ok := args[1].(string)
+	if !ok {
+		return nil, errors.New("failedOpWithRevert: cannot assert type: reason is not of type string")
+	}
+	inner, ok := args[2].([]byte)
+	if !ok {
+		return nil, errors.New("failedOpWithRevert: cannot assert type: inner is not of type []byte")
+	}
+
+	if str, err := abi.UnpackRevert(inner); err == nil {
+		reason = fmt.Sprintf("%s %s", reason, str)
+	} else if len(inner) > 0 {
+		reason = fmt.Sprintf("%s %s", reason, hexutil.Encode(inner))
+	}
+	return &FailedOpRevert{
+		OpIndex: int(opIndex.Int64()),
+		Reason:  reason,
+	}, nil
+}
+
 func NewFailedOp(err error) (*FailedOpRevert, error) {
 	rpcErr, ok := err.(rpc.DataError)
 	if !ok {
@@ -45,6 +94,9 @@ func NewFailedOp(err error) (*FailedOpRevert, error) {
 	failedOp := failedOp()
 	revert, err := failedOp.Unpack(common.Hex2Bytes(data[2:]))
 	if err != nil {
+		if fo, foErr := newFailedOpWithRevert(common.Hex2Bytes(data[2:])); foErr == nil {
+			return fo, nil
+		}
 		return nil, fmt.Errorf("failedOp: %s, data: %s", err, data)
 	}
 
@@ -0,0 +1,60 @@
+package reverts
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// SigValidationFailedAddress is the aggregator value returned in validationData to signal an invalid
+	// signature.
+	SigValidationFailedAddress = common.BigToAddress(common.Big1)
+
+	maxUint48 = big.NewInt(0).SetUint64(1<<48 - 1)
+)
+
+// ValidationData is the decoded form of the packed uint256 returned by an account or paymaster during
+// validation.
+type ValidationData struct {
+	Aggregator common.Address
+	ValidAfter *big.Int
+	ValidUntil *big.Int
+}
+
+// ParseValidationData unpacks validationData into an aggregator address and a validity time range. A
+// validUntil value of 0 is interpreted as no expiry.
+func ParseValidationData(vd *big.Int) *ValidationData {
+	b := common.LeftPadBytes(vd.Bytes(), 32)
+	validAfter := big.NewInt(0).SetBytes(b[0:6])
+	validUntil := big.NewInt(0).SetBytes(b[6:12])
+	if validUntil.Cmp(common.Big0) == 0 {
+		validUntil = big.NewInt(0).Set(maxUint48)
+	}
+
+	return &ValidationData{
+		Aggregator: common.BytesToAddress(b[12:]),
+		ValidAfter: validAfter,
+		ValidUntil: validUntil,
+	}
+}
+
+// SigFailed returns true if the validationData signals an invalid signature.
+func (vd *ValidationData) SigFailed() bool {
+	return vd.Aggregator == SigValidationFailedAddress
+}
+
+// intersectValidationData returns the time range in which both the account and paymaster validationData are
+// valid.
+func intersectValidationData(account *ValidationData, paymaster *ValidationData) (validAfter, validUntil *big.Int) {
+	validAfter = account.ValidAfter
+	if paymaster.ValidAfter.Cmp(validAfter) > 0 {
+		validAfter = paymaster.ValidAfter
+	}
+
+	validUntil = account.ValidUntil
+	if paymaster.ValidUntil.Cmp(validUntil) < 0 {
+		validUntil = paymaster.ValidUntil
+	}
+	return validAfter, validUntil
+}
@@ -25,11 +25,19 @@ type StakeInfo struct {
 	UnstakeDelaySec *big.Int `json:"unstakeDelaySec"`
 }
 
+type AggregatorStakeInfo struct {
+	Aggregator common.Address `json:"aggregator"`
+	StakeInfo  *StakeInfo     `json:"stakeInfo"`
+}
+
 type ValidationResultRevert struct {
 	ReturnInfo    *ReturnInfo
 	SenderInfo    *StakeInfo
 	FactoryInfo   *StakeInfo
 	PaymasterInfo *StakeInfo
+
+	// AggregatorInfo is only set if the account uses a signature aggregator. Otherwise it is nil.
+	AggregatorInfo *AggregatorStakeInfo
 }
 
 var (
@@ -45,6 +53,10 @@ var (
 		{Name: "stake", Type: "uint256"},
 		{Name: "unstakeDelaySec", Type: "uint256"},
 	}
+	aggregatorStakeInfoType = []abi.ArgumentMarshaling{
+		{Name: "aggregator", Type: "address"},
+		{Name: "stakeInfo", Type: "tuple", Components: stakeInfoType},
+	}
 )
 
 func validationResult() abi.Error {
@@ -61,6 +73,35 @@ func validationResult() abi.Error {
 	})
 }
 
+func validationResultWithAggregation() abi.Error {
+	returnInfo, _ := abi.NewType("tuple", "ReturnInfo", returnInfoType)
+	senderInfo, _ := abi.NewType("tuple", "SenderInfo", stakeInfoType)
+	factoryInfo, _ := abi.NewType("tuple", "FactoryInfo", stakeInfoType)
+	paymasterInfo, _ := abi.NewType("tuple", "PaymasterInfo", stakeInfoType)
+	aggregatorInfo, _ := abi.NewType("tuple", "AggregatorInfo", aggregatorStakeInfoType)
+
+	return abi.NewError("ValidationResultWithAggregation", abi.Arguments{
+		{Name: "returnInfo", Type: returnInfo},
+		{Name: "senderInfo", Type: senderInfo},
+		{Name: "factoryInfo", Type: factoryInfo},
+		{Name: "paymasterInfo", Type: paymasterInfo},
+		{Name: "aggregatorInfo", Type: aggregatorInfo},
+	})
+}
+
+func decodeArg(arg any, v any) error {
+	raw, err := json.Marshal(arg)
+	if err != nil {
+		return fmt.Errorf("validationResult: %s", err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("validationResult: %s", err)
+	}
+	return nil
+}
+
+// NewValidationResult decodes a ValidationResult or ValidationResultWithAggregation revert from
+// EntryPoint.simulateValidation in v0.6.
 func NewValidationResult(err error) (*ValidationResultRevert, error) {
 	rpcErr, ok := err.(rpc.DataError)
 	if !ok {
@@ -74,58 +115,138 @@ func NewValidationResult(err error) (*ValidationResultRevert, error) {
 
 	sim := validationResult()
 	revert, err := sim.Unpack(common.Hex2Bytes(data[2:]))
+	expectedArgs := 4
 	if err != nil {
-		return nil, fmt.Errorf("validationResult: %s", err)
+		simAgg := validationResultWithAggregation()
+		var aggErr error
+		revert, aggErr = simAgg.Unpack(common.Hex2Bytes(data[2:]))
+		if aggErr != nil {
+			return nil, fmt.Errorf("validationResult: %s", err)
+		}
+		expectedArgs = 5
 	}
 
 	args, ok := revert.([]any)
 	if !ok {
 		return nil, errors.New("validationResult: cannot assert type: args is not of type []any")
 	}
-	if len(args) != 4 {
-		return nil, fmt.Errorf("validationResult: invalid args length: expected 4, got %d", len(args))
+	if len(args) != expectedArgs {
+		return nil, fmt.Errorf("validationResult: invalid args length: expected %d, got %d", expectedArgs, len(args))
 	}
 
 	returnInfo := &ReturnInfo{}
-	ri, err := json.Marshal(args[0])
-	if err != nil {
-		return nil, fmt.Errorf("validationResult: %s", err)
-	}
-	if err := json.Unmarshal(ri, returnInfo); err != nil {
-		return nil, fmt.Errorf("validationResult: %s", err)
+	if err := decodeArg(args[0], returnInfo); err != nil {
+		return nil, err
 	}
 
 	senderInfo := &StakeInfo{}
-	si, err := json.Marshal(args[1])
-	if err != nil {
-		return nil, fmt.Errorf("validationResult: %s", err)
-	}
-	if err := json.Unmarshal(si, senderInfo); err != nil {
-		return nil, fmt.Errorf("validationResult: %s", err)
+	if err := decodeArg(args[1], senderInfo); err != nil {
+		return nil, err
 	}
 
 	factoryInfo := &StakeInfo{}
-	fi, err := json.Marshal(args[2])
+	if err := decodeArg(args[2], factoryInfo); err != nil {
+		return nil, err
+	}
+
+	paymasterInfo := &StakeInfo{}
+	if err := decodeArg(args[3], paymasterInfo); err != nil {
+		return nil, err
+	}
+
+	var aggregatorInfo *AggregatorStakeInfo
+	if expectedArgs == 5 {
+		aggregatorInfo = &AggregatorStakeInfo{}
+		if err := decodeArg(args[4], aggregatorInfo); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ValidationResultRevert{
+		ReturnInfo:     returnInfo,
+		SenderInfo:     senderInfo,
+		FactoryInfo:    factoryInfo,
+		PaymasterInfo:  paymasterInfo,
+		AggregatorInfo: aggregatorInfo,
+	}, nil
+}
+
+var (
+	returnInfoV07Type = []abi.ArgumentMarshaling{
+		{Name: "preOpGas", Type: "uint256"},
+		{Name: "prefund", Type: "uint256"},
+		{Name: "accountValidationData", Type: "uint256"},
+		{Name: "paymasterValidationData", Type: "uint256"},
+		{Name: "paymasterContext", Type: "bytes"},
+	}
+	validationResultV07Type = []abi.ArgumentMarshaling{
+		{Name: "returnInfo", Type: "tuple", Components: returnInfoV07Type},
+		{Name: "senderInfo", Type: "tuple", Components: stakeInfoType},
+		{Name: "factoryInfo", Type: "tuple", Components: stakeInfoType},
+		{Name: "paymasterInfo", Type: "tuple", Components: stakeInfoType},
+		{Name: "aggregatorInfo", Type: "tuple", Components: aggregatorStakeInfoType},
+	}
+
+	// ValidationResultV07Outputs is the ABI for the return value of EntryPointSimulations.simulateValidation
+	// in v0.7.
+	ValidationResultV07Outputs = func() abi.Arguments {
+		t, _ := abi.NewType("tuple", "ValidationResult", validationResultV07Type)
+		return abi.Arguments{{Name: "result", Type: t}}
+	}()
+)
+
+// NewValidationResultV07 decodes the return value of EntryPointSimulations.simulateValidation in v0.7 into the
+// same format as the ValidationResult revert in v0.6.
+func NewValidationResultV07(data []byte) (*ValidationResultRevert, error) {
+	ret, err := ValidationResultV07Outputs.Unpack(data)
 	if err != nil {
 		return nil, fmt.Errorf("validationResult: %s", err)
 	}
-	if err := json.Unmarshal(fi, factoryInfo); err != nil {
-		return nil, fmt.Errorf("validationResult: %s", err)
+	if len(ret) != 1 {
+		return nil, fmt.Errorf("validationResult: invalid args length: expected 1, got %d", len(ret))
 	}
 
-	paymasterInfo := &StakeInfo{}
-	pmi, err := json.Marshal(args[3])
+	raw, err := json.Marshal(ret[0])
 	if err != nil {
 		return nil, fmt.Errorf("validationResult: %s", err)
 	}
-	if err := json.Unmarshal(pmi, paymasterInfo); err != nil {
+	var res struct {
+		ReturnInfo struct {
+			PreOpGas                *big.Int `json:"preOpGas"`
+			Prefund                 *big.Int `json:"prefund"`
+			AccountValidationData   *big.Int `json:"accountValidationData"`
+			PaymasterValidationData *big.Int `json:"paymasterValidationData"`
+			PaymasterContext        []byte   `json:"paymasterContext"`
+		} `json:"returnInfo"`
+		SenderInfo     *StakeInfo           `json:"senderInfo"`
+		FactoryInfo    *StakeInfo           `json:"factoryInfo"`
+		PaymasterInfo  *StakeInfo           `json:"paymasterInfo"`
+		AggregatorInfo *AggregatorStakeInfo `json:"aggregatorInfo"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
 		return nil, fmt.Errorf("validationResult: %s", err)
 	}
 
+	account := ParseValidationData(res.ReturnInfo.AccountValidationData)
+	paymaster := ParseValidationData(res.ReturnInfo.PaymasterValidationData)
+	validAfter, validUntil := intersectValidationData(account, paymaster)
+
+	var aggregatorInfo *AggregatorStakeInfo
+	if res.AggregatorInfo != nil && res.AggregatorInfo.Aggregator != common.HexToAddress("0x") {
+		aggregatorInfo = res.AggregatorInfo
+	}
 	return &ValidationResultRevert{
-		ReturnInfo:    returnInfo,
-		SenderInfo:    senderInfo,
-		FactoryInfo:   factoryInfo,
-		PaymasterInfo: paymasterInfo,
+		ReturnInfo: &ReturnInfo{
+			PreOpGas:         res.ReturnInfo.PreOpGas,
+			Prefund:          res.ReturnInfo.Prefund,
+			SigFailed:        account.SigFailed() || paymaster.SigFailed(),
+			ValidAfter:       validAfter,
+			ValidUntil:       validUntil,
+			PaymasterContext: res.ReturnInfo.PaymasterContext,
+		},
+		SenderInfo:     res.SenderInfo,
+		FactoryInfo:    res.FactoryInfo,
+		PaymasterInfo:  res.PaymasterInfo,
+		AggregatorInfo: aggregatorInfo,
 	}, nil
 }
@@ -0,0 +1,90 @@
+package reverts
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type mockDataError struct {
+	data string
+}
+
+func (e *mockDataError) Error() string          { return "execution reverted" }
+func (e *mockDataError) ErrorData() interface{} { return e.data }
+
+type mockReturnInfo struct {
+	PreOpGas         *big.Int
+	Prefund          *big.Int
+	SigFailed        bool
+	ValidAfter       *big.Int
+	ValidUntil       *big.Int
+	PaymasterContext []byte
+}
+
+type mockStakeInfo struct {
+	Stake           *big.Int
+	UnstakeDelaySec *big.Int
+}
+
+type mockAggregatorStakeInfo struct {
+	Aggregator common.Address
+	StakeInfo  mockStakeInfo
+}
+
+// TestNewValidationResultWithAggregation calls reverts.NewValidationResult with an encoded v0.6
+// ValidationResultWithAggregation revert and verifies that the aggregator info is decoded.
+func TestNewValidationResultWithAggregation(t *testing.T) {
+	agg := common.HexToAddress("0x8Ba1f109551bD432803012645Ac136ddd64DBA72")
+	ri := mockReturnInfo{big.NewInt(1), big.NewInt(2), false, big.NewInt(0), big.NewInt(0), []byte{}}
+	si := mockStakeInfo{big.NewInt(0), big.NewInt(0)}
+	ai := mockAggregatorStakeInfo{agg, mockStakeInfo{big.NewInt(3), big.NewInt(4)}}
+
+	e := validationResultWithAggregation()
+	args, err := e.Inputs.Pack(ri, si, si, si, ai)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	data := hexutil.Encode(append(e.ID[:4], args...))
+
+	sim, err := NewValidationResult(&mockDataError{data})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if sim.AggregatorInfo == nil {
+		t.Fatal("got nil aggregator info, want non-nil")
+	}
+	if sim.AggregatorInfo.Aggregator != agg {
+		t.Fatalf("got aggregator %s, want %s", sim.AggregatorInfo.Aggregator, agg)
+	}
+	if sim.AggregatorInfo.StakeInfo.Stake.Cmp(big.NewInt(3)) != 0 {
+		t.Fatalf("got aggregator stake %s, want 3", sim.AggregatorInfo.StakeInfo.Stake)
+	}
+	if sim.ReturnInfo.Prefund.Cmp(big.NewInt(2)) != 0 {
+		t.Fatalf("got prefund %s, want 2", sim.ReturnInfo.Prefund)
+	}
+}
+
+// TestNewValidationResultWithoutAggregation calls reverts.NewValidationResult with an encoded v0.6
+// ValidationResult revert and verifies that the aggregator info is nil.
+func TestNewValidationResultWithoutAggregation(t *testing.T) {
+	ri := mockReturnInfo{big.NewInt(1), big.NewInt(2), false, big.NewInt(0), big.NewInt(0), []byte{}}
+	si := mockStakeInfo{big.NewInt(0), big.NewInt(0)}
+
+	e := validationResult()
+	args, err := e.Inputs.Pack(ri, si, si, si)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	data := hexutil.Encode(append(e.ID[:4], args...))
+
+	sim, err := NewValidationResult(&mockDataError{data})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if sim.AggregatorInfo != nil {
+		t.Fatalf("got aggregator info %v, want nil", sim.AggregatorInfo)
+	}
+}
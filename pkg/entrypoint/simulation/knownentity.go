@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
 	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
@@ -18,6 +19,7 @@ func newKnownEntity(
 	op *userop.UserOperation,
 	res *tracer.BundlerCollectorReturn,
 	stakes EntityStakes,
+	req stake.Requirement,
 ) (knownEntity, error) {
 	if len(res.NumberLevels) != 3 {
 		return nil, fmt.Errorf("unexpected NumberLevels length in tracing result: %d", len(res.NumberLevels))
@@ -27,17 +29,17 @@ func newKnownEntity(
 		"factory": {
 			Address:  op.GetFactory(),
 			Info:     res.NumberLevels[factoryNumberLevel],
-			IsStaked: stakes[op.GetFactory()] != nil && stakes[op.GetFactory()].Staked,
+			IsStaked: req.IsStaked(stakes[op.GetFactory()]),
 		},
 		"account": {
 			Address:  op.Sender,
 			Info:     res.NumberLevels[accountNumberLevel],
-			IsStaked: stakes[op.Sender] != nil && stakes[op.Sender].Staked,
+			IsStaked: req.IsStaked(stakes[op.Sender]),
 		},
 		"paymaster": {
 			Address:  op.GetPaymaster(),
 			Info:     res.NumberLevels[paymasterNumberLevel],
-			IsStaked: stakes[op.GetPaymaster()] != nil && stakes[op.GetPaymaster()].Staked,
+			IsStaked: req.IsStaked(stakes[op.GetPaymaster()]),
 		},
 	}, nil
 }
@@ -4,6 +4,9 @@ import (
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // EntityStakes provides a mapping for encountered entity addresses and their stake info on the EntryPoint.
@@ -46,3 +49,32 @@ var (
 	revertOpCode = "REVERT"
 	returnOpCode = "RETURN"
 )
+
+// simulateValidationCallData returns the calldata and any required state overrides for calling
+// simulateValidation on the given EntryPoint.
+func simulateValidationCallData(
+	entryPoint common.Address,
+	op *userop.UserOperation,
+) ([]byte, utils.StateOverrides, error) {
+	if op.Version() == userop.V07 {
+		overrides, err := utils.SimulationsV07Overrides(entryPoint)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := methods.SimulateValidationV07Method.Inputs.Pack(op.ToPacked())
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(methods.SimulateValidationV07Method.ID, data...), overrides, nil
+	}
+
+	epAbi, err := entrypoint.EntrypointMetaData.GetAbi()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := epAbi.Pack("simulateValidation", entrypoint.ToUserOperation(op))
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, nil, nil
+}
@@ -1,28 +1,42 @@
 package simulation
 
 import (
+	"context"
 	stdError "errors"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
-// SimulateValidation makes a static call to Entrypoint.simulateValidation(userop) and returns the
-// results without any state changes.
+// SimulateValidation makes a static call to Entrypoint.simulateValidation(userop) at the given block and
+// returns the results without any state changes. Any given state overrides are applied to the call. If block
+// is nil, the latest block is used.
 func SimulateValidation(
 	rpc *rpc.Client,
 	entryPoint common.Address,
 	op *userop.UserOperation,
 	signer *signer.EOA,
+	overrides utils.StateOverrides,
+	block *rpc.BlockNumberOrHash,
 ) (*reverts.ValidationResultRevert, error) {
+	if op.Version() == userop.V07 {
+		return simulateValidationV07(rpc, entryPoint, op, signer, overrides, block)
+	}
+	if len(overrides) > 0 || block != nil {
+		return simulateValidationEthCall(rpc, entryPoint, op, signer, overrides, block)
+	}
+
 	ep, err := entrypoint.NewEntrypoint(entryPoint, ethclient.NewClient(rpc))
 	if err != nil {
 		return nil, err
@@ -32,7 +46,85 @@ func SimulateValidation(
 	rawCaller := &entrypoint.EntrypointRaw{Contract: ep}
 	err = rawCaller.Call(&bind.CallOpts{
 		From: signer.Address,
-	}, &res, "simulateValidation", entrypoint.UserOperation(*op))
+	}, &res, "simulateValidation", entrypoint.ToUserOperation(op))
+	if err == nil {
+		return nil, stdError.New("unexpected result from simulateValidation")
+	}
+
+	sim, simErr := reverts.NewValidationResult(err)
+	if simErr != nil {
+		fo, foErr := reverts.NewFailedOp(err)
+		if foErr != nil {
+			return nil, fmt.Errorf("%s, %s", simErr, foErr)
+		}
+		return nil, errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, fo.Reason, fo)
+	}
+
+	return sim, nil
+}
+
+// simulateValidationV07 makes a static call to EntryPointSimulations.simulateValidation(userop) with the
+// simulation code overriding the EntryPoint. Unlike v0.6, a successful result is returned instead of
+// reverted.
+func simulateValidationV07(
+	rpc *rpc.Client,
+	entryPoint common.Address,
+	op *userop.UserOperation,
+	signer *signer.EOA,
+	extra utils.StateOverrides,
+	block *rpc.BlockNumberOrHash,
+) (*reverts.ValidationResultRevert, error) {
+	overrides, err := utils.SimulationsV07Overrides(entryPoint)
+	if err != nil {
+		return nil, err
+	}
+	overrides = extra.Merge(overrides)
+	data, err := methods.SimulateValidationV07Method.Inputs.Pack(op.ToPacked())
+	if err != nil {
+		return nil, err
+	}
+
+	var out hexutil.Bytes
+	req := utils.TraceCallReq{
+		From: signer.Address,
+		To:   entryPoint,
+		Data: append(methods.SimulateValidationV07Method.ID, data...),
+	}
+	err = rpc.CallContext(context.Background(), &out, "eth_call", &req, utils.BlockParam(block), overrides)
+	if err != nil {
+		fo, foErr := reverts.NewFailedOp(err)
+		if foErr != nil {
+			return nil, fmt.Errorf("%s, %s", err, foErr)
+		}
+		return nil, errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, fo.Reason, fo)
+	}
+
+	return reverts.NewValidationResultV07(out)
+}
+
+// simulateValidationEthCall makes an eth_call to Entrypoint.simulateValidation(userop) with state overrides at
+// the given block. This is equivalent to SimulateValidation for v0.6 since the bound contract caller does not
+// support overrides or block tags.
+func simulateValidationEthCall(
+	rpc *rpc.Client,
+	entryPoint common.Address,
+	op *userop.UserOperation,
+	signer *signer.EOA,
+	overrides utils.StateOverrides,
+	block *rpc.BlockNumberOrHash,
+) (*reverts.ValidationResultRevert, error) {
+	data, _, err := simulateValidationCallData(entryPoint, op)
+	if err != nil {
+		return nil, err
+	}
+
+	var out hexutil.Bytes
+	req := utils.TraceCallReq{
+		From: signer.Address,
+		To:   entryPoint,
+		Data: data,
+	}
+	err = rpc.CallContext(context.Background(), &out, "eth_call", &req, utils.BlockParam(block), overrides)
 	if err == nil {
 		return nil, stdError.New("unexpected result from simulateValidation")
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
 	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
@@ -109,10 +110,11 @@ func (v *storageSlotsValidator) Process() error {
 
 		if mustStakeSlot != "" && !v.EntityIsStaked {
 			return fmt.Errorf(
-				"unstaked %s accessed %s slot %s",
+				"unstaked %s accessed %s slot %s: %w",
 				v.EntityName,
 				addr2KnownEntity(v.Op, addr),
 				mustStakeSlot,
+				stake.ErrNotStaked,
 			)
 		}
 	}
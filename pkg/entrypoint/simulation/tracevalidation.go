@@ -1,64 +1,51 @@
 package simulation
 
 import (
-	"context"
-	"errors"
 	"fmt"
-	"math"
 	"math/big"
 
 	mapset "github.com/deckarep/golang-set/v2"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
-	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
-	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // TraceSimulateValidation makes a debug_traceCall to Entrypoint.simulateValidation(userop) and returns an
-// array of all the interacted contracts touched by entities during the trace.
+// array of all the interacted contracts touched by entities during the trace. The rules that are enforced
+// depend on the given TracingLevel. An entity is only treated as staked if it meets the given stake
+// Requirement.
 func TraceSimulateValidation(
 	rpc *rpc.Client,
 	entryPoint common.Address,
 	op *userop.UserOperation,
 	chainID *big.Int,
 	stakes EntityStakes,
+	stakeReq stake.Requirement,
+	level TracingLevel,
 ) ([]common.Address, error) {
-	// only sodium
-	return make([]common.Address, 0), nil
-	ep, err := entrypoint.NewEntrypoint(entryPoint, ethclient.NewClient(rpc))
-	if err != nil {
-		return nil, err
-	}
-	auth, err := bind.NewKeyedTransactorWithChainID(utils.DummyPk, chainID)
-	if err != nil {
-		return nil, err
+	if level == TracingOff {
+		return []common.Address{}, nil
 	}
-	auth.GasLimit = math.MaxUint64
-	auth.NoSend = true
-	tx, err := ep.SimulateValidation(auth, entrypoint.UserOperation(*op))
+
+	data, overrides, err := simulateValidationCallData(entryPoint, op)
 	if err != nil {
 		return nil, err
 	}
 
-	var res tracer.BundlerCollectorReturn
 	req := utils.TraceCallReq{
 		From: common.HexToAddress("0x"),
 		To:   entryPoint,
-		Data: tx.Data(),
-	}
-	opts := utils.TraceCallOpts{
-		Tracer: tracer.Loaded.BundlerCollectorTracer,
+		Data: data,
 	}
-	if err := rpc.CallContext(context.Background(), &res, "debug_traceCall", &req, "latest", &opts); err != nil {
+	res, err := utils.TraceCallCollector(rpc, &req, overrides, nil)
+	if err != nil {
 		return nil, err
 	}
 
-	knownEntity, err := newKnownEntity(op, &res, stakes)
+	knownEntity, err := newKnownEntity(op, res, stakes, stakeReq)
 	if err != nil {
 		return nil, err
 	}
@@ -89,6 +76,10 @@ func TraceSimulateValidation(
 		return nil, fmt.Errorf("paymaster uses banned opcode: %s", create2OpCode)
 	}
 
+	if level == TracingRelaxed {
+		return ic.ToSlice(), nil
+	}
+
 	slotsByEntity := newStorageSlotsByEntity(stakes, res.Keccak)
 	for title, entity := range knownEntity {
 		v := &storageSlotsValidator{
@@ -109,7 +100,8 @@ func TraceSimulateValidation(
 
 	callStack := newCallStack(res.Calls)
 	for _, call := range callStack {
-		if call.Method == methods.ValidatePaymasterUserOpSelector {
+		if call.Method == methods.ValidatePaymasterUserOpSelector ||
+			call.Method == methods.ValidatePaymasterUserOpV07Selector {
 			out, err := methods.DecodeValidatePaymasterUserOpOutput(call.Return)
 			if err != nil {
 				return nil, fmt.Errorf(
@@ -120,7 +112,7 @@ func TraceSimulateValidation(
 			}
 
 			if len(out.Context) != 0 && !knownEntity["paymaster"].IsStaked {
-				return nil, errors.New("unstaked paymaster must not return context")
+				return nil, fmt.Errorf("unstaked paymaster must not return context: %w", stake.ErrNotStaked)
 			}
 		}
 	}
@@ -0,0 +1,53 @@
+package simulation
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
+)
+
+// TracingLevel determines which ERC-7562 validation rules are enforced when tracing a UserOperation.
+type TracingLevel string
+
+const (
+	// TracingFull enforces all opcode, CREATE2, storage access, and paymaster context rules.
+	TracingFull TracingLevel = "full"
+
+	// TracingRelaxed only enforces banned opcode and CREATE2 rules. Storage access and paymaster context rules
+	// are skipped.
+	TracingRelaxed TracingLevel = "relaxed"
+
+	// TracingOff skips tracing altogether. No validation rules are enforced and no code hashes are collected.
+	TracingOff TracingLevel = "off"
+)
+
+// ParseTracingLevel returns the TracingLevel for a given string value.
+func ParseTracingLevel(level string) (TracingLevel, error) {
+	switch TracingLevel(level) {
+	case TracingFull, TracingRelaxed, TracingOff:
+		return TracingLevel(level), nil
+	default:
+		return "", fmt.Errorf("tracing level: unknown value %s", level)
+	}
+}
+
+// ProbeTracingLevel checks if the node supports debug_traceCall with the BundlerCollectorTracer, or the
+// built-in tracers in native mode, and returns the effective TracingLevel. If the node does not support
+// tracing, TracingOff is returned along with the error from the probe.
+func ProbeTracingLevel(rpc *rpc.Client, entryPoint common.Address, level TracingLevel) (TracingLevel, error) {
+	if level == TracingOff {
+		return TracingOff, nil
+	}
+
+	req := utils.TraceCallReq{
+		From: common.HexToAddress("0x"),
+		To:   entryPoint,
+	}
+	if _, err := utils.TraceCallCollector(rpc, &req, nil, nil); err != nil {
+		return TracingOff, fmt.Errorf("tracing probe: %s", err)
+	}
+
+	return level, nil
+}
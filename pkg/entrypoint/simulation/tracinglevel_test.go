@@ -0,0 +1,84 @@
+package simulation
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+// TestParseTracingLevel calls simulation.ParseTracingLevel with known and unknown values.
+func TestParseTracingLevel(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    TracingLevel
+		wantErr bool
+	}{
+		{"full", TracingFull, false},
+		{"relaxed", TracingRelaxed, false},
+		{"off", TracingOff, false},
+		{"", "", true},
+		{"FULL", "", true},
+		{"strict", "", true},
+	}
+	for _, c := range cases {
+		got, err := ParseTracingLevel(c.value)
+		if c.wantErr && err == nil {
+			t.Fatalf("got nil for %q, want err", c.value)
+		} else if !c.wantErr && err != nil {
+			t.Fatalf("got %v for %q, want nil", err, c.value)
+		}
+		if got != c.want {
+			t.Fatalf("got %q for %q, want %q", got, c.value, c.want)
+		}
+	}
+}
+
+// TestProbeTracingLevel calls simulation.ProbeTracingLevel against nodes with and without support for
+// debug_traceCall. Expects the configured level if tracing is supported and a fallback to TracingOff with an
+// error otherwise.
+func TestProbeTracingLevel(t *testing.T) {
+	cases := []struct {
+		name    string
+		mocks   testutils.MethodMocks
+		level   TracingLevel
+		want    TracingLevel
+		wantErr bool
+	}{
+		{
+			"supported full",
+			testutils.MethodMocks{"debug_traceCall": map[string]any{}},
+			TracingFull,
+			TracingFull,
+			false,
+		},
+		{
+			"supported relaxed",
+			testutils.MethodMocks{"debug_traceCall": map[string]any{}},
+			TracingRelaxed,
+			TracingRelaxed,
+			false,
+		},
+		{"unsupported full", testutils.MethodMocks{}, TracingFull, TracingOff, true},
+		{"unsupported relaxed", testutils.MethodMocks{}, TracingRelaxed, TracingOff, true},
+		{"off skips probe", testutils.MethodMocks{}, TracingOff, TracingOff, false},
+	}
+	for _, c := range cases {
+		srv := testutils.EthMock(c.mocks)
+		rpc, err := rpc.Dial(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ProbeTracingLevel(rpc, testutils.ValidAddress1, c.level)
+		srv.Close()
+		if c.wantErr && err == nil {
+			t.Fatalf("%s: got nil, want err", c.name)
+		} else if !c.wantErr && err != nil {
+			t.Fatalf("%s: got %v, want nil", c.name, err)
+		}
+		if got != c.want {
+			t.Fatalf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
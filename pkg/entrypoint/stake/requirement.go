@@ -0,0 +1,47 @@
+// Package stake provides the minimum stake requirements for an entity to be treated as staked by the bundler.
+package stake
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+// ErrNotStaked is wrapped by errors caused by an entity that is required to be staked but does not meet the
+// minimum stake or unstake delay.
+var ErrNotStaked = errors.New("stake or unstake delay too low")
+
+// Requirement is the minimum stake and unstake delay an entity must have on the EntryPoint to be treated as
+// staked. The zero value only requires a non-zero stake.
+type Requirement struct {
+	MinStake           *big.Int
+	MinUnstakeDelaySec uint64
+}
+
+// Meets returns true if the given stake and unstake delay satisfy the requirement.
+func (r Requirement) Meets(stake *big.Int, unstakeDelaySec uint64) bool {
+	if stake == nil || stake.Sign() <= 0 {
+		return false
+	}
+	if r.MinStake != nil && stake.Cmp(r.MinStake) < 0 {
+		return false
+	}
+	return unstakeDelaySec >= r.MinUnstakeDelaySec
+}
+
+// IsStaked returns true if the entity's EntryPoint deposit info satisfies the requirement. A nil deposit info
+// is treated as unstaked.
+func (r Requirement) IsStaked(dep *entrypoint.IStakeManagerDepositInfo) bool {
+	return dep != nil && dep.Staked && r.Meets(dep.Stake, uint64(dep.UnstakeDelaySec))
+}
+
+// String returns a description of the requirement for use in error messages.
+func (r Requirement) String() string {
+	min := r.MinStake
+	if min == nil {
+		min = big.NewInt(0)
+	}
+	return fmt.Sprintf("minimum stake of %s wei and unstake delay of %d seconds", min, r.MinUnstakeDelaySec)
+}
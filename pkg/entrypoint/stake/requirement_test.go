@@ -0,0 +1,44 @@
+package stake
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+)
+
+// TestIsStaked verifies that an entity is only treated as staked if it meets both the minimum stake and
+// unstake delay.
+func TestIsStaked(t *testing.T) {
+	req := Requirement{
+		MinStake:           testutils.OneETH,
+		MinUnstakeDelaySec: uint64(testutils.DefaultUnstakeDelaySec),
+	}
+
+	lowStake := *testutils.StakedDepositInfo
+	lowStake.Stake = big.NewInt(1)
+	lowDelay := *testutils.StakedDepositInfo
+	lowDelay.UnstakeDelaySec = 1
+
+	cases := []struct {
+		name string
+		dep  *entrypoint.IStakeManagerDepositInfo
+		want bool
+	}{
+		{"staked", testutils.StakedDepositInfo, true},
+		{"not staked", testutils.NonStakedDepositInfo, false},
+		{"nil", nil, false},
+		{"low stake", &lowStake, false},
+		{"low unstake delay", &lowDelay, false},
+	}
+	for _, c := range cases {
+		if got := req.IsStaked(c.dep); got != c.want {
+			t.Fatalf("%s: got %t, want %t", c.name, got, c.want)
+		}
+	}
+
+	if !(Requirement{}).IsStaked(&lowStake) {
+		t.Fatal("got false, want true for zero value requirement")
+	}
+}
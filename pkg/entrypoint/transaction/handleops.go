@@ -10,17 +10,26 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/methods"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
+// UserOpsPerAggregator is a group of UserOperations in a batch that share the same signature aggregator.
+type UserOpsPerAggregator struct {
+	UserOps    []*userop.UserOperation
+	Aggregator common.Address
+	Signature  []byte
+}
+
 // Opts contains all the fields required for submitting a transaction to call HandleOps on the EntryPoint
 // contract.
 type Opts struct {
@@ -34,7 +43,12 @@ type Opts struct {
 	Batch       []*userop.UserOperation
 	Beneficiary common.Address
 
-	// Options for the EOA transaction
+	// OpsPerAggregator is only required when calling handleAggregatedOps(). It should contain the same
+	// UserOperations as Batch, grouped by aggregator and in the same order.
+	OpsPerAggregator []UserOpsPerAggregator
+
+	// Options for the EOA transaction. If Nonce is nil, the latest nonce of the EOA is used.
+	Nonce       *big.Int
 	BaseFee     *big.Int
 	Tip         *big.Int
 	GasPrice    *big.Int
@@ -45,28 +59,102 @@ type Opts struct {
 func toAbiType(batch []*userop.UserOperation) []entrypoint.UserOperation {
 	ops := []entrypoint.UserOperation{}
 	for _, op := range batch {
-		ops = append(ops, entrypoint.UserOperation(*op))
+		ops = append(ops, entrypoint.ToUserOperation(op))
 	}
 
 	return ops
 }
 
-// EstimateHandleOpsGas returns a gas estimate required to call handleOps() with a given batch. A failed call
-// will return the cause of the revert.
-func EstimateHandleOpsGas(opts *Opts) (gas uint64, revert *reverts.FailedOpRevert, err error) {
-	ep, err := entrypoint.NewEntrypoint(opts.EntryPoint, opts.Eth)
+func toPackedAbiType(batch []*userop.UserOperation) []userop.PackedUserOperation {
+	ops := []userop.PackedUserOperation{}
+	for _, op := range batch {
+		ops = append(ops, op.ToPacked())
+	}
+
+	return ops
+}
+
+type abiOpsPerAggregator struct {
+	UserOps    []entrypoint.UserOperation
+	Aggregator common.Address
+	Signature  []byte
+}
+
+type packedAbiOpsPerAggregator struct {
+	UserOps    []userop.PackedUserOperation
+	Aggregator common.Address
+	Signature  []byte
+}
+
+// toHandleAggregatedOpsCallData returns the calldata for handleAggregatedOps() with the encoding that matches
+// the EntryPoint version.
+func toHandleAggregatedOpsCallData(opts *Opts) ([]byte, error) {
+	if entrypoint.GetVersion(opts.EntryPoint) == userop.V07 {
+		opa := []packedAbiOpsPerAggregator{}
+		for _, g := range opts.OpsPerAggregator {
+			opa = append(opa, packedAbiOpsPerAggregator{toPackedAbiType(g.UserOps), g.Aggregator, g.Signature})
+		}
+
+		args, err := methods.HandleAggregatedOpsV07Method.Inputs.Pack(opa, opts.Beneficiary)
+		if err != nil {
+			return nil, err
+		}
+		return append(methods.HandleAggregatedOpsV07Method.ID, args...), nil
+	}
+
+	opa := []abiOpsPerAggregator{}
+	for _, g := range opts.OpsPerAggregator {
+		opa = append(opa, abiOpsPerAggregator{toAbiType(g.UserOps), g.Aggregator, g.Signature})
+	}
+
+	args, err := methods.HandleAggregatedOpsMethod.Inputs.Pack(opa, opts.Beneficiary)
 	if err != nil {
-		return 0, nil, err
+		return nil, err
+	}
+	return append(methods.HandleAggregatedOpsMethod.ID, args...), nil
+}
+
+// toHandleOpsCallData returns the calldata for handleOps() with the encoding that matches the EntryPoint
+// version. If OpsPerAggregator is set, the calldata will be for handleAggregatedOps() instead.
+func toHandleOpsCallData(opts *Opts) ([]byte, error) {
+	if len(opts.OpsPerAggregator) > 0 {
+		return toHandleAggregatedOpsCallData(opts)
+	}
+
+	if entrypoint.GetVersion(opts.EntryPoint) == userop.V07 {
+		args, err := methods.HandleOpsV07Method.Inputs.Pack(toPackedAbiType(opts.Batch), opts.Beneficiary)
+		if err != nil {
+			return nil, err
+		}
+		return append(methods.HandleOpsV07Method.ID, args...), nil
 	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(opts.EOA.PrivateKey, opts.ChainID)
+	args, err := methods.HandleOpsMethod.Inputs.Pack(toAbiType(opts.Batch), opts.Beneficiary)
 	if err != nil {
-		return 0, nil, err
+		return nil, err
+	}
+	return append(methods.HandleOpsMethod.ID, args...), nil
+}
+
+// transactHandleOps creates a handleOps() transaction with the given auth options. It will only be sent if
+// auth.NoSend is false.
+func transactHandleOps(opts *Opts, auth *bind.TransactOpts) (*types.Transaction, error) {
+	data, err := toHandleOpsCallData(opts)
+	if err != nil {
+		return nil, err
 	}
+
+	ep := bind.NewBoundContract(opts.EntryPoint, abi.ABI{}, opts.Eth, opts.Eth, opts.Eth)
+	return ep.RawTransact(auth, data)
+}
+
+// EstimateHandleOpsGas returns a gas estimate required to call handleOps() with a given batch. A failed call
+// will return the cause of the revert.
+func EstimateHandleOpsGas(opts *Opts) (gas uint64, revert *reverts.FailedOpRevert, err error) {
+	auth := opts.EOA.NewCallTransactor()
 	auth.GasLimit = math.MaxUint64
-	auth.NoSend = true
 
-	tx, err := ep.HandleOps(auth, toAbiType(opts.Batch), opts.Beneficiary)
+	tx, err := transactHandleOps(opts, auth)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -101,22 +189,39 @@ func EstimateHandleOpsGas(opts *Opts) (gas uint64, revert *reverts.FailedOpRever
 
 // HandleOps submits a transaction to send a batch of UserOperations to the EntryPoint.
 func HandleOps(opts *Opts) (txn *types.Transaction, err error) {
-	ep, err := entrypoint.NewEntrypoint(opts.EntryPoint, opts.Eth)
-	if err != nil {
-		return nil, err
+	if len(opts.OpsPerAggregator) > 0 {
+		return nil, errors.New("transaction: use HandleAggregatedOps for batches with aggregators")
+	}
+
+	return sendHandleOps(opts)
+}
+
+// HandleAggregatedOps submits a transaction to send a batch of UserOperations grouped by signature aggregator
+// to the EntryPoint.
+func HandleAggregatedOps(opts *Opts) (txn *types.Transaction, err error) {
+	if len(opts.OpsPerAggregator) == 0 {
+		return nil, errors.New("transaction: OpsPerAggregator must be set for HandleAggregatedOps")
 	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(opts.EOA.PrivateKey, opts.ChainID)
+	return sendHandleOps(opts)
+}
+
+func sendHandleOps(opts *Opts) (txn *types.Transaction, err error) {
+	auth, err := opts.EOA.NewTransactor(opts.ChainID)
 	if err != nil {
 		return nil, err
 	}
 	auth.GasLimit = opts.GasLimit
 
-	nonce, err := opts.Eth.NonceAt(context.Background(), opts.EOA.Address, nil)
-	if err != nil {
-		return nil, err
+	if opts.Nonce != nil {
+		auth.Nonce = opts.Nonce
+	} else {
+		nonce, err := opts.Eth.NonceAt(context.Background(), opts.EOA.Address, nil)
+		if err != nil {
+			return nil, err
+		}
+		auth.Nonce = big.NewInt(int64(nonce))
 	}
-	auth.Nonce = big.NewInt(int64(nonce))
 
 	if opts.BaseFee != nil && opts.Tip != nil {
 		auth.GasTipCap = SuggestMeanGasTipCap(opts.Tip, opts.Batch)
@@ -127,7 +232,7 @@ func HandleOps(opts *Opts) (txn *types.Transaction, err error) {
 		return nil, errors.New("transaction: either the dynamic or legacy gas fees must be set")
 	}
 
-	txn, err = ep.HandleOps(auth, toAbiType(opts.Batch), opts.Beneficiary)
+	txn, err = transactHandleOps(opts, auth)
 	if err != nil {
 		return nil, err
 	} else if opts.WaitTimeout == 0 {
@@ -151,12 +256,7 @@ func HandleOps(opts *Opts) (txn *types.Transaction, err error) {
 // CreateRawHandleOps returns a raw transaction string that calls handleOps() on the EntryPoint with a given
 // batch, gas limit, and tip.
 func CreateRawHandleOps(opts *Opts) (string, error) {
-	ep, err := entrypoint.NewEntrypoint(opts.EntryPoint, opts.Eth)
-	if err != nil {
-		return "", err
-	}
-
-	auth, err := bind.NewKeyedTransactorWithChainID(opts.EOA.PrivateKey, opts.ChainID)
+	auth, err := opts.EOA.NewTransactor(opts.ChainID)
 	if err != nil {
 		return "", err
 	}
@@ -172,7 +272,7 @@ func CreateRawHandleOps(opts *Opts) (string, error) {
 		auth.GasFeeCap = big.NewInt(0).Add(opts.BaseFee, tip)
 	}
 
-	tx, err := ep.HandleOps(auth, toAbiType(opts.Batch), opts.Beneficiary)
+	tx, err := transactHandleOps(opts, auth)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,131 @@
+package transaction
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// MinReplacementBump is the min percentage increase in fees required by most nodes to accept a transaction
+// that replaces another with the same nonce.
+const MinReplacementBump = 10
+
+// Fees holds the gas price fields of a transaction. Either the dynamic fee fields or the legacy GasPrice will
+// be set.
+type Fees struct {
+	GasTipCap *big.Int `json:"gasTipCap,omitempty"`
+	GasFeeCap *big.Int `json:"gasFeeCap,omitempty"`
+	GasPrice  *big.Int `json:"gasPrice,omitempty"`
+}
+
+// GetFees returns the Fees of a transaction.
+func GetFees(tx *types.Transaction) Fees {
+	if tx.Type() == types.LegacyTxType {
+		return Fees{GasPrice: tx.GasPrice()}
+	}
+	return Fees{GasTipCap: tx.GasTipCap(), GasFeeCap: tx.GasFeeCap()}
+}
+
+// bump returns the old value increased by MinReplacementBump, or the current value if it is higher.
+func bump(old *big.Int, curr *big.Int) *big.Int {
+	b := big.NewInt(0).Mul(old, big.NewInt(100+MinReplacementBump))
+	b = b.Div(b, big.NewInt(100))
+	b = b.Add(b, common.Big1)
+	if curr != nil && curr.Cmp(b) > 0 {
+		return curr
+	}
+	return b
+}
+
+// BumpFees returns the Fees for a replacement transaction given the Fees of the original and the current
+// network values. Each field is increased by at least MinReplacementBump.
+func BumpFees(old Fees, baseFee *big.Int, tip *big.Int, gasPrice *big.Int) Fees {
+	if old.GasPrice != nil {
+		return Fees{GasPrice: bump(old.GasPrice, gasPrice)}
+	}
+
+	var feeCap *big.Int
+	if baseFee != nil && tip != nil {
+		feeCap = big.NewInt(0).Add(big.NewInt(0).Mul(baseFee, common.Big2), tip)
+	}
+	nt := bump(old.GasTipCap, tip)
+	nf := bump(old.GasFeeCap, feeCap)
+	if nf.Cmp(nt) < 0 {
+		nf = nt
+	}
+	return Fees{GasTipCap: nt, GasFeeCap: nf}
+}
+
+// SendWithNonce signs and sends a transaction from the EOA with an explicit nonce and Fees. This can be used
+// to replace a pending transaction.
+func SendWithNonce(
+	eoa *signer.EOA,
+	eth *ethclient.Client,
+	chainID *big.Int,
+	nonce uint64,
+	to common.Address,
+	data []byte,
+	gasLimit uint64,
+	fees Fees,
+) (*types.Transaction, error) {
+	return send(eoa, eth, chainID, nonce, to, big.NewInt(0), data, gasLimit, fees)
+}
+
+func send(
+	eoa *signer.EOA,
+	eth *ethclient.Client,
+	chainID *big.Int,
+	nonce uint64,
+	to common.Address,
+	value *big.Int,
+	data []byte,
+	gasLimit uint64,
+	fees Fees,
+) (*types.Transaction, error) {
+	var inner types.TxData
+	if fees.GasPrice != nil {
+		inner = &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: fees.GasPrice,
+			Gas:      gasLimit,
+			To:       &to,
+			Value:    value,
+			Data:     data,
+		}
+	} else {
+		inner = &types.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     nonce,
+			GasTipCap: fees.GasTipCap,
+			GasFeeCap: fees.GasFeeCap,
+			Gas:       gasLimit,
+			To:        &to,
+			Value:     value,
+			Data:      data,
+		}
+	}
+
+	tx, err := eoa.SignTx(types.NewTx(inner), chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := eth.SendTransaction(context.Background(), tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Cancel replaces a pending transaction with a zero value transfer from the EOA to itself.
+func Cancel(
+	eoa *signer.EOA,
+	eth *ethclient.Client,
+	chainID *big.Int,
+	nonce uint64,
+	fees Fees,
+) (*types.Transaction, error) {
+	return SendWithNonce(eoa, eth, chainID, nonce, eoa.Address, nil, TransferGasLimit, fees)
+}
@@ -0,0 +1,47 @@
+package transaction
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestBumpFeesWithLowerNetworkFees verifies that dynamic fees are increased by the min replacement bump when
+// the current network fees are lower than the original.
+func TestBumpFeesWithLowerNetworkFees(t *testing.T) {
+	old := Fees{GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(1000)}
+	fees := BumpFees(old, big.NewInt(100), big.NewInt(50), nil)
+
+	if want := big.NewInt(111); fees.GasTipCap.Cmp(want) != 0 {
+		t.Fatalf("got tip %d, want %d", fees.GasTipCap.Int64(), want.Int64())
+	}
+	if want := big.NewInt(1101); fees.GasFeeCap.Cmp(want) != 0 {
+		t.Fatalf("got fee cap %d, want %d", fees.GasFeeCap.Int64(), want.Int64())
+	}
+}
+
+// TestBumpFeesWithHigherNetworkFees verifies that dynamic fees follow the current network fees when they are
+// higher than the min replacement bump.
+func TestBumpFeesWithHigherNetworkFees(t *testing.T) {
+	old := Fees{GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(1000)}
+	fees := BumpFees(old, big.NewInt(1000), big.NewInt(500), nil)
+
+	if want := big.NewInt(500); fees.GasTipCap.Cmp(want) != 0 {
+		t.Fatalf("got tip %d, want %d", fees.GasTipCap.Int64(), want.Int64())
+	}
+	if want := big.NewInt(2500); fees.GasFeeCap.Cmp(want) != 0 {
+		t.Fatalf("got fee cap %d, want %d", fees.GasFeeCap.Int64(), want.Int64())
+	}
+}
+
+// TestBumpFeesLegacy verifies that only the gas price is bumped for legacy transactions.
+func TestBumpFeesLegacy(t *testing.T) {
+	old := Fees{GasPrice: big.NewInt(100)}
+	fees := BumpFees(old, nil, nil, big.NewInt(10))
+
+	if want := big.NewInt(111); fees.GasPrice.Cmp(want) != 0 {
+		t.Fatalf("got %d, want %d", fees.GasPrice.Int64(), want.Int64())
+	}
+	if fees.GasTipCap != nil || fees.GasFeeCap != nil {
+		t.Fatalf("got dynamic fees %+v, want nil", fees)
+	}
+}
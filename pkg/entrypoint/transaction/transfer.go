@@ -0,0 +1,68 @@
+package transaction
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// TransferGasLimit is the gas required for a native transfer to an EOA.
+const TransferGasLimit = 21000
+
+// SuggestFees returns the Fees for a new transaction based on the current network values. Dynamic fees are
+// used if the latest block has a basefee, otherwise the legacy gas price is used.
+func SuggestFees(eth *ethclient.Client) (Fees, error) {
+	head, err := eth.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return Fees{}, err
+	}
+
+	if head.BaseFee == nil {
+		gp, err := eth.SuggestGasPrice(context.Background())
+		if err != nil {
+			return Fees{}, err
+		}
+		return Fees{GasPrice: gp}, nil
+	}
+
+	tip, err := eth.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return Fees{}, err
+	}
+	return Fees{
+		GasTipCap: tip,
+		GasFeeCap: big.NewInt(0).Add(big.NewInt(0).Mul(head.BaseFee, common.Big2), tip),
+	}, nil
+}
+
+// MaxCost returns the max amount of native tokens that can be spent on gas for a transaction with the given
+// gas limit and Fees.
+func (f Fees) MaxCost(gasLimit uint64) *big.Int {
+	price := f.GasFeeCap
+	if f.GasPrice != nil {
+		price = f.GasPrice
+	}
+	return big.NewInt(0).Mul(price, big.NewInt(0).SetUint64(gasLimit))
+}
+
+// Transfer sends an amount of native tokens from the EOA to an address using the next pending nonce and the
+// given Fees.
+func Transfer(
+	eoa *signer.EOA,
+	eth *ethclient.Client,
+	chainID *big.Int,
+	to common.Address,
+	amount *big.Int,
+	fees Fees,
+) (*types.Transaction, error) {
+	nonce, err := eth.PendingNonceAt(context.Background(), eoa.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return send(eoa, eth, chainID, nonce, to, amount, nil, TransferGasLimit, fees)
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethRpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockParam returns the value to use for the block param of an eth_call or debug_traceCall. If block is nil,
+// the latest block is used. A block hash is encoded as an EIP-1898 object.
+func BlockParam(block *ethRpc.BlockNumberOrHash) any {
+	if block == nil {
+		return ethRpc.LatestBlockNumber
+	}
+	if num, ok := block.Number(); ok {
+		return num
+	}
+	return block
+}
+
+// PinBlock resolves a block tag to the number of the block it currently refers to. This allows multiple calls
+// to be made against the same state even if a new block is mined in between. The pending tag cannot be
+// resolved to a stable block and is returned as is, along with block numbers and hashes.
+func PinBlock(rpc *ethRpc.Client, block *ethRpc.BlockNumberOrHash) (*ethRpc.BlockNumberOrHash, error) {
+	if block != nil {
+		num, ok := block.Number()
+		if !ok || num >= 0 || num == ethRpc.PendingBlockNumber {
+			return block, nil
+		}
+	}
+
+	var head struct {
+		Number hexutil.Uint64 `json:"number"`
+	}
+	err := rpc.CallContext(context.Background(), &head, "eth_getBlockByNumber", BlockParam(block), false)
+	if err != nil {
+		return nil, err
+	}
+	pinned := ethRpc.BlockNumberOrHashWithNumber(ethRpc.BlockNumber(head.Number))
+	return &pinned, nil
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethRpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+)
+
+// TestBlockParam verifies that block tags and numbers are encoded as strings, hashes as an EIP-1898 object,
+// and that nil defaults to latest.
+func TestBlockParam(t *testing.T) {
+	pending := ethRpc.BlockNumberOrHashWithNumber(ethRpc.PendingBlockNumber)
+	num := ethRpc.BlockNumberOrHashWithNumber(ethRpc.BlockNumber(16))
+	hash := ethRpc.BlockNumberOrHashWithHash(common.HexToHash("0x01"), false)
+
+	cases := []struct {
+		block *ethRpc.BlockNumberOrHash
+		want  string
+	}{
+		{nil, `"latest"`},
+		{&pending, `"pending"`},
+		{&num, `"0x10"`},
+		{&hash, `{"blockHash":"0x0000000000000000000000000000000000000000000000000000000000000001"}`},
+	}
+	for _, c := range cases {
+		got, err := json.Marshal(BlockParam(c.block))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		} else if string(got) != c.want {
+			t.Fatalf("got %s, want %s", got, c.want)
+		}
+	}
+}
+
+// TestPinBlock verifies that the latest tag is resolved to the current block number and that pending is
+// returned as is.
+func TestPinBlock(t *testing.T) {
+	srv := testutils.EthMock(testutils.MethodMocks{
+		"eth_getBlockByNumber": map[string]any{"number": "0x10"},
+	})
+	defer srv.Close()
+
+	rpc, err := ethRpc.Dial(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pinned, err := PinBlock(rpc, nil)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if num, ok := pinned.Number(); !ok || num != 16 {
+		t.Fatalf("got %s, want 0x10", pinned.String())
+	}
+
+	pending := ethRpc.BlockNumberOrHashWithNumber(ethRpc.PendingBlockNumber)
+	pinned, err = PinBlock(rpc, &pending)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if pinned != &pending {
+		t.Fatalf("got %s, want pending", pinned.String())
+	}
+}
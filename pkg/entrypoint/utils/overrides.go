@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// OverrideAccount is the state of an account that is temporarily replaced during an eth_call or
+// debug_traceCall.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+	Code      *hexutil.Bytes              `json:"code,omitempty"`
+	Balance   *hexutil.Big                `json:"balance,omitempty"`
+	State     map[common.Hash]common.Hash `json:"state,omitempty"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+}
+
+// StateOverrides maps account addresses to their overridden state.
+type StateOverrides map[common.Address]OverrideAccount
+
+// Merge returns a copy of s with the overrides from other applied on top. If both override the same account,
+// fields set in other take precedence while all other fields in s are kept.
+func (s StateOverrides) Merge(other StateOverrides) StateOverrides {
+	if len(s) == 0 && len(other) == 0 {
+		return nil
+	}
+
+	out := StateOverrides{}
+	for addr, acc := range s {
+		out[addr] = acc
+	}
+	for addr, acc := range other {
+		curr, ok := out[addr]
+		if !ok {
+			out[addr] = acc
+			continue
+		}
+
+		if acc.Nonce != nil {
+			curr.Nonce = acc.Nonce
+		}
+		if acc.Code != nil {
+			curr.Code = acc.Code
+		}
+		if acc.Balance != nil {
+			curr.Balance = acc.Balance
+		}
+		if acc.State != nil {
+			curr.State = acc.State
+			curr.StateDiff = nil
+		}
+		if acc.StateDiff != nil {
+			curr.StateDiff = acc.StateDiff
+			curr.State = nil
+		}
+		out[addr] = curr
+	}
+	return out
+}
+
+var (
+	simulationsV07Mu   sync.RWMutex
+	simulationsV07Code hexutil.Bytes
+)
+
+// SetSimulationsV07Code sets the bytecode of the EntryPointSimulations contract for v0.7. EntryPoint v0.7
+// does not include simulation methods and instead relies on this code being swapped in at the EntryPoint
+// address with a state override.
+func SetSimulationsV07Code(code []byte) {
+	simulationsV07Mu.Lock()
+	defer simulationsV07Mu.Unlock()
+
+	simulationsV07Code = code
+}
+
+// SimulationsV07Overrides returns StateOverrides that replace the code at the given EntryPoint with the v0.7
+// EntryPointSimulations contract.
+func SimulationsV07Overrides(entryPoint common.Address) (StateOverrides, error) {
+	simulationsV07Mu.RLock()
+	defer simulationsV07Mu.RUnlock()
+
+	if len(simulationsV07Code) == 0 {
+		return nil, errors.New("simulation: EntryPointSimulations code for v0.7 is not set")
+	}
+	code := simulationsV07Code
+	return StateOverrides{entryPoint: {Code: &code}}, nil
+}
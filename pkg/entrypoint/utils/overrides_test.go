@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// TestStateOverridesUnmarshal verifies that geth style state overrides can be decoded from a JSON-RPC param.
+func TestStateOverridesUnmarshal(t *testing.T) {
+	raw := `{
+		"0x0000000000000000000000000000000000000001": {"balance": "0x10", "code": "0x6000"},
+		"0x0000000000000000000000000000000000000002": {
+			"stateDiff": {
+				"0x0000000000000000000000000000000000000000000000000000000000000001":
+				"0x0000000000000000000000000000000000000000000000000000000000000002"
+			}
+		}
+	}`
+
+	var o StateOverrides
+	if err := json.Unmarshal([]byte(raw), &o); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	acc := o[common.HexToAddress("0x01")]
+	if acc.Balance == nil || acc.Balance.ToInt().Cmp(big.NewInt(16)) != 0 {
+		t.Fatalf("got balance %v, want 0x10", acc.Balance)
+	}
+	if acc.Code == nil || acc.Code.String() != "0x6000" {
+		t.Fatalf("got code %v, want 0x6000", acc.Code)
+	}
+	diff := o[common.HexToAddress("0x02")].StateDiff
+	if diff[common.HexToHash("0x01")] != common.HexToHash("0x02") {
+		t.Fatalf("got stateDiff %v, want slot 0x01 set to 0x02", diff)
+	}
+}
+
+// TestStateOverridesMerge verifies that fields from the second set of overrides take precedence while other
+// fields and accounts are kept.
+func TestStateOverridesMerge(t *testing.T) {
+	ep := common.HexToAddress("0x01")
+	sender := common.HexToAddress("0x02")
+	bal := hexutil.Big(*big.NewInt(1))
+	userCode := hexutil.Bytes{0x01}
+	simCode := hexutil.Bytes{0x02}
+
+	user := StateOverrides{
+		ep:     {Code: &userCode, Balance: &bal},
+		sender: {Balance: &bal},
+	}
+	sim := StateOverrides{ep: {Code: &simCode}}
+
+	out := user.Merge(sim)
+	if len(out) != 2 {
+		t.Fatalf("got %d accounts, want 2", len(out))
+	}
+	if out[ep].Code.String() != simCode.String() {
+		t.Fatalf("got code %s, want %s", out[ep].Code, simCode)
+	}
+	if out[ep].Balance == nil {
+		t.Fatal("got nil balance, want 1")
+	}
+	if user[ep].Code.String() != userCode.String() {
+		t.Fatal("incorrect merge: modified original overrides")
+	}
+
+	if o := StateOverrides(nil).Merge(nil); o != nil {
+		t.Fatalf("got %v, want nil", o)
+	}
+}
@@ -1,9 +1,14 @@
 package utils
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/tracer"
+	"golang.org/x/sync/errgroup"
 )
 
 type TraceCallReq struct {
@@ -13,10 +18,104 @@ type TraceCallReq struct {
 }
 
 type TraceCallOpts struct {
-	Tracer string `json:"tracer"`
+	Tracer         string         `json:"tracer,omitempty"`
+	TracerConfig   any            `json:"tracerConfig,omitempty"`
+	EnableMemory   bool           `json:"enableMemory,omitempty"`
+	StateOverrides StateOverrides `json:"stateOverrides,omitempty"`
 }
 
 var (
 	// A dummy private key used to build *bind.TransactOpts for simulation.
 	DummyPk, _ = crypto.GenerateKey()
 )
+
+// traceCallNative makes a debug_traceCall with each of the built-in tracers required to reconstruct the
+// output of a custom JS tracer. The block is pinned first so that every trace is made against the same state.
+func traceCallNative(
+	rpc *rpc.Client,
+	req *TraceCallReq,
+	overrides StateOverrides,
+	block *rpc.BlockNumberOrHash,
+) (*tracer.NativeTraces, error) {
+	pinned, err := PinBlock(rpc, block)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &tracer.NativeTraces{}
+	blk := BlockParam(pinned)
+	g := new(errgroup.Group)
+	g.Go(func() error {
+		opts := TraceCallOpts{EnableMemory: true, StateOverrides: overrides}
+		return rpc.CallContext(context.Background(), &out.StructLogs, "debug_traceCall", req, blk, &opts)
+	})
+	g.Go(func() error {
+		opts := TraceCallOpts{Tracer: "callTracer", StateOverrides: overrides}
+		return rpc.CallContext(context.Background(), &out.Calls, "debug_traceCall", req, blk, &opts)
+	})
+	g.Go(func() error {
+		opts := TraceCallOpts{Tracer: "prestateTracer", StateOverrides: overrides}
+		return rpc.CallContext(context.Background(), &out.Prestate, "debug_traceCall", req, blk, &opts)
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// TraceCallCollector makes a debug_traceCall at the given block and returns the output of the
+// BundlerCollectorTracer based on the current tracer.Mode. If block is nil, the latest block is used.
+func TraceCallCollector(
+	rpc *rpc.Client,
+	req *TraceCallReq,
+	overrides StateOverrides,
+	block *rpc.BlockNumberOrHash,
+) (*tracer.BundlerCollectorReturn, error) {
+	if tracer.GetMode() == tracer.NativeMode {
+		traces, err := traceCallNative(rpc, req, overrides, block)
+		if err != nil {
+			return nil, err
+		}
+		return tracer.NewBundlerCollectorReturn(traces)
+	}
+
+	var res tracer.BundlerCollectorReturn
+	opts := TraceCallOpts{
+		Tracer:         tracer.Loaded.BundlerCollectorTracer,
+		StateOverrides: overrides,
+	}
+	err := rpc.CallContext(context.Background(), &res, "debug_traceCall", req, BlockParam(block), &opts)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// TraceCallExecution makes a debug_traceCall at the given block and returns the output of the
+// BundlerExecutionTracer based on the current tracer.Mode. If block is nil, the latest block is used.
+func TraceCallExecution(
+	rpc *rpc.Client,
+	req *TraceCallReq,
+	overrides StateOverrides,
+	block *rpc.BlockNumberOrHash,
+) (*tracer.BundlerExecutionReturn, error) {
+	if tracer.GetMode() == tracer.NativeMode {
+		traces, err := traceCallNative(rpc, req, overrides, block)
+		if err != nil {
+			return nil, err
+		}
+		return tracer.NewBundlerExecutionReturn(traces)
+	}
+
+	var res tracer.BundlerExecutionReturn
+	opts := TraceCallOpts{
+		Tracer:         tracer.Loaded.BundlerExecutionTracer,
+		StateOverrides: overrides,
+	}
+	err := rpc.CallContext(context.Background(), &res, "debug_traceCall", req, BlockParam(block), &opts)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	ethRpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// TestTraceCallNativePinsBlock calls traceCallNative with the latest block and verifies that every
+// debug_traceCall is made at the same block number.
+func TestTraceCallNativePinsBlock(t *testing.T) {
+	var mu sync.Mutex
+	blocks := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "eth_getBlockByNumber":
+			result = map[string]any{"number": "0x10"}
+		case "debug_traceCall":
+			mu.Lock()
+			blocks = append(blocks, string(req.Params[1]))
+			mu.Unlock()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	defer srv.Close()
+
+	rpc, err := ethRpc.Dial(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := traceCallNative(rpc, &TraceCallReq{}, nil, nil); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(blocks) != 3 {
+		t.Fatalf("got %d traces, want 3", len(blocks))
+	}
+	for _, b := range blocks {
+		if b != `"0x10"` {
+			t.Fatalf("got block %s, want \"0x10\"", b)
+		}
+	}
+}
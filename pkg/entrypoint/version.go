@@ -0,0 +1,64 @@
+package entrypoint
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	// AddressV06 is the canonical address of EntryPoint v0.6.
+	AddressV06 = common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+
+	// AddressV07 is the canonical address of EntryPoint v0.7.
+	AddressV07 = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+
+	versionsMu sync.RWMutex
+	versions   = map[common.Address]userop.Version{
+		AddressV06: userop.V06,
+		AddressV07: userop.V07,
+	}
+)
+
+// SetVersion registers the version of an EntryPoint deployed at a non-canonical address (e.g. on a devnet or
+// fork). This should be called at startup before any UserOperation is handled.
+func SetVersion(entryPoint common.Address, v userop.Version) {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	versions[entryPoint] = v
+}
+
+// LookupVersion returns the version of an EntryPoint at a canonical or registered address. It returns false
+// if the EntryPoint is not recognised.
+func LookupVersion(entryPoint common.Address) (userop.Version, bool) {
+	versionsMu.RLock()
+	defer versionsMu.RUnlock()
+	v, ok := versions[entryPoint]
+	return v, ok
+}
+
+// GetVersion returns the UserOperation format expected by the EntryPoint at the given address. Supported
+// EntryPoints are checked with LookupVersion at startup so that an unrecognised address is rejected rather
+// than defaulting to v0.6 here.
+func GetVersion(entryPoint common.Address) userop.Version {
+	v, _ := LookupVersion(entryPoint)
+	return v
+}
+
+// ToUserOperation converts a v0.6 UserOperation into the type expected by the generated bindings.
+func ToUserOperation(op *userop.UserOperation) UserOperation {
+	return UserOperation{
+		Sender:               op.Sender,
+		Nonce:                op.Nonce,
+		InitCode:             op.InitCode,
+		CallData:             op.CallData,
+		CallGasLimit:         op.CallGasLimit,
+		VerificationGasLimit: op.VerificationGasLimit,
+		PreVerificationGas:   op.PreVerificationGas,
+		MaxFeePerGas:         op.MaxFeePerGas,
+		MaxPriorityFeePerGas: op.MaxPriorityFeePerGas,
+		PaymasterAndData:     op.PaymasterAndData,
+		Signature:            op.Signature,
+	}
+}
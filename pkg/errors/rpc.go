@@ -9,6 +9,7 @@ var (
 	INVALID_PAYMASTER_STAKE       = -32505
 	INVALID_AGGREGATOR            = -32506
 	INVALID_SIGNATURE             = -32507
+	MEMPOOL_FULL                  = -32509
 	INVALID_FIELDS                = -32602
 
 	EXECUTION_REVERTED = -32521
@@ -0,0 +1,202 @@
+// Package events implements a publisher for lifecycle events of UserOperations in the bundler. This allows
+// clients to subscribe to updates instead of polling for receipts.
+package events
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Kind is the type of lifecycle event for a UserOperation.
+type Kind string
+
+const (
+	// NewUserOps is emitted when a UserOperation is accepted into the mempool.
+	NewUserOps Kind = "newUserOperations"
+
+	// IncludedUserOps is emitted when a UserOperation is removed from the mempool after being included
+	// on-chain. The receipt is attached if it can be found.
+	IncludedUserOps Kind = "includedUserOperations"
+
+	// DroppedUserOps is emitted when a UserOperation is removed from the mempool without being included
+	// on-chain. The reason it was dropped is attached.
+	DroppedUserOps Kind = "droppedUserOperations"
+)
+
+// DefaultBufferSize is the default number of events that can be queued for a subscriber before further
+// events are discarded.
+var DefaultBufferSize = 256
+
+// Event is a lifecycle update for a single UserOperation.
+type Event struct {
+	Kind       Kind                         `json:"kind"`
+	UserOpHash common.Hash                  `json:"userOpHash"`
+	EntryPoint common.Address               `json:"entryPoint"`
+	UserOp     *userop.UserOperation        `json:"userOperation"`
+	Receipt    *filter.UserOperationReceipt `json:"receipt,omitempty"`
+	Reason     string                       `json:"reason,omitempty"`
+}
+
+// GetUserOpReceiptFunc is a general interface for fetching a UserOperationReceipt given a userOpHash and
+// EntryPoint address.
+type GetUserOpReceiptFunc = func(hash string, ep common.Address) (*filter.UserOperationReceipt, error)
+
+func getUserOpReceiptNoop() GetUserOpReceiptFunc {
+	return func(hash string, ep common.Address) (*filter.UserOperationReceipt, error) {
+		return nil, nil
+	}
+}
+
+type subscription struct {
+	kind Kind
+	ch   chan *Event
+}
+
+// Feed publishes UserOperation events to all subscribers of the same kind. Publishing never blocks. If a
+// subscriber is not keeping up, events are discarded for that subscriber once its buffer is full.
+type Feed struct {
+	mu               sync.Mutex
+	subs             map[*subscription]bool
+	getUserOpReceipt GetUserOpReceiptFunc
+}
+
+// New initializes a new event Feed with no subscribers.
+func New() *Feed {
+	return &Feed{
+		subs:             make(map[*subscription]bool),
+		getUserOpReceipt: getUserOpReceiptNoop(),
+	}
+}
+
+// SetGetUserOpReceiptFunc defines a general function for fetching the receipt that is attached to an
+// IncludedUserOps event. By default, no receipt is attached.
+func (f *Feed) SetGetUserOpReceiptFunc(fn GetUserOpReceiptFunc) {
+	f.getUserOpReceipt = fn
+}
+
+func (f *Feed) hasSubscribers(kind Kind) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for s := range f.subs {
+		if s.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish sends the event to every subscriber of the same kind.
+func (f *Feed) Publish(ev *Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for s := range f.subs {
+		if s.kind != ev.Kind {
+			continue
+		}
+		select {
+		case s.ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribe calls notify for every event of the given kind until the returned unsubscribe function is called.
+// Events are delivered in order from a dedicated goroutine.
+func (f *Feed) Subscribe(kind string, notify func(result any)) (func(), error) {
+	k := Kind(kind)
+	switch k {
+	case NewUserOps, IncludedUserOps, DroppedUserOps:
+	default:
+		return nil, fmt.Errorf("events: unsupported subscription %s", kind)
+	}
+
+	s := &subscription{kind: k, ch: make(chan *Event, DefaultBufferSize)}
+	f.mu.Lock()
+	f.subs[s] = true
+	f.mu.Unlock()
+
+	go func() {
+		for ev := range s.ch {
+			notify(ev)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+
+			delete(f.subs, s)
+			close(s.ch)
+		})
+	}, nil
+}
+
+// PublishUserOpAdded sends a NewUserOps event for a UserOperation that has been added to the mempool.
+func (f *Feed) PublishUserOpAdded(ep common.Address, hash common.Hash, op *userop.UserOperation) {
+	f.Publish(&Event{Kind: NewUserOps, UserOpHash: hash, EntryPoint: ep, UserOp: op})
+}
+
+// PublishUserOpDropped sends a DroppedUserOps event for a UserOperation that has been removed from the
+// mempool when adding another, such as a replacement with the same nonce or an eviction at capacity.
+func (f *Feed) PublishUserOpDropped(
+	ep common.Address,
+	hash common.Hash,
+	op *userop.UserOperation,
+	reason string,
+) {
+	f.Publish(&Event{Kind: DroppedUserOps, UserOpHash: hash, EntryPoint: ep, UserOp: op, Reason: reason})
+}
+
+// PublishRemovedOps returns a BatchHandler that is used by the Bundler to publish events for every
+// UserOperation that will be removed from the mempool at the end of the run. Ops that were marked for removal
+// with a reason are published as DroppedUserOps. All other ops are published as IncludedUserOps with their
+// receipt fetched in the background. This should be the last module executed by the Bundler.
+func (f *Feed) PublishRemovedOps() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		included := append([]*userop.UserOperation{}, ctx.Batch...)
+		for _, op := range ctx.PendingRemoval {
+			if reason, ok := ctx.GetRemovalReason(op); ok {
+				f.Publish(&Event{
+					Kind:       DroppedUserOps,
+					UserOpHash: op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID),
+					EntryPoint: ctx.EntryPoint,
+					UserOp:     op,
+					Reason:     reason,
+				})
+			} else {
+				included = append(included, op)
+			}
+		}
+
+		if len(included) == 0 || !f.hasSubscribers(IncludedUserOps) {
+			return nil
+		}
+		events := []*Event{}
+		for _, op := range included {
+			events = append(events, &Event{
+				Kind:       IncludedUserOps,
+				UserOpHash: op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID),
+				EntryPoint: ctx.EntryPoint,
+				UserOp:     op,
+			})
+		}
+		go func() {
+			for _, ev := range events {
+				if receipt, err := f.getUserOpReceipt(ev.UserOpHash.String(), ev.EntryPoint); err == nil {
+					ev.Receipt = receipt
+				}
+				f.Publish(ev)
+			}
+		}()
+		return nil
+	}
+}
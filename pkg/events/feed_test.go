@@ -0,0 +1,133 @@
+package events
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/filter"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func subscribe(t *testing.T, f *Feed, kind Kind) (chan *Event, func()) {
+	ch := make(chan *Event, 10)
+	unsubscribe, err := f.Subscribe(string(kind), func(result any) {
+		ch <- result.(*Event)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ch, unsubscribe
+}
+
+func receive(t *testing.T, ch chan *Event) *Event {
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("got no event, want event")
+		return nil
+	}
+}
+
+// TestFeedSubscribeUnsupportedKind verifies that an unknown subscription kind returns an error.
+func TestFeedSubscribeUnsupportedKind(t *testing.T) {
+	if _, err := New().Subscribe("unknown", func(result any) {}); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestFeedPublishUserOpAdded verifies that only subscribers of NewUserOps receive the event and that no events
+// are received after unsubscribing.
+func TestFeedPublishUserOpAdded(t *testing.T) {
+	f := New()
+	added, unsubscribe := subscribe(t, f, NewUserOps)
+	dropped, _ := subscribe(t, f, DroppedUserOps)
+
+	op := testutils.MockValidInitUserOp()
+	hash := op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	f.PublishUserOpAdded(testutils.ValidAddress1, hash, op)
+	if ev := receive(t, added); ev.UserOpHash != hash {
+		t.Fatalf("got %s, want %s", ev.UserOpHash, hash)
+	}
+	select {
+	case ev := <-dropped:
+		t.Fatalf("got %v, want no event", ev)
+	default:
+	}
+
+	unsubscribe()
+	unsubscribe()
+	f.PublishUserOpAdded(testutils.ValidAddress1, hash, op)
+	select {
+	case ev := <-added:
+		t.Fatalf("got %v, want no event", ev)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+// TestFeedPublishRemovedOps verifies that ops marked for removal with a reason are published as dropped and
+// all other ops are published as included with a receipt.
+func TestFeedPublishRemovedOps(t *testing.T) {
+	f := New()
+	f.SetGetUserOpReceiptFunc(func(hash string, ep common.Address) (*filter.UserOperationReceipt, error) {
+		return &filter.UserOperationReceipt{UserOpHash: common.HexToHash(hash), Success: true}, nil
+	})
+	included, _ := subscribe(t, f, IncludedUserOps)
+	dropped, _ := subscribe(t, f, DroppedUserOps)
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(0).Add(op1.Nonce, common.Big1)
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	ctx.MarkOpIndexForRemoval(1, "expired")
+	if err := f.PublishRemovedOps()(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := receive(t, dropped)
+	if want := op2.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID); ev.UserOpHash != want {
+		t.Fatalf("got %s, want %s", ev.UserOpHash, want)
+	} else if ev.Reason != "expired" {
+		t.Fatalf("got reason %s, want expired", ev.Reason)
+	}
+
+	ev = receive(t, included)
+	if want := op1.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID); ev.UserOpHash != want {
+		t.Fatalf("got %s, want %s", ev.UserOpHash, want)
+	} else if ev.Receipt == nil || ev.Receipt.UserOpHash != want {
+		t.Fatalf("got receipt %+v, want receipt for %s", ev.Receipt, want)
+	}
+}
+
+// TestFeedPublishUserOpDropped verifies that only subscribers of DroppedUserOps receive the event with its
+// reason.
+func TestFeedPublishUserOpDropped(t *testing.T) {
+	f := New()
+	added, _ := subscribe(t, f, NewUserOps)
+	dropped, _ := subscribe(t, f, DroppedUserOps)
+
+	op := testutils.MockValidInitUserOp()
+	hash := op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	f.PublishUserOpDropped(testutils.ValidAddress1, hash, op, "evicted by 0x01")
+	if ev := receive(t, dropped); ev.UserOpHash != hash {
+		t.Fatalf("got %s, want %s", ev.UserOpHash, hash)
+	} else if ev.Reason != "evicted by 0x01" {
+		t.Fatalf("got reason %s, want evicted by 0x01", ev.Reason)
+	}
+	select {
+	case ev := <-added:
+		t.Fatalf("got %v, want no event", ev)
+	default:
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/execution"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/utils"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
@@ -51,10 +52,13 @@ type EstimateInput struct {
 	MaxGasLimit          *big.Int
 	VerificationGasLimit *big.Int
 	Signer               *signer.EOA
+	Overrides            utils.StateOverrides
+	Block                *rpc.BlockNumberOrHash
 }
 
 // EstimateGas uses the simulateHandleOp method on the EntryPoint to derive an estimate for
-// verificationGasLimit and callGasLimit.
+// verificationGasLimit and callGasLimit. All simulations are made against in.Block, or the latest block if it
+// is nil, with a block tag pinned to a block number before the first simulation.
 func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err error) {
 	// Skip if maxFeePerGas is zero.
 	if in.Op.MaxFeePerGas.Cmp(big.NewInt(0)) != 1 {
@@ -65,6 +69,13 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 		)
 	}
 
+	// Pin every simulation to the same block for consistent results.
+	block, err := utils.PinBlock(in.Rpc, in.Block)
+	if err != nil {
+		return 0, 0, err
+	}
+	in.Block = block
+
 	// Set the initial conditions.
 	data, err := in.Op.ToMap()
 	if err != nil {
@@ -93,6 +104,8 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 			EntryPoint: in.EntryPoint,
 			Op:         simOp,
 			ChainID:    in.ChainID,
+			Overrides:  in.Overrides,
+			Block:      in.Block,
 		})
 		simErr = err
 		if err != nil {
@@ -136,6 +149,8 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 		EntryPoint: in.EntryPoint,
 		Op:         simOp,
 		ChainID:    in.ChainID,
+		Overrides:  in.Overrides,
+		Block:      in.Block,
 	})
 	if err != nil {
 		return 0, 0, err
@@ -163,6 +178,8 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 		EntryPoint: in.EntryPoint,
 		Op:         simOp,
 		ChainID:    in.ChainID,
+		Overrides:  in.Overrides,
+		Block:      in.Block,
 	})
 	if err != nil {
 		// Execution is successful but one shot tracing has failed. Fallback to binary search with an
@@ -186,6 +203,8 @@ func EstimateGas(in *EstimateInput) (verificationGas uint64, callGas uint64, err
 					EntryPoint: in.EntryPoint,
 					Op:         simOp,
 					ChainID:    in.ChainID,
+					Overrides:  in.Overrides,
+					Block:      in.Block,
 				})
 				simErr = err
 				if err != nil && (isExecutionOOG(err) || isExecutionReverted(err)) {
@@ -240,11 +259,19 @@ func EstimateGasNoTrace(in *EstimateInput) (verificationGas uint64, callGas uint
 		)
 	}
 
+	// Pin every simulation to the same block for consistent results.
+	block, err := utils.PinBlock(in.Rpc, in.Block)
+	if err != nil {
+		return 0, 0, err
+	}
+	in.Block = block
+
 	walletCreationGas, err := execution.EstimateCreationGas(
 		in.Signer,
 		in.Rpc,
 		in.Op,
 		in.MaxGasLimit,
+		in.Block,
 	)
 
 	if err != nil {
@@ -275,6 +302,8 @@ func EstimateGasNoTrace(in *EstimateInput) (verificationGas uint64, callGas uint
 		common.BigToAddress(big.NewInt(0)),
 		nil,
 		in.MaxGasLimit,
+		in.Overrides,
+		in.Block,
 	)
 
 	times += 1
@@ -291,6 +320,8 @@ func EstimateGasNoTrace(in *EstimateInput) (verificationGas uint64, callGas uint
 				common.BigToAddress(big.NewInt(0)),
 				nil,
 				in.MaxGasLimit,
+				in.Overrides,
+				in.Block,
 			)
 			times += 1
 			if err == nil {
@@ -308,13 +339,13 @@ func EstimateGasNoTrace(in *EstimateInput) (verificationGas uint64, callGas uint
 	}
 
 	// estimate verification gas
-	_, err = simulation.SimulateValidation(in.Rpc, in.EntryPoint, in.Op, in.Signer)
+	_, err = simulation.SimulateValidation(in.Rpc, in.EntryPoint, in.Op, in.Signer, in.Overrides, in.Block)
 	times += 1
 	if err != nil && isValidationOOG(err) {
 		for {
 			verificationGas = verificationGas + 10000
 			in.Op.VerificationGasLimit = big.NewInt(0).SetUint64(verificationGas)
-			_, err = simulation.SimulateValidation(in.Rpc, in.EntryPoint, in.Op, in.Signer)
+			_, err = simulation.SimulateValidation(in.Rpc, in.EntryPoint, in.Op, in.Signer, in.Overrides, in.Block)
 			times += 1
 			if err == nil {
 				break
@@ -347,6 +378,8 @@ func EstimateGasNoTrace(in *EstimateInput) (verificationGas uint64, callGas uint
 		common.BigToAddress(big.NewInt(0)),
 		nil,
 		in.MaxGasLimit,
+		in.Overrides,
+		in.Block,
 	)
 
 	times += 1
@@ -362,7 +395,7 @@ func EstimateGasNoTrace(in *EstimateInput) (verificationGas uint64, callGas uint
 	callGasLimit := big.NewInt(0).Sub(ev.Paid, ev.PreOpGas)
 	callGas = callGasLimit.Uint64()
 
-	if len(in.Op.PaymasterAndData) > 0 {
+	if in.Op.Version() == userop.V06 && len(in.Op.PaymasterAndData) > 0 {
 		fmt.Println("has paymaster")
 		verificationGas = (verificationGas-walletCreationGas)*3 + walletCreationGas
 	} else {
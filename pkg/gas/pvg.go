@@ -30,6 +30,30 @@ func calcPVGFuncNoop() CalcPreVerificationGasFunc {
 	}
 }
 
+// packHandleOps returns the calldata for calling handleOps with a single userOp using the encoding that
+// matches the userOp's EntryPoint version.
+func packHandleOps(op *userop.UserOperation, beneficiary common.Address) ([]byte, error) {
+	if op.Version() == userop.V07 {
+		args, err := methods.HandleOpsV07Method.Inputs.Pack(
+			[]userop.PackedUserOperation{op.ToPacked()},
+			beneficiary,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return append(methods.HandleOpsV07Method.ID, args...), nil
+	}
+
+	args, err := methods.HandleOpsMethod.Inputs.Pack(
+		[]entrypoint.UserOperation{entrypoint.ToUserOperation(op)},
+		beneficiary,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return append(methods.HandleOpsMethod.ID, args...), nil
+}
+
 // CalcArbitrumPVGWithEthClient uses Arbitrum's NodeInterface precompile to get an estimate for
 // preVerificationGas that takes into account the L1 gas component. see
 // https://medium.com/offchainlabs/understanding-arbitrum-2-dimensional-fees-fd1d582596c9.
@@ -43,21 +67,11 @@ func CalcArbitrumPVGWithEthClient(
 		// Sanitize paymasterAndData.
 		// TODO: Figure out why variability in this field is causing Arbitrum's precompile to return different
 		// values.
-		data, err := op.ToMap()
-		if err != nil {
-			return nil, err
-		}
-		data["paymasterAndData"] = hexutil.Encode(bytes.Repeat([]byte{1}, len(op.PaymasterAndData)))
-		tmp, err := userop.New(data)
-		if err != nil {
-			return nil, err
-		}
+		tmp := *op
+		tmp.PaymasterAndData = bytes.Repeat([]byte{1}, len(op.PaymasterAndData))
 
 		// Pack handleOps method inputs
-		ho, err := methods.HandleOpsMethod.Inputs.Pack(
-			[]entrypoint.UserOperation{entrypoint.UserOperation(*tmp)},
-			dummy.Address,
-		)
+		ho, err := packHandleOps(&tmp, dummy.Address)
 		if err != nil {
 			return nil, err
 		}
@@ -70,7 +84,7 @@ func CalcArbitrumPVGWithEthClient(
 		ge, err := nodeinterface.GasEstimateL1ComponentMethod.Inputs.Pack(
 			entryPoint,
 			create,
-			append(methods.HandleOpsMethod.ID, ho...),
+			ho,
 		)
 		if err != nil {
 			return nil, err
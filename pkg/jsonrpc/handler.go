@@ -2,20 +2,33 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"reflect"
+	"strconv"
+	"sync"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/gin-gonic/gin"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
-func jsonrpcError(c *gin.Context, code int, message string, data any, id *float64) {
-	c.JSON(http.StatusOK, gin.H{
+const (
+	// maxBatchSize is the max number of requests allowed in a single batch.
+	maxBatchSize = 100
+
+	// batchWorkers is the max number of requests in a batch that are processed at the same time.
+	batchWorkers = 10
+)
+
+func jsonrpcErrorResponse(code int, message string, data any, id any) gin.H {
+	return gin.H{
 		"jsonrpc": "2.0",
 		"error": gin.H{
 			"code":    code,
@@ -23,16 +36,54 @@ func jsonrpcError(c *gin.Context, code int, message string, data any, id *float6
 			"data":    data,
 		},
 		"id": id,
-	})
+	}
+}
+
+func jsonrpcError(c *gin.Context, code int, message string, data any, id any) {
+	c.JSON(http.StatusOK, jsonrpcErrorResponse(code, message, data, id))
 	c.Abort()
 }
 
+func jsonrpcResult(result any, err error, id any) gin.H {
+	if err != nil {
+		rpcErr, ok := err.(*errors.RPCError)
+
+		if ok {
+			return jsonrpcErrorResponse(rpcErr.Code(), rpcErr.Error(), rpcErr.Data(), id)
+		} else {
+			return jsonrpcErrorResponse(-32601, err.Error(), err.Error(), id)
+		}
+	}
+
+	return gin.H{
+		"result":  result,
+		"jsonrpc": "2.0",
+		"id":      id,
+	}
+}
+
+// localMethod is an RPC method that is handled by the transport itself instead of the api (e.g. subscriptions
+// over WebSocket).
+type localMethod = func(params []json.RawMessage) (any, error)
+
+func isError(res gin.H) bool {
+	_, ok := res["error"]
+	return ok
+}
+
 // Controller returns a custom Gin middleware that handles incoming JSON-RPC requests via HTTP. It maps the
 // RPC method name to struct methods on the given api. For example, if the RPC request has the method field
 // set to "namespace_methodName" then the controller will make a call to api.Namespace_methodName with the
 // params spread as arguments.
 //
-// If request is valid it will also set the data on the Gin context with the key "json-rpc-request".
+// The request body can either be a single request object or a batch array of request objects. A batch can
+// have up to 100 requests which are processed concurrently by a bounded number of workers and the response is
+// an array with a result or error for each request.
+// Requests without an id are treated as notifications and no response is returned for them. If a request or
+// batch only contains notifications then the response will have no body.
+//
+// If a single request is valid it will also set the data on the Gin context with the key "json-rpc-request".
+// For a batch, the valid requests are set as a slice with the key "json-rpc-batch".
 func Controller(api interface{}) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.Method != "POST" {
@@ -51,364 +102,365 @@ func Controller(api interface{}) gin.HandlerFunc {
 			return
 		}
 
-		data := make(map[string]any)
-		err = json.Unmarshal(body, &data)
-		if err != nil {
+		body = bytes.TrimSpace(body)
+		if !json.Valid(body) {
 			jsonrpcError(c, -32700, "Parse error", "Error parsing json request", nil)
 			return
 		}
 
-		id, ok := data["id"].(float64)
-		if !ok {
-			jsonrpcError(c, -32600, "Invalid Request", "No or invalid 'id' in request", nil)
+		if len(body) > 0 && body[0] == '[' {
+			handleBatch(c, api, body)
 			return
 		}
 
-		if data["jsonrpc"] != "2.0" {
-			jsonrpcError(c, -32600, "Invalid Request", "Version of jsonrpc is not 2.0", &id)
-			return
+		res, data := handleRequest(api, nil, body)
+		if data != nil {
+			c.Set("json-rpc-request", data)
+		}
+		if res == nil {
+			c.Status(http.StatusNoContent)
+		} else if isError(res) {
+			c.JSON(http.StatusOK, res)
+			c.Abort()
+		} else {
+			c.JSON(http.StatusOK, res)
 		}
+	}
+}
 
-		method, ok := data["method"].(string)
-		if !ok {
-			jsonrpcError(c, -32600, "Invalid Request", "No or invalid 'method' in request", &id)
-			return
+// handleBatch processes every request in a batch array concurrently and writes the responses in the same
+// order as the requests.
+func handleBatch(c *gin.Context, api interface{}, body []byte) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		jsonrpcError(c, -32700, "Parse error", "Error parsing json request", nil)
+		return
+	}
+	if msg, ok := checkBatchSize(items); !ok {
+		jsonrpcError(c, -32600, "Invalid Request", msg, nil)
+		return
+	}
+
+	res, batch := handleBatchItems(api, nil, items)
+	c.Set("json-rpc-batch", batch)
+
+	if len(res) == 0 {
+		c.Status(http.StatusNoContent)
+	} else {
+		c.JSON(http.StatusOK, res)
+	}
+}
+
+// checkBatchSize returns false and a reason if a batch is empty or has more than maxBatchSize requests.
+func checkBatchSize(items []json.RawMessage) (string, bool) {
+	if len(items) == 0 {
+		return "Empty batch", false
+	} else if len(items) > maxBatchSize {
+		return fmt.Sprintf("Batch exceeds max size of %d", maxBatchSize), false
+	}
+	return "", true
+}
+
+// handleBatchItems processes every request in a batch concurrently with up to batchWorkers at a time. It
+// returns the responses, excluding notifications, and the valid requests in the same order as the batch.
+func handleBatchItems(
+	api interface{},
+	local map[string]localMethod,
+	items []json.RawMessage,
+) ([]gin.H, []map[string]any) {
+	results := make([]gin.H, len(items))
+	requests := make([]map[string]any, len(items))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < batchWorkers && w < len(items); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], requests[i] = handleRequest(api, local, items[i])
+			}
+		}()
+	}
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	res := []gin.H{}
+	for _, r := range results {
+		if r != nil {
+			res = append(res, r)
 		}
+	}
+	batch := []map[string]any{}
+	for _, r := range requests {
+		if r != nil {
+			batch = append(batch, r)
+		}
+	}
+	return res, batch
+}
 
-		params, ok := data["params"].([]interface{})
-		if !ok {
-			jsonrpcError(c, -32602, "Invalid params", "No or invalid 'params' in request", &id)
-			return
+// parseID returns the id of a request and whether it is valid. A request without an id is a notification
+// and will return a nil id. Per the JSON-RPC 2.0 spec, an id must either be a string, number, or null. A
+// number is kept as a json.Number so that it is echoed back exactly as it was sent.
+func parseID(data map[string]any) (id any, isNotification bool, ok bool) {
+	id, exists := data["id"]
+	if !exists {
+		return nil, true, true
+	}
+
+	switch id.(type) {
+	case nil, string, json.Number:
+		return id, false, true
+	default:
+		return nil, false, false
+	}
+}
+
+// handleRequest processes a single JSON-RPC request object. It returns the response and the parsed request
+// if it was valid. The response is nil if the request is a notification. Methods in local take precedence
+// over methods on the api.
+func handleRequest(api interface{}, local map[string]localMethod, body []byte) (gin.H, map[string]any) {
+	data := make(map[string]any)
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return jsonrpcErrorResponse(-32600, "Invalid Request", "Request is not an object", nil), nil
+	}
+
+	id, isNotification, ok := parseID(data)
+	if !ok {
+		return jsonrpcErrorResponse(-32600, "Invalid Request", "No or invalid 'id' in request", nil), nil
+	}
+
+	if data["jsonrpc"] != "2.0" {
+		return jsonrpcErrorResponse(-32600, "Invalid Request", "Version of jsonrpc is not 2.0", id), nil
+	}
+
+	method, ok := data["method"].(string)
+	if !ok {
+		return jsonrpcErrorResponse(-32600, "Invalid Request", "No or invalid 'method' in request", id), nil
+	}
+
+	var req struct {
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return jsonrpcErrorResponse(-32600, "Invalid Request", "Request is not an object", nil), nil
+	}
+
+	res := callMethod(api, local, method, req.Params, id)
+	if isNotification {
+		return nil, data
+	}
+	return res, data
+}
+
+// callMethod maps the method and params to a call on the api and returns the JSON-RPC response. Trailing
+// params that can be nil (e.g. pointers, maps, slices, and interfaces) are optional and will be set to their
+// zero value if omitted.
+func callMethod(
+	api interface{},
+	local map[string]localMethod,
+	method string,
+	rawParams json.RawMessage,
+	id any,
+) gin.H {
+	params := []json.RawMessage{}
+	if len(rawParams) > 0 && string(rawParams) != "null" {
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return jsonrpcErrorResponse(-32602, "Invalid params", "No or invalid 'params' in request", id)
 		}
+	}
 
-		call := reflect.ValueOf(api).MethodByName(cases.Title(language.Und, cases.NoLower).String(method))
-		if !call.IsValid() {
-			jsonrpcError(c, -32601, "Method not found", "Method not found", &id)
-			return
+	if fn, ok := local[method]; ok {
+		result, err := fn(params)
+		return jsonrpcResult(result, err, id)
+	}
+
+	call := reflect.ValueOf(api).MethodByName(cases.Title(language.Und, cases.NoLower).String(method))
+	if !call.IsValid() {
+		return jsonrpcErrorResponse(-32601, "Method not found", "Method not found", id)
+	}
+
+	numIn := call.Type().NumIn()
+	if len(params) > numIn || len(params) < numIn-optionalParams(call.Type()) {
+		return jsonrpcErrorResponse(-32602, "Invalid params", "Invalid number of params", id)
+	}
+
+	args := make([]reflect.Value, numIn)
+	for i := 0; i < numIn; i++ {
+		t := call.Type().In(i)
+		if i >= len(params) {
+			args[i] = reflect.Zero(t)
+			continue
 		}
 
-		if call.Type().NumIn() != len(params) {
-			jsonrpcError(c, -32602, "Invalid params", "Invalid number of params", &id)
-			return
+		val, err := decodeArg(t, params[i])
+		if err != nil {
+			return jsonrpcErrorResponse(
+				-32602,
+				"Invalid params",
+				fmt.Sprintf("Param [%d] can't be converted to %v", i, t.String()),
+				id,
+			)
 		}
+		args[i] = val
+	}
 
-		args := make([]reflect.Value, len(params))
-		for i, arg := range params {
-			switch call.Type().In(i).Kind() {
-			case reflect.Float32:
-				val, ok := arg.(float32)
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Float64:
-				val, ok := arg.(float64)
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Int:
-				val, ok := arg.(int)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = int(fval)
-					}
-				}
+	result := call.Call(args)
+	if len(result) == 0 {
+		return jsonrpcResult(nil, nil, id)
+	}
+	if err, ok := result[len(result)-1].Interface().(error); ok && err != nil {
+		return jsonrpcResult(nil, err, id)
+	} else if len(result) > 1 || !isErrorType(call.Type().Out(0)) {
+		return jsonrpcResult(result[0].Interface(), nil, id)
+	} else {
+		return jsonrpcResult(nil, nil, id)
+	}
+}
 
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Int8:
-				val, ok := arg.(int8)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = int8(fval)
-					}
-				}
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Int16:
-				val, ok := arg.(int16)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = int16(fval)
-					}
-				}
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Int32:
-				val, ok := arg.(int32)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = int32(fval)
-					}
-				}
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Int64:
-				val, ok := arg.(int64)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = int64(fval)
-					}
-				}
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Interface:
-				args[i] = reflect.ValueOf(arg)
-
-			case reflect.Map:
-				val, ok := arg.(map[string]any)
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Slice:
-				val, ok := arg.([]interface{})
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.String:
-				val, ok := arg.(string)
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Uint:
-				val, ok := arg.(uint)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = uint(fval)
-					}
-				}
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Uint8:
-				val, ok := arg.(uint8)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = uint8(fval)
-					}
-				}
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Uint16:
-				val, ok := arg.(uint16)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = uint16(fval)
-					}
-				}
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Uint32:
-				val, ok := arg.(uint32)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = uint32(fval)
-					}
-				}
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
-
-			case reflect.Uint64:
-				val, ok := arg.(uint64)
-				if !ok {
-					var fval float64
-					fval, ok = arg.(float64)
-					if ok {
-						val = uint64(fval)
-					}
-				}
-				if !ok {
-					jsonrpcError(
-						c,
-						-32602,
-						"Invalid params",
-						fmt.Sprintf("Param [%d] can't be converted to %v", i, call.Type().In(i).String()),
-						&id,
-					)
-					return
-				}
-				args[i] = reflect.ValueOf(val)
+var (
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	bigIntType = reflect.TypeOf((*big.Int)(nil))
+)
 
-			default:
-				if !ok {
-					jsonrpcError(c, -32603, "Internal error", "Invalid method definition", &id)
-					return
-				}
+func isErrorType(t reflect.Type) bool {
+	return t.Implements(errorType)
+}
+
+// optionalParams returns the number of trailing arguments of a method that can be omitted.
+func optionalParams(t reflect.Type) int {
+	n := 0
+	for i := t.NumIn() - 1; i >= 0; i-- {
+		switch t.In(i).Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+			n++
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// decodeArg converts a JSON param to the type of a method argument. Basic types are converted from their
+// generic JSON representation for compatibility with existing methods. Any other type is decoded with
+// encoding/json, which allows for structs and types that implement json.Unmarshaler such as common.Address
+// and hexutil.Bytes. A *big.Int can be given as either a number or a hex string.
+func decodeArg(t reflect.Type, raw json.RawMessage) (reflect.Value, error) {
+	var arg any
+	if err := json.Unmarshal(raw, &arg); err != nil {
+		return reflect.Value{}, err
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.String:
+		if t.PkgPath() == "" {
+			if val, ok := convertArg(t, raw); ok {
+				return val, nil
 			}
+			return reflect.Value{}, fmt.Errorf("jsonrpc: cannot convert %v to %v", arg, t)
+		}
+
+	case reflect.Interface:
+		if arg == nil {
+			return reflect.Zero(t), nil
+		}
+		if reflect.TypeOf(arg).Implements(t) {
+			return reflect.ValueOf(arg), nil
 		}
 
-		c.Set("json-rpc-request", data)
-		result := call.Call(args)
-		if err, ok := result[len(result)-1].Interface().(error); ok && err != nil {
-			rpcErr, ok := err.(*errors.RPCError)
+	case reflect.Map:
+		if v, ok := arg.(map[string]any); ok && t == reflect.TypeOf(v) {
+			return reflect.ValueOf(v), nil
+		}
 
-			if ok {
-				jsonrpcError(c, rpcErr.Code(), rpcErr.Error(), rpcErr.Data(), &id)
-			} else {
-				jsonrpcError(c, -32601, err.Error(), err.Error(), &id)
+	case reflect.Slice:
+		if v, ok := arg.([]interface{}); ok && t == reflect.TypeOf(v) {
+			return reflect.ValueOf(v), nil
+		}
+
+	case reflect.Pointer:
+		if t == bigIntType {
+			if s, ok := arg.(string); ok {
+				v, err := hexutil.DecodeBig(s)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				return reflect.ValueOf(v), nil
 			}
-		} else if len(result) > 0 {
-			c.JSON(http.StatusOK, gin.H{
-				"result":  result[0].Interface(),
-				"jsonrpc": "2.0",
-				"id":      id,
-			})
-		} else {
-			c.JSON(http.StatusOK, gin.H{
-				"result":  nil,
-				"jsonrpc": "2.0",
-				"id":      id,
-			})
 		}
 	}
+
+	val := reflect.New(t)
+	if err := json.Unmarshal(raw, val.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return val.Elem(), nil
+}
+
+// convertArg converts a JSON value to a basic type. Numbers are parsed from their original representation so
+// that integers are not rounded through a float64. Integer types only accept whole numbers within the range
+// of the type. The second return value is false if the value cannot be converted.
+func convertArg(t reflect.Type, raw json.RawMessage) (reflect.Value, bool) {
+	if t.Kind() == reflect.String {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(v), true
+	}
+
+	// A quoted number would also be decoded into a json.Number.
+	var n json.Number
+	if len(raw) == 0 || raw[0] == '"' || json.Unmarshal(raw, &n) != nil {
+		return reflect.Value{}, false
+	}
+
+	val := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(n.String(), t.Bits())
+		if err != nil {
+			return reflect.Value{}, false
+		}
+		val.SetFloat(f)
+		return val, true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := parseInteger(n)
+		if !ok || !i.IsInt64() || val.OverflowInt(i.Int64()) {
+			return reflect.Value{}, false
+		}
+		val.SetInt(i.Int64())
+		return val, true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := parseInteger(n)
+		if !ok || !i.IsUint64() || val.OverflowUint(i.Uint64()) {
+			return reflect.Value{}, false
+		}
+		val.SetUint(i.Uint64())
+		return val, true
+
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+// parseInteger returns the value of a JSON number if it is a whole number (e.g. 2 or 2.0 but not 2.5).
+func parseInteger(n json.Number) (*big.Int, bool) {
+	f, _, err := big.ParseFloat(n.String(), 10, 256, big.ToNearestEven)
+	// Values of 2^64 or more are out of range for every integer type.
+	if err != nil || !f.IsInt() || f.MantExp(nil) > 64 {
+		return nil, false
+	}
+	i, _ := f.Int(nil)
+	return i, true
 }
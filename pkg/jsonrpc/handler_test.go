@@ -0,0 +1,366 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+)
+
+type testApi struct{}
+
+type testStruct struct {
+	Address common.Address `json:"address"`
+	Data    hexutil.Bytes  `json:"data"`
+	Values  []uint64       `json:"values"`
+}
+
+type testResult struct {
+	Address common.Address `json:"address"`
+	Amount  *hexutil.Big   `json:"amount"`
+	Length  int            `json:"length"`
+	Sum     uint64         `json:"sum"`
+}
+
+func (t *testApi) Test_typed(s testStruct, amount *big.Int, opt *testStruct) (*testResult, error) {
+	sum := uint64(0)
+	for _, v := range s.Values {
+		sum += v
+	}
+	if opt != nil {
+		sum += 100
+	}
+	return &testResult{
+		Address: s.Address,
+		Amount:  (*hexutil.Big)(amount),
+		Length:  len(s.Data),
+		Sum:     sum,
+	}, nil
+}
+
+func (t *testApi) Test_echo(s string) (string, error) {
+	return s, nil
+}
+
+func (t *testApi) Test_add(a int, b int) (int, error) {
+	return a + b, nil
+}
+
+func (t *testApi) Test_fail() (string, error) {
+	return "", errors.New("fail")
+}
+
+var slowActive, slowMaxActive int64
+
+func (t *testApi) Test_slow() (string, error) {
+	n := atomic.AddInt64(&slowActive, 1)
+	defer atomic.AddInt64(&slowActive, -1)
+	for {
+		m := atomic.LoadInt64(&slowMaxActive)
+		if n <= m || atomic.CompareAndSwapInt64(&slowMaxActive, m, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return "ok", nil
+}
+
+func serve(t *testing.T, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", Controller(&testApi{}))
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeObject(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	var res map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("got %s, want object: %v", w.Body.String(), err)
+	}
+	return res
+}
+
+func errorCode(res map[string]any) float64 {
+	e, ok := res["error"].(map[string]any)
+	if !ok {
+		return 0
+	}
+	return e["code"].(float64)
+}
+
+// TestControllerStringID verifies that a request with a string id is handled and the id is echoed back.
+func TestControllerStringID(t *testing.T) {
+	w := serve(t, `{"jsonrpc":"2.0","id":"abc","method":"test_echo","params":["hi"]}`)
+	res := decodeObject(t, w)
+	if res["id"] != "abc" {
+		t.Fatalf("got id %v, want abc", res["id"])
+	}
+	if res["result"] != "hi" {
+		t.Fatalf("got result %v, want hi", res["result"])
+	}
+}
+
+// TestControllerNullID verifies that a request with a null id receives a response with a null id.
+func TestControllerNullID(t *testing.T) {
+	w := serve(t, `{"jsonrpc":"2.0","id":null,"method":"test_add","params":[1,2]}`)
+	res := decodeObject(t, w)
+	if v, ok := res["id"]; !ok || v != nil {
+		t.Fatalf("got id %v, want null", v)
+	}
+	if res["result"] != float64(3) {
+		t.Fatalf("got result %v, want 3", res["result"])
+	}
+}
+
+// TestControllerNotification verifies that a request without an id does not receive a response body.
+func TestControllerNotification(t *testing.T) {
+	w := serve(t, `{"jsonrpc":"2.0","method":"test_echo","params":["hi"]}`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w.Body.Len() != 0 {
+		t.Fatalf("got body %s, want empty", w.Body.String())
+	}
+}
+
+// TestControllerInvalidID verifies that an id that is not a string, number or null is an invalid request.
+func TestControllerInvalidID(t *testing.T) {
+	w := serve(t, `{"jsonrpc":"2.0","id":{},"method":"test_echo","params":["hi"]}`)
+	if code := errorCode(decodeObject(t, w)); code != -32600 {
+		t.Fatalf("got code %v, want -32600", code)
+	}
+}
+
+// TestControllerInvalidRequest verifies that valid JSON that is not a request object returns -32600 and
+// invalid JSON returns -32700.
+func TestControllerInvalidRequest(t *testing.T) {
+	if code := errorCode(decodeObject(t, serve(t, `1`))); code != -32600 {
+		t.Fatalf("got code %v, want -32600", code)
+	}
+	if code := errorCode(decodeObject(t, serve(t, `[]`))); code != -32600 {
+		t.Fatalf("got code %v, want -32600", code)
+	}
+	if code := errorCode(decodeObject(t, serve(t, `{"jsonrpc":"2.0"`))); code != -32700 {
+		t.Fatalf("got code %v, want -32700", code)
+	}
+}
+
+// TestControllerBatch verifies that every request in a batch gets a response in order, with per item errors,
+// and that notifications are omitted.
+func TestControllerBatch(t *testing.T) {
+	w := serve(t, `[
+		{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["hi"]},
+		{"jsonrpc":"2.0","method":"test_echo","params":["notify"]},
+		{"jsonrpc":"2.0","id":"2","method":"test_fail","params":[]},
+		1,
+		{"jsonrpc":"2.0","id":3,"method":"test_missing","params":[]}
+	]`)
+
+	var res []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("got %s, want array: %v", w.Body.String(), err)
+	}
+	if len(res) != 4 {
+		t.Fatalf("got %d responses, want 4", len(res))
+	}
+	if res[0]["id"] != float64(1) || res[0]["result"] != "hi" {
+		t.Fatalf("got %v, want result hi for id 1", res[0])
+	}
+	if res[1]["id"] != "2" || errorCode(res[1]) == 0 {
+		t.Fatalf("got %v, want error for id 2", res[1])
+	}
+	if res[2]["id"] != nil || errorCode(res[2]) != -32600 {
+		t.Fatalf("got %v, want -32600 with null id", res[2])
+	}
+	if res[3]["id"] != float64(3) || errorCode(res[3]) != -32601 {
+		t.Fatalf("got %v, want -32601 for id 3", res[3])
+	}
+}
+
+// TestControllerBatchNotifications verifies that a batch of only notifications does not receive a response
+// body.
+func TestControllerBatchNotifications(t *testing.T) {
+	w := serve(t, `[{"jsonrpc":"2.0","method":"test_echo","params":["hi"]}]`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if w.Body.Len() != 0 {
+		t.Fatalf("got body %s, want empty", w.Body.String())
+	}
+}
+
+// TestControllerTypedParams verifies that params are decoded into structs and types that implement
+// json.Unmarshaler, that a *big.Int can be a hex string or number, and that typed results are encoded.
+func TestControllerTypedParams(t *testing.T) {
+	w := serve(t, `{"jsonrpc":"2.0","id":1,"method":"test_typed","params":[
+		{"address":"0x0000000000000000000000000000000000000001","data":"0x0102","values":[1,2]},
+		"0x10"
+	]}`)
+	res := decodeObject(t, w)
+	result, ok := res["result"].(map[string]any)
+	if !ok {
+		t.Fatalf("got %v, want result object", res)
+	}
+	if result["address"] != "0x0000000000000000000000000000000000000001" {
+		t.Fatalf("got address %v, want 0x...01", result["address"])
+	}
+	if result["amount"] != "0x10" {
+		t.Fatalf("got amount %v, want 0x10", result["amount"])
+	}
+	if result["length"] != float64(2) {
+		t.Fatalf("got length %v, want 2", result["length"])
+	}
+	if result["sum"] != float64(3) {
+		t.Fatalf("got sum %v, want 3", result["sum"])
+	}
+
+	w = serve(t, `{"jsonrpc":"2.0","id":1,"method":"test_typed","params":[{"values":[1]},16,{}]}`)
+	result = decodeObject(t, w)["result"].(map[string]any)
+	if result["amount"] != "0x10" {
+		t.Fatalf("got amount %v, want 0x10", result["amount"])
+	}
+	if result["sum"] != float64(101) {
+		t.Fatalf("got sum %v, want 101", result["sum"])
+	}
+}
+
+// TestControllerOptionalParams verifies that only trailing nilable params can be omitted.
+func TestControllerOptionalParams(t *testing.T) {
+	w := serve(t, `{"jsonrpc":"2.0","id":1,"method":"test_typed","params":[{}]}`)
+	if res := decodeObject(t, w); res["result"] == nil {
+		t.Fatalf("got %v, want result", res)
+	}
+
+	w = serve(t, `{"jsonrpc":"2.0","id":1,"method":"test_typed","params":[]}`)
+	if code := errorCode(decodeObject(t, w)); code != -32602 {
+		t.Fatalf("got code %v, want -32602", code)
+	}
+
+	w = serve(t, `{"jsonrpc":"2.0","id":1,"method":"test_add","params":[1]}`)
+	if code := errorCode(decodeObject(t, w)); code != -32602 {
+		t.Fatalf("got code %v, want -32602", code)
+	}
+}
+
+// TestControllerInvalidTypedParams verifies that a param that cannot be decoded returns -32602.
+func TestControllerInvalidTypedParams(t *testing.T) {
+	w := serve(t, `{"jsonrpc":"2.0","id":1,"method":"test_typed","params":[{"address":1}]}`)
+	if code := errorCode(decodeObject(t, w)); code != -32602 {
+		t.Fatalf("got code %v, want -32602", code)
+	}
+}
+
+// TestControllerNumericID verifies that a numeric id is echoed back exactly as it was sent, including large
+// integers that can't be represented as a float64.
+func TestControllerNumericID(t *testing.T) {
+	w := serve(t, `{"jsonrpc":"2.0","id":12345678901234567891,"method":"test_echo","params":["hi"]}`)
+	if !strings.Contains(w.Body.String(), `"id":12345678901234567891`) {
+		t.Fatalf("got %s, want id 12345678901234567891", w.Body.String())
+	}
+
+	w = serve(t, `[{"jsonrpc":"2.0","id":1.50,"method":"test_echo","params":["hi"]}]`)
+	if !strings.Contains(w.Body.String(), `"id":1.50`) {
+		t.Fatalf("got %s, want id 1.50", w.Body.String())
+	}
+}
+
+// TestControllerBatchTooLarge verifies that a batch with more than the max number of requests returns -32600.
+func TestControllerBatchTooLarge(t *testing.T) {
+	items := make([]string, maxBatchSize+1)
+	for i := range items {
+		items[i] = `{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["hi"]}`
+	}
+	w := serve(t, "["+strings.Join(items, ",")+"]")
+	if code := errorCode(decodeObject(t, w)); code != -32600 {
+		t.Fatalf("got code %v, want -32600", code)
+	}
+
+	w = serve(t, "["+strings.Join(items[:maxBatchSize], ",")+"]")
+	var res []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || len(res) != maxBatchSize {
+		t.Fatalf("got %s, want %d responses", w.Body.String(), maxBatchSize)
+	}
+}
+
+// TestControllerBatchWorkers verifies that no more than batchWorkers requests in a batch are processed at the
+// same time.
+func TestControllerBatchWorkers(t *testing.T) {
+	items := make([]string, maxBatchSize)
+	for i := range items {
+		items[i] = `{"jsonrpc":"2.0","id":1,"method":"test_slow","params":[]}`
+	}
+	atomic.StoreInt64(&slowMaxActive, 0)
+	serve(t, "["+strings.Join(items, ",")+"]")
+	if n := atomic.LoadInt64(&slowMaxActive); n > batchWorkers {
+		t.Fatalf("got %d concurrent requests, want at most %d", n, batchWorkers)
+	}
+}
+
+// TestDecodeArgNumbers verifies that numbers are converted to float and integer types, and that integer types
+// reject fractional and out of range values.
+func TestDecodeArgNumbers(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want any
+		ok   bool
+	}{
+		{`1.5`, float32(1.5), true},
+		{`1e39`, float32(0), false},
+		{`1.5`, float64(1.5), true},
+		{`"1.5"`, float64(0), false},
+		{`2`, int(2), true},
+		{`2.0`, int(2), true},
+		{`2.5`, int(0), false},
+		{`-129`, int8(0), false},
+		{`-128`, int8(-128), true},
+		{`9223372036854775808`, int64(0), false},
+		{`18446744073709551615`, uint64(18446744073709551615), true},
+		{`18446744073709551616`, uint64(0), false},
+		{`-1`, uint(0), false},
+		{`256`, uint8(0), false},
+		{`1e1000000`, uint64(0), false},
+		{`"2"`, int(0), false},
+	}
+
+	for _, tc := range tests {
+		typ := reflect.TypeOf(tc.want)
+		got, err := decodeArg(typ, json.RawMessage(tc.raw))
+		if !tc.ok {
+			if err == nil {
+				t.Fatalf("%s as %v: got %v, want err", tc.raw, typ, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s as %v: got err %v, want %v", tc.raw, typ, err, tc.want)
+		}
+		if got.Interface() != tc.want {
+			t.Fatalf("%s as %v: got %v, want %v", tc.raw, typ, got.Interface(), tc.want)
+		}
+	}
+}
+
+// TestControllerFractionalIntParam verifies that a fractional number for an integer param returns -32602.
+func TestControllerFractionalIntParam(t *testing.T) {
+	w := serve(t, `{"jsonrpc":"2.0","id":1,"method":"test_add","params":[1.5,2]}`)
+	if code := errorCode(decodeObject(t, w)); code != -32602 {
+		t.Fatalf("got code %v, want -32602", code)
+	}
+}
@@ -7,7 +7,7 @@ import (
 )
 
 // WithOTELTracerAttributes adds custom opentelemetry attributes relating to the JSON-RPC method call for the
-// current span.
+// current span. For a batch request, the methods of every valid request in the batch are added.
 func WithOTELTracerAttributes() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		req, ok := ctx.Get("json-rpc-request")
@@ -16,5 +16,15 @@ func WithOTELTracerAttributes() gin.HandlerFunc {
 			span := trace.SpanFromContext(ctx.Request.Context())
 			span.SetAttributes(attribute.String("jsonrpc_method", json["method"].(string)))
 		}
+
+		batch, ok := ctx.Get("json-rpc-batch")
+		if ok {
+			methods := []string{}
+			for _, json := range batch.([]map[string]any) {
+				methods = append(methods, json["method"].(string))
+			}
+			span := trace.SpanFromContext(ctx.Request.Context())
+			span.SetAttributes(attribute.StringSlice("jsonrpc_batch_methods", methods))
+		}
 	}
 }
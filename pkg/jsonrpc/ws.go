@@ -0,0 +1,215 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// DefaultWriteTimeout is the maximum time to wait for a message to be written to a WebSocket connection.
+	DefaultWriteTimeout = 10 * time.Second
+
+	// DefaultMaxMessageSize is the maximum size in bytes of a message read from a WebSocket connection.
+	DefaultMaxMessageSize = int64(5 * 1024 * 1024)
+
+	// DefaultMaxSubscriptions is the maximum number of active subscriptions on a single WebSocket connection.
+	DefaultMaxSubscriptions = 10
+)
+
+// SubscribeFunc is a general interface for starting a subscription of a given kind. The notify function must
+// be called with the result of every event until the returned unsubscribe function is called.
+type SubscribeFunc = func(kind string, notify func(result any)) (unsubscribe func(), err error)
+
+type wsConn struct {
+	conn      *websocket.Conn
+	subscribe SubscribeFunc
+	writeMu   sync.Mutex
+	subsMu    sync.Mutex
+	subs      map[string]func()
+}
+
+func (w *wsConn) write(v any) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	if err := w.conn.SetWriteDeadline(time.Now().Add(DefaultWriteTimeout)); err != nil {
+		return err
+	}
+	return w.conn.WriteJSON(v)
+}
+
+func newSubscriptionID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hexutil.Encode(id), nil
+}
+
+// ethSubscribe starts a subscription and returns its id. Events are sent to the connection as
+// eth_subscription notifications.
+func (w *wsConn) ethSubscribe(params []json.RawMessage) (any, error) {
+	if w.subscribe == nil {
+		return nil, errors.New("subscriptions are not supported")
+	}
+	if len(params) == 0 {
+		return nil, errors.New("missing subscription kind")
+	}
+	var kind string
+	if err := json.Unmarshal(params[0], &kind); err != nil {
+		return nil, err
+	}
+
+	// The lock is held until the subscription is added so that concurrent batch items cannot exceed the cap.
+	w.subsMu.Lock()
+	defer w.subsMu.Unlock()
+	if len(w.subs) >= DefaultMaxSubscriptions {
+		return nil, fmt.Errorf("too many subscriptions: max %d per connection", DefaultMaxSubscriptions)
+	}
+
+	id, err := newSubscriptionID()
+	if err != nil {
+		return nil, err
+	}
+	unsubscribe, err := w.subscribe(kind, func(result any) {
+		_ = w.write(gin.H{
+			"jsonrpc": "2.0",
+			"method":  "eth_subscription",
+			"params": gin.H{
+				"subscription": id,
+				"result":       result,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	w.subs[id] = unsubscribe
+	return id, nil
+}
+
+// ethUnsubscribe cancels a subscription by id and returns true if it existed.
+func (w *wsConn) ethUnsubscribe(params []json.RawMessage) (any, error) {
+	if len(params) != 1 {
+		return nil, errors.New("missing subscription id")
+	}
+	var id string
+	if err := json.Unmarshal(params[0], &id); err != nil {
+		return nil, err
+	}
+
+	w.subsMu.Lock()
+	unsubscribe, ok := w.subs[id]
+	delete(w.subs, id)
+	w.subsMu.Unlock()
+	if ok {
+		unsubscribe()
+	}
+	return ok, nil
+}
+
+func (w *wsConn) close() {
+	w.subsMu.Lock()
+	defer w.subsMu.Unlock()
+
+	for id, unsubscribe := range w.subs {
+		unsubscribe()
+		delete(w.subs, id)
+	}
+	_ = w.conn.Close()
+}
+
+// WebSocketController returns a custom Gin middleware that upgrades the request to a WebSocket connection
+// and handles JSON-RPC requests sent as messages. Methods are mapped to the api in the same way as Controller,
+// including batch requests and notifications.
+//
+// In addition, eth_subscribe and eth_unsubscribe are handled with the given SubscribeFunc. The first param of
+// eth_subscribe is the kind of subscription and the result is a subscription id. Each event is sent as an
+// eth_subscription notification with the subscription id and the event as the result. All subscriptions are
+// cancelled when the connection is closed. If subscribe is nil, eth_subscribe will return an error. A
+// connection can have at most DefaultMaxSubscriptions active subscriptions.
+func WebSocketController(api interface{}, subscribe SubscribeFunc) gin.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+
+	return func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			_ = c.Error(fmt.Errorf("websocket upgrade: %w", err))
+			c.Abort()
+			return
+		}
+		conn.SetReadLimit(DefaultMaxMessageSize)
+
+		w := &wsConn{
+			conn:      conn,
+			subscribe: subscribe,
+			subs:      make(map[string]func()),
+		}
+		defer w.close()
+
+		local := map[string]localMethod{
+			"eth_subscribe":   w.ethSubscribe,
+			"eth_unsubscribe": w.ethUnsubscribe,
+		}
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if res := handleMessage(api, local, msg); res != nil {
+				if err := w.write(res); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// handleMessage processes a single or batch request from a WebSocket message and returns the response. The
+// response is nil if there is nothing to send back.
+func handleMessage(api interface{}, local map[string]localMethod, msg []byte) any {
+	msg = bytes.TrimSpace(msg)
+	if !json.Valid(msg) {
+		return jsonrpcErrorResponse(-32700, "Parse error", "Error parsing json request", nil)
+	}
+
+	if len(msg) > 0 && msg[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(msg, &items); err != nil {
+			return jsonrpcErrorResponse(-32700, "Parse error", "Error parsing json request", nil)
+		}
+		if msg, ok := checkBatchSize(items); !ok {
+			return jsonrpcErrorResponse(-32600, "Invalid Request", msg, nil)
+		}
+
+		res, _ := handleBatchItems(api, local, items)
+		if len(res) == 0 {
+			return nil
+		}
+		return res
+	}
+
+	res, _ := handleRequest(api, local, msg)
+	if res == nil {
+		return nil
+	}
+	return res
+}
@@ -0,0 +1,124 @@
+package jsonrpc
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func dialWebSocket(t *testing.T, subscribe SubscribeFunc) *websocket.Conn {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/ws", WebSocketController(&testApi{}, subscribe))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) map[string]any {
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	var res map[string]any
+	if err := conn.ReadJSON(&res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// TestWebSocketCall verifies that api methods can be called over a WebSocket connection.
+func TestWebSocketCall(t *testing.T) {
+	conn := dialWebSocket(t, nil)
+	if err := conn.WriteMessage(
+		websocket.TextMessage,
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"test_add","params":[1,2]}`),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := readMessage(t, conn); res["result"] != float64(3) {
+		t.Fatalf("got %v, want result 3", res)
+	}
+}
+
+// TestWebSocketSubscription verifies that events are sent as eth_subscription notifications until the
+// subscription is cancelled.
+func TestWebSocketSubscription(t *testing.T) {
+	notifyCh := make(chan func(any), 1)
+	unsubscribed := make(chan bool, 1)
+	conn := dialWebSocket(t, func(kind string, notify func(result any)) (func(), error) {
+		notifyCh <- notify
+		return func() { unsubscribed <- true }, nil
+	})
+
+	if err := conn.WriteMessage(
+		websocket.TextMessage,
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newUserOperations"]}`),
+	); err != nil {
+		t.Fatal(err)
+	}
+	res := readMessage(t, conn)
+	id, ok := res["result"].(string)
+	if !ok {
+		t.Fatalf("got %v, want subscription id", res)
+	}
+
+	notify := <-notifyCh
+	notify("event")
+	res = readMessage(t, conn)
+	params, ok := res["params"].(map[string]any)
+	if res["method"] != "eth_subscription" || !ok {
+		t.Fatalf("got %v, want eth_subscription", res)
+	} else if params["subscription"] != id || params["result"] != "event" {
+		t.Fatalf("got %v, want subscription %s with result event", params, id)
+	}
+
+	if err := conn.WriteMessage(
+		websocket.TextMessage,
+		[]byte(`{"jsonrpc":"2.0","id":2,"method":"eth_unsubscribe","params":["`+id+`"]}`),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if res := readMessage(t, conn); res["result"] != true {
+		t.Fatalf("got %v, want true", res)
+	}
+	select {
+	case <-unsubscribed:
+	case <-time.After(time.Second):
+		t.Fatal("got no unsubscribe, want unsubscribe")
+	}
+}
+
+// TestWebSocketMaxSubscriptions verifies that eth_subscribe returns an error once a connection has reached
+// the maximum number of active subscriptions.
+func TestWebSocketMaxSubscriptions(t *testing.T) {
+	conn := dialWebSocket(t, func(kind string, notify func(result any)) (func(), error) {
+		return func() {}, nil
+	})
+
+	for i := 0; i <= DefaultMaxSubscriptions; i++ {
+		if err := conn.WriteMessage(
+			websocket.TextMessage,
+			[]byte(`{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newUserOperations"]}`),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		res := readMessage(t, conn)
+		if i < DefaultMaxSubscriptions && res["result"] == nil {
+			t.Fatalf("got %v for subscription %d, want result", res, i)
+		} else if i == DefaultMaxSubscriptions && res["error"] == nil {
+			t.Fatalf("got %v, want error", res)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package mempool
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Capacity defines the limits on the number of UserOperations held in the mempool for each EntryPoint. A
+// value of 0 means there is no limit.
+type Capacity struct {
+	// MaxPoolSize is the max number of UserOperations in the pool.
+	MaxPoolSize int
+
+	// MaxOpsPerPaymaster is the max number of UserOperations in the pool using the same paymaster.
+	MaxOpsPerPaymaster int
+
+	// MaxOpsPerFactory is the max number of UserOperations in the pool using the same factory.
+	MaxOpsPerFactory int
+}
+
+// capacityLimit is a single limit from the Capacity and the ops in the pool that count towards it.
+type capacityLimit struct {
+	name  string
+	limit int
+	ops   []*userop.UserOperation
+}
+
+// isEvictable returns true if the UserOperation can be removed from the pool without leaving a nonce gap for
+// its sender. Only the op with the highest sequence in each nonce key of a sender is evictable.
+func isEvictable(op *userop.UserOperation, pool []*userop.UserOperation, evict []*userop.UserOperation) bool {
+	for _, other := range pool {
+		if other.Sender == op.Sender &&
+			other.GetNonceKey().Cmp(op.GetNonceKey()) == 0 &&
+			other.GetNonceSequence() > op.GetNonceSequence() &&
+			!containsOp(evict, other) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsOp(ops []*userop.UserOperation, op *userop.UserOperation) bool {
+	for _, o := range ops {
+		if o.Sender == op.Sender && o.Nonce.Cmp(op.Nonce) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// findEvictionCandidate returns the UserOperation with the lowest effective gas price from the given ops that
+// can be removed to make room for the incoming op. If no op is priced lower than the incoming op, nil is
+// returned.
+func findEvictionCandidate(
+	op *userop.UserOperation,
+	ops []*userop.UserOperation,
+	pool []*userop.UserOperation,
+	evict []*userop.UserOperation,
+	baseFee *big.Int,
+) *userop.UserOperation {
+	var candidate *userop.UserOperation
+	for _, o := range ops {
+		if o.Sender == op.Sender || containsOp(evict, o) || !isEvictable(o, pool, evict) {
+			continue
+		}
+
+		if candidate == nil ||
+			o.GetDynamicGasPrice(baseFee).Cmp(candidate.GetDynamicGasPrice(baseFee)) < 0 {
+			candidate = o
+		}
+	}
+
+	if candidate == nil || candidate.GetDynamicGasPrice(baseFee).Cmp(op.GetDynamicGasPrice(baseFee)) >= 0 {
+		return nil
+	}
+	return candidate
+}
+
+func filterOps(ops []*userop.UserOperation, fn func(op *userop.UserOperation) bool) []*userop.UserOperation {
+	out := []*userop.UserOperation{}
+	for _, op := range ops {
+		if fn(op) {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+func newCapacityError(msg string, limit int) error {
+	return errors.NewRPCError(
+		errors.MEMPOOL_FULL,
+		fmt.Sprintf("mempool: %s limit of %d reached and userOp gas price is too low to replace", msg, limit),
+		nil,
+	)
+}
+
+// getCapacityLimits returns every limit from the Capacity that applies to the incoming op.
+func getCapacityLimits(c Capacity, pool []*userop.UserOperation, op *userop.UserOperation) []capacityLimit {
+	limits := []capacityLimit{}
+	if pm := op.GetPaymaster(); pm != common.HexToAddress("0x") && c.MaxOpsPerPaymaster > 0 {
+		limits = append(limits, capacityLimit{
+			name:  "paymaster",
+			limit: c.MaxOpsPerPaymaster,
+			ops:   filterOps(pool, func(o *userop.UserOperation) bool { return o.GetPaymaster() == pm }),
+		})
+	}
+	if f := op.GetFactory(); f != common.HexToAddress("0x") && c.MaxOpsPerFactory > 0 {
+		limits = append(limits, capacityLimit{
+			name:  "factory",
+			limit: c.MaxOpsPerFactory,
+			ops:   filterOps(pool, func(o *userop.UserOperation) bool { return o.GetFactory() == f }),
+		})
+	}
+	if c.MaxPoolSize > 0 {
+		limits = append(limits, capacityLimit{name: "pool size", limit: c.MaxPoolSize, ops: pool})
+	}
+	return limits
+}
+
+// isAtCapacity returns true if adding the incoming op would exceed at least one limit from the Capacity.
+func isAtCapacity(c Capacity, pool []*userop.UserOperation, op *userop.UserOperation) bool {
+	if containsOp(pool, op) {
+		return false
+	}
+
+	for _, l := range getCapacityLimits(c, pool, op) {
+		if len(l.ops) >= l.limit {
+			return true
+		}
+	}
+	return false
+}
+
+// getOpsToEvict returns the UserOperations that must be removed from the pool in order to add the incoming
+// op without exceeding the Capacity. An error is returned if the op cannot displace any existing ops.
+func getOpsToEvict(
+	c Capacity,
+	pool []*userop.UserOperation,
+	op *userop.UserOperation,
+	baseFee *big.Int,
+) ([]*userop.UserOperation, error) {
+	evict := []*userop.UserOperation{}
+	if containsOp(pool, op) {
+		// Replacing an existing op does not change the size of the pool.
+		return evict, nil
+	}
+
+	for _, l := range getCapacityLimits(c, pool, op) {
+		remaining := filterOps(l.ops, func(o *userop.UserOperation) bool { return !containsOp(evict, o) })
+		if len(remaining) < l.limit {
+			continue
+		}
+
+		candidate := findEvictionCandidate(op, l.ops, pool, evict, baseFee)
+		if candidate == nil {
+			return nil, newCapacityError(l.name, l.limit)
+		}
+		evict = append(evict, candidate)
+	}
+	return evict, nil
+}
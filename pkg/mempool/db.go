@@ -14,6 +14,8 @@ var (
 	keyPrefix = dbutils.JoinValues("mempool")
 )
 
+// getUniqueKey returns the DB key for a UserOperation. The full nonce is used so that ops from the same
+// sender with the same sequence under different nonce keys do not collide.
 func getUniqueKey(entryPoint common.Address, sender common.Address, nonce *big.Int) []byte {
 	return []byte(
 		dbutils.JoinValues(keyPrefix, entryPoint.String(), sender.String(), nonce.String()),
@@ -3,16 +3,28 @@
 package mempool
 
 import (
+	"math/big"
+	"sync"
+	"time"
+
 	badger "github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // Mempool provides read and write access to a pool of pending UserOperations which have passed all Client
 // checks.
 type Mempool struct {
-	db    *badger.DB
-	queue *userOpQueues
+	db         *badger.DB
+	queue      *userOpQueues
+	mu         sync.Mutex
+	capacity   Capacity
+	getBaseFee gasprice.GetBaseFeeFunc
+	getNonce   GetNonceFunc
+	nonces     map[nonceLane]uint64
+	addedAt    map[string]time.Time
+	loadedAt   time.Time
 }
 
 // New creates an instance of a mempool that uses an embedded DB to persist and load UserOperations from disk
@@ -24,7 +36,34 @@ func New(db *badger.DB) (*Mempool, error) {
 		return nil, err
 	}
 
-	return &Mempool{db, queue}, nil
+	return &Mempool{
+		db:         db,
+		queue:      queue,
+		getBaseFee: gasprice.NoopGetBaseFeeFunc(),
+		getNonce:   NoopGetNonceFunc(),
+		nonces:     make(map[nonceLane]uint64),
+		addedAt:    make(map[string]time.Time),
+		loadedAt:   time.Now(),
+	}, nil
+}
+
+// SetCapacity defines the limits on the number of UserOperations held in the mempool for each EntryPoint.
+// Once a limit is reached, incoming UserOperations must have a higher effective gas price than an existing
+// one in order to evict it.
+func (m *Mempool) SetCapacity(c Capacity) {
+	m.capacity = c
+}
+
+// SetGetBaseFeeFunc defines the function used to get the basefee when comparing the effective gas price of
+// UserOperations for eviction.
+func (m *Mempool) SetGetBaseFeeFunc(fn gasprice.GetBaseFeeFunc) {
+	m.getBaseFee = fn
+}
+
+// SetGetNonceFunc defines the function used to get the on-chain nonce of a sender. This is used to determine
+// which UserOperations are ready to be bundled and which are queued behind a nonce gap.
+func (m *Mempool) SetGetNonceFunc(fn GetNonceFunc) {
+	m.getNonce = fn
 }
 
 // GetOps returns all the UserOperations associated with an EntryPoint and Sender address.
@@ -33,27 +72,78 @@ func (m *Mempool) GetOps(entryPoint common.Address, sender common.Address) ([]*u
 	return ops, nil
 }
 
+// GetOpsByPaymaster returns all the UserOperations associated with an EntryPoint that are sponsored by the
+// given paymaster.
+func (m *Mempool) GetOpsByPaymaster(
+	entryPoint common.Address,
+	paymaster common.Address,
+) ([]*userop.UserOperation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ops := []*userop.UserOperation{}
+	for _, op := range m.queue.All(entryPoint) {
+		if op.GetPaymaster() == paymaster {
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
 // AddOp adds a UserOperation to the mempool or replace an existing one with the same EntryPoint, Sender, and
-// Nonce values.
-func (m *Mempool) AddOp(entryPoint common.Address, op *userop.UserOperation) error {
+// Nonce values. If the mempool is at capacity, the lowest priced UserOperations are evicted to make room and
+// returned to the caller.
+func (m *Mempool) AddOp(entryPoint common.Address, op *userop.UserOperation) ([]*userop.UserOperation, error) {
 	data, err := op.MarshalJSON()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// The basefee is only required to compare ops for eviction. It is fetched without holding the lock so
+	// that other mempool calls are not blocked on the RPC.
+	var bf *big.Int
+	if isAtCapacity(m.capacity, m.queue.All(entryPoint), op) {
+		m.mu.Unlock()
+		bf, err = m.getBaseFee()
+		m.mu.Lock()
+		if err != nil {
+			return nil, err
+		}
+	}
+	evict, err := getOpsToEvict(m.capacity, m.queue.All(entryPoint), op, bf)
+	if err != nil {
+		return nil, err
 	}
 
 	err = m.db.Update(func(txn *badger.Txn) error {
+		for _, e := range evict {
+			if err := txn.Delete(getUniqueKey(entryPoint, e.Sender, e.Nonce)); err != nil {
+				return err
+			}
+		}
 		return txn.Set(getUniqueKey(entryPoint, op.Sender, op.Nonce), data)
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	m.queue.RemoveOps(entryPoint, evict...)
 	m.queue.AddOp(entryPoint, op)
-	return nil
+	for _, e := range evict {
+		delete(m.addedAt, string(getUniqueKey(entryPoint, e.Sender, e.Nonce)))
+	}
+	m.addedAt[string(getUniqueKey(entryPoint, op.Sender, op.Nonce))] = time.Now()
+	return evict, nil
 }
 
 // RemoveOps removes a list of UserOperations from the mempool by EntryPoint, Sender, and Nonce values.
 func (m *Mempool) RemoveOps(entryPoint common.Address, ops ...*userop.UserOperation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	err := m.db.Update(func(txn *badger.Txn) error {
 		for _, op := range ops {
 			err := txn.Delete(getUniqueKey(entryPoint, op.Sender, op.Nonce))
@@ -69,20 +159,59 @@ func (m *Mempool) RemoveOps(entryPoint common.Address, ops ...*userop.UserOperat
 	}
 
 	m.queue.RemoveOps(entryPoint, ops...)
+	for _, op := range ops {
+		delete(m.addedAt, string(getUniqueKey(entryPoint, op.Sender, op.Nonce)))
+	}
 	return nil
 }
 
-// Dump will return a list of UserOperations from the mempool by EntryPoint in the order it arrived.
+// GetAddedAt returns the time a UserOperation was added to the mempool. Ops that were loaded from disk are
+// considered to have been added when the mempool was initialized.
+func (m *Mempool) GetAddedAt(entryPoint common.Address, op *userop.UserOperation) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if at, ok := m.addedAt[string(getUniqueKey(entryPoint, op.Sender, op.Nonce))]; ok {
+		return at
+	}
+	return m.loadedAt
+}
+
+// Dump will return a list of UserOperations from the mempool by EntryPoint in the order it arrived. Only
+// UserOperations that are ready to be bundled are returned. Ops queued behind a nonce gap are excluded until
+// their predecessors land on-chain.
 func (m *Mempool) Dump(entryPoint common.Address) ([]*userop.UserOperation, error) {
+	ready, _, err := m.partition(entryPoint)
+	return ready, err
+}
+
+// DumpQueued will return a list of UserOperations from the mempool by EntryPoint in the order it arrived
+// that are queued behind a nonce gap.
+func (m *Mempool) DumpQueued(entryPoint common.Address) ([]*userop.UserOperation, error) {
+	_, queued, err := m.partition(entryPoint)
+	return queued, err
+}
+
+// DumpAll will return every UserOperation in the mempool by EntryPoint in the order it arrived, including
+// ones that are queued behind a nonce gap.
+func (m *Mempool) DumpAll(entryPoint common.Address) ([]*userop.UserOperation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	return m.queue.All(entryPoint), nil
 }
 
 // Clear will clear the entire embedded db and reset it to a clean state.
 func (m *Mempool) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if err := m.db.DropAll(); err != nil {
 		return err
 	}
 	m.queue = newUserOpQueue()
+	m.nonces = make(map[nonceLane]uint64)
+	m.addedAt = make(map[string]time.Time)
 
 	return nil
 }
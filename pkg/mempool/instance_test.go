@@ -6,6 +6,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // TestAddOpToMempool verifies that a UserOperation can be added to the mempool and later retrieved without
@@ -17,7 +18,7 @@ func TestAddOpToMempool(t *testing.T) {
 	ep := testutils.ValidAddress1
 	op := testutils.MockValidInitUserOp()
 
-	if err := mem.AddOp(ep, op); err != nil {
+	if _, err := mem.AddOp(ep, op); err != nil {
 		t.Fatalf("got %v, want nil", err)
 	}
 
@@ -45,10 +46,10 @@ func TestReplaceOpInMempool(t *testing.T) {
 	op2 := testutils.MockValidInitUserOp()
 	op2.MaxPriorityFeePerGas = big.NewInt(0).Add(op1.MaxPriorityFeePerGas, common.Big1)
 
-	if err := mem.AddOp(ep, op1); err != nil {
+	if _, err := mem.AddOp(ep, op1); err != nil {
 		t.Fatalf("got %v, want nil", err)
 	}
-	if err := mem.AddOp(ep, op2); err != nil {
+	if _, err := mem.AddOp(ep, op2); err != nil {
 		t.Fatalf("got %v, want nil", err)
 	}
 
@@ -73,7 +74,7 @@ func TestRemoveOpsFromMempool(t *testing.T) {
 	ep := testutils.ValidAddress1
 	op := testutils.MockValidInitUserOp()
 
-	if err := mem.AddOp(ep, op); err != nil {
+	if _, err := mem.AddOp(ep, op); err != nil {
 		t.Fatalf("got %v, want nil", err)
 	}
 
@@ -112,13 +113,13 @@ func TestDumpFromMempool(t *testing.T) {
 	op3.MaxFeePerGas = big.NewInt(6)
 	op3.MaxPriorityFeePerGas = big.NewInt(1)
 
-	if err := mem.AddOp(ep, op1); err != nil {
+	if _, err := mem.AddOp(ep, op1); err != nil {
 		t.Fatalf("got %v, want nil", err)
 	}
-	if err := mem.AddOp(ep, op2); err != nil {
+	if _, err := mem.AddOp(ep, op2); err != nil {
 		t.Fatalf("got %v, want nil", err)
 	}
-	if err := mem.AddOp(ep, op3); err != nil {
+	if _, err := mem.AddOp(ep, op3); err != nil {
 		t.Fatalf("got %v, want nil", err)
 	}
 
@@ -147,10 +148,10 @@ func TestNewMempoolLoadsFromDisk(t *testing.T) {
 	op2.Nonce = big.NewInt(0).Add(op1.Nonce, common.Big1)
 	op2.MaxPriorityFeePerGas = big.NewInt(0).Add(op1.MaxPriorityFeePerGas, common.Big1)
 
-	if err := mem1.AddOp(ep, op1); err != nil {
+	if _, err := mem1.AddOp(ep, op1); err != nil {
 		t.Fatalf("got %v, want nil", err)
 	}
-	if err := mem1.AddOp(ep, op2); err != nil {
+	if _, err := mem1.AddOp(ep, op2); err != nil {
 		t.Fatalf("got %v, want nil", err)
 	}
 	if err := mem1.RemoveOps(ep, op1); err != nil {
@@ -170,3 +171,336 @@ func TestNewMempoolLoadsFromDisk(t *testing.T) {
 		t.Fatalf("ops not equal: %s", testutils.GetOpsDiff(op2, memOps[0]))
 	}
 }
+
+// TestAddOpEvictsLowestGasPrice verifies that a UserOperation with a higher gas price will evict the lowest
+// priced UserOperation when the mempool is full.
+func TestAddOpEvictsLowestGasPrice(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetCapacity(Capacity{MaxPoolSize: 2})
+	ep := testutils.ValidAddress1
+
+	op1 := testutils.MockValidInitUserOp()
+	op1.MaxPriorityFeePerGas = big.NewInt(2)
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	op2.MaxPriorityFeePerGas = big.NewInt(1)
+	op3 := testutils.MockValidInitUserOp()
+	op3.Sender = testutils.ValidAddress3
+	op3.MaxPriorityFeePerGas = big.NewInt(3)
+
+	for _, op := range []*userop.UserOperation{op1, op2, op3} {
+		if _, err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	if memOps, err := mem.Dump(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(memOps) != 2 {
+		t.Fatalf("got length %d, want 2", len(memOps))
+	} else if !testutils.IsOpsEqual(memOps[0], op1) || !testutils.IsOpsEqual(memOps[1], op3) {
+		t.Fatal("incorrect eviction: lowest priced op not removed")
+	}
+}
+
+// TestAddOpReturnsEvictedOps verifies that the basefee is only fetched once the mempool is at capacity and
+// that any evicted UserOperations are returned to the caller.
+func TestAddOpReturnsEvictedOps(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetCapacity(Capacity{MaxPoolSize: 1})
+	calls := 0
+	mem.SetGetBaseFeeFunc(func() (*big.Int, error) {
+		calls++
+		return big.NewInt(1), nil
+	})
+	ep := testutils.ValidAddress1
+
+	op1 := testutils.MockValidInitUserOp()
+	op1.MaxPriorityFeePerGas = big.NewInt(1)
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	op2.MaxPriorityFeePerGas = big.NewInt(2)
+
+	if evicted, err := mem.AddOp(ep, op1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(evicted) != 0 {
+		t.Fatalf("got %d evicted ops, want 0", len(evicted))
+	} else if calls != 0 {
+		t.Fatalf("got %d basefee calls, want 0", calls)
+	}
+
+	if evicted, err := mem.AddOp(ep, op2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(evicted) != 1 || !testutils.IsOpsEqual(evicted[0], op1) {
+		t.Fatal("got incorrect evicted ops, want op1")
+	} else if calls != 1 {
+		t.Fatalf("got %d basefee calls, want 1", calls)
+	}
+}
+
+// TestAddOpRejectedWhenFull verifies that a UserOperation is rejected when the mempool is full and it is not
+// priced higher than any existing UserOperation.
+func TestAddOpRejectedWhenFull(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetCapacity(Capacity{MaxPoolSize: 1})
+	ep := testutils.ValidAddress1
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+
+	if _, err := mem.AddOp(ep, op1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if _, err := mem.AddOp(ep, op2); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestAddOpEvictsByPaymasterLimit verifies that the per-paymaster limit only evicts UserOperations that use
+// the same paymaster.
+func TestAddOpEvictsByPaymasterLimit(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetCapacity(Capacity{MaxOpsPerPaymaster: 1})
+	ep := testutils.ValidAddress1
+
+	op1 := testutils.MockValidInitUserOp()
+	op1.MaxPriorityFeePerGas = big.NewInt(1)
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	op2.PaymasterAndData = testutils.ValidAddress3.Bytes()
+	op2.MaxPriorityFeePerGas = big.NewInt(2)
+	op3 := testutils.MockValidInitUserOp()
+	op3.Sender = testutils.ValidAddress3
+	op3.PaymasterAndData = testutils.ValidAddress3.Bytes()
+	op3.MaxPriorityFeePerGas = big.NewInt(3)
+
+	for _, op := range []*userop.UserOperation{op1, op2, op3} {
+		if _, err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	if memOps, err := mem.Dump(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(memOps) != 2 {
+		t.Fatalf("got length %d, want 2", len(memOps))
+	} else if !testutils.IsOpsEqual(memOps[0], op1) || !testutils.IsOpsEqual(memOps[1], op3) {
+		t.Fatal("incorrect eviction: op with same paymaster not removed")
+	}
+}
+
+// TestAddOpEvictionRespectsNonceOrder verifies that only the UserOperation with the highest nonce for a
+// sender can be evicted so that no nonce gaps are left in the mempool.
+func TestAddOpEvictionRespectsNonceOrder(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	mem.SetCapacity(Capacity{MaxPoolSize: 2})
+	ep := testutils.ValidAddress1
+
+	op1 := testutils.MockValidInitUserOp()
+	op1.MaxPriorityFeePerGas = big.NewInt(1)
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(0).Add(op1.Nonce, common.Big1)
+	op2.MaxPriorityFeePerGas = big.NewInt(2)
+	op3 := testutils.MockValidInitUserOp()
+	op3.Sender = testutils.ValidAddress2
+	op3.MaxPriorityFeePerGas = big.NewInt(3)
+
+	for _, op := range []*userop.UserOperation{op1, op2, op3} {
+		if _, err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	memOps, err := mem.GetOps(ep, op1.Sender)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(memOps) != 1 {
+		t.Fatalf("got length %d, want 1", len(memOps))
+	}
+	if !testutils.IsOpsEqual(memOps[0], op1) {
+		t.Fatal("incorrect eviction: op with lowest nonce removed")
+	}
+}
+
+// TestDumpExcludesQueuedOps verifies that a UserOperation behind a nonce gap is not returned from Dump until
+// its predecessor lands on-chain.
+func TestDumpExcludesQueuedOps(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+	onchain := big.NewInt(0)
+	mem.SetGetNonceFunc(func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		return onchain, nil
+	})
+
+	op1 := testutils.MockValidInitUserOp()
+	op1.Nonce = big.NewInt(1)
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(2)
+
+	if _, err := mem.AddOp(ep, op2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ready, err := mem.Dump(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ready) != 0 {
+		t.Fatalf("got length %d, want 0", len(ready))
+	}
+	if queued, err := mem.DumpQueued(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(queued) != 1 {
+		t.Fatalf("got length %d, want 1", len(queued))
+	}
+
+	onchain = big.NewInt(1)
+	if _, err := mem.AddOp(ep, op1); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ready, err := mem.Dump(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ready) != 2 {
+		t.Fatalf("got length %d, want 2", len(ready))
+	}
+}
+
+// TestDumpPromotesQueuedOps verifies that a queued UserOperation is returned from Dump once its predecessor
+// has landed on-chain.
+func TestDumpPromotesQueuedOps(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+	onchain := big.NewInt(0)
+	mem.SetGetNonceFunc(func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		return onchain, nil
+	})
+
+	op := testutils.MockValidInitUserOp()
+	op.Nonce = big.NewInt(1)
+	if _, err := mem.AddOp(ep, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if ready, _ := mem.Dump(ep); len(ready) != 0 {
+		t.Fatalf("got length %d, want 0", len(ready))
+	}
+
+	onchain = big.NewInt(1)
+	if ready, err := mem.Dump(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ready) != 1 {
+		t.Fatalf("got length %d, want 1", len(ready))
+	}
+}
+
+// TestAddOpsWithNonceKeys verifies that a sender can have UserOperations with the same sequence under
+// different nonce keys, including keys that use the full 192 bits.
+func TestAddOpsWithNonceKeys(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+
+	key, _ := big.NewInt(0).SetString("ffffffffffffffffffffffffffffffffffffffffffffffff", 16)
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(0).Lsh(key, 64)
+	op3 := testutils.MockValidInitUserOp()
+	op3.Nonce = big.NewInt(0).Add(op2.Nonce, common.Big1)
+
+	for _, op := range []*userop.UserOperation{op3, op1, op2} {
+		if _, err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	memOps, err := mem.GetOps(ep, op1.Sender)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(memOps) != 3 {
+		t.Fatalf("got length %d, want 3", len(memOps))
+	}
+	if !testutils.IsOpsEqual(memOps[0], op3) {
+		t.Fatal("incorrect order: op with highest sequence out of place")
+	}
+}
+
+// TestGetOpsByPaymasterFromMempool verifies that only UserOperations sponsored by the given paymaster are
+// returned across all senders.
+func TestGetOpsByPaymasterFromMempool(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+	pm := testutils.ValidAddress2
+	op1 := testutils.MockValidInitUserOp()
+	op1.PaymasterAndData = pm.Bytes()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress3
+	op2.PaymasterAndData = pm.Bytes()
+	op3 := testutils.MockValidInitUserOp()
+	op3.Sender = testutils.ValidAddress1
+
+	for _, op := range []*userop.UserOperation{op1, op2, op3} {
+		if _, err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	memOps, err := mem.GetOpsByPaymaster(ep, pm)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(memOps) != 2 {
+		t.Fatalf("got length %d, want 2", len(memOps))
+	}
+	for _, op := range memOps {
+		if op.GetPaymaster() != pm {
+			t.Fatalf("got paymaster %s, want %s", op.GetPaymaster(), pm)
+		}
+	}
+}
+
+// TestNonceCacheIsPruned verifies that cached on-chain nonces are removed once a lane no longer has any
+// UserOperations in the mempool.
+func TestNonceCacheIsPruned(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	mem, _ := New(db)
+	ep := testutils.ValidAddress1
+	mem.SetGetNonceFunc(func(entryPoint, sender common.Address, key *big.Int) (*big.Int, error) {
+		return big.NewInt(0), nil
+	})
+
+	op := testutils.MockValidInitUserOp()
+	if _, err := mem.AddOp(ep, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if _, err := mem.Dump(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(mem.nonces) != 1 {
+		t.Fatalf("got %d cached nonces, want 1", len(mem.nonces))
+	}
+
+	if err := mem.RemoveOps(ep, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if _, err := mem.Dump(ep); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(mem.nonces) != 0 {
+		t.Fatalf("got %d cached nonces, want 0", len(mem.nonces))
+	}
+}
@@ -0,0 +1,146 @@
+package mempool
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// GetNonceFunc provides a general interface for retrieving the current on-chain nonce of a sender for a given
+// nonce key.
+type GetNonceFunc = func(entryPoint common.Address, sender common.Address, key *big.Int) (*big.Int, error)
+
+// NoopGetNonceFunc returns a nil nonce and nil error. With this function every UserOperation in the mempool
+// is considered ready.
+func NoopGetNonceFunc() GetNonceFunc {
+	return func(entryPoint common.Address, sender common.Address, key *big.Int) (*big.Int, error) {
+		return nil, nil
+	}
+}
+
+// GetNonceWithEthClient returns a GetNonceFunc using an eth client.
+func GetNonceWithEthClient(eth *ethclient.Client) GetNonceFunc {
+	return func(entryPoint common.Address, sender common.Address, key *big.Int) (*big.Int, error) {
+		ep, err := entrypoint.NewEntrypoint(entryPoint, eth)
+		if err != nil {
+			return nil, err
+		}
+		return ep.GetNonce(nil, sender, key)
+	}
+}
+
+// nonceLane identifies a sequence of nonces for a sender under a single key.
+type nonceLane struct {
+	entryPoint common.Address
+	sender     common.Address
+	key        string
+}
+
+func getNonceLane(entryPoint common.Address, op *userop.UserOperation) nonceLane {
+	return nonceLane{entryPoint, op.Sender, op.GetNonceKey().String()}
+}
+
+// groupByLane returns the UserOperations for each lane sorted by sequence.
+func groupByLane(entryPoint common.Address, ops []*userop.UserOperation) map[nonceLane][]*userop.UserOperation {
+	lanes := make(map[nonceLane][]*userop.UserOperation)
+	for _, op := range ops {
+		l := getNonceLane(entryPoint, op)
+		lanes[l] = append(lanes[l], op)
+	}
+	for _, laneOps := range lanes {
+		sort.SliceStable(laneOps, func(i, j int) bool {
+			return laneOps[i].GetNonceSequence() < laneOps[j].GetNonceSequence()
+		})
+	}
+	return lanes
+}
+
+// partition splits the UserOperations for an EntryPoint into ones that are ready to be bundled and ones that
+// are queued behind a nonce gap. The mempool lock is not held while fetching on-chain nonces.
+func (m *Mempool) partition(
+	entryPoint common.Address,
+) (ready []*userop.UserOperation, queued []*userop.UserOperation, err error) {
+	m.mu.Lock()
+	ops := m.queue.All(entryPoint)
+	lanes := groupByLane(entryPoint, ops)
+	m.pruneNonces(entryPoint, lanes)
+	fetch := make(map[nonceLane]*userop.UserOperation)
+	for l, laneOps := range lanes {
+		if seq, ok := m.nonces[l]; !ok || laneOps[0].GetNonceSequence() > seq {
+			fetch[l] = laneOps[0]
+		}
+	}
+	m.mu.Unlock()
+
+	fetched := make(map[nonceLane]*big.Int)
+	for l, lowest := range fetch {
+		nonce, err := m.getNonce(l.entryPoint, l.sender, lowest.GetNonceKey())
+		if err != nil {
+			return nil, nil, err
+		}
+		fetched[l] = nonce
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for l, nonce := range fetched {
+		if nonce == nil {
+			delete(m.nonces, l)
+			continue
+		}
+		m.nonces[l] = big.NewInt(0).And(nonce, big.NewInt(0).SetUint64(^uint64(0))).Uint64()
+	}
+	ready, queued = partitionByNonce(ops, lanes, m.nonces)
+	return ready, queued, nil
+}
+
+// pruneNonces removes cached nonces for lanes of the EntryPoint that no longer have any UserOperations in the
+// mempool. This bounds the cache to the number of active lanes. The caller must hold the mempool lock.
+func (m *Mempool) pruneNonces(entryPoint common.Address, lanes map[nonceLane][]*userop.UserOperation) {
+	for l := range m.nonces {
+		if _, ok := lanes[l]; !ok && l.entryPoint == entryPoint {
+			delete(m.nonces, l)
+		}
+	}
+}
+
+// partitionByNonce splits the UserOperations into ones that are ready to be bundled and ones that are queued
+// behind a nonce gap. An op is ready if its sequence matches the on-chain nonce or directly follows another
+// ready op from the same lane. Ops with a sequence lower than the on-chain nonce are also released so that
+// they can be dropped downstream. If the on-chain nonce of a lane is unknown, all its ops are ready. The
+// original order is preserved in both results.
+func partitionByNonce(
+	ops []*userop.UserOperation,
+	lanes map[nonceLane][]*userop.UserOperation,
+	nonces map[nonceLane]uint64,
+) (ready []*userop.UserOperation, queued []*userop.UserOperation) {
+	isReady := make(map[*userop.UserOperation]bool)
+	for l, laneOps := range lanes {
+		expected, ok := nonces[l]
+		for _, op := range laneOps {
+			seq := op.GetNonceSequence()
+			if ok && seq > expected {
+				break
+			}
+			if seq == expected {
+				expected++
+			}
+			isReady[op] = true
+		}
+	}
+
+	ready = []*userop.UserOperation{}
+	queued = []*userop.UserOperation{}
+	for _, op := range ops {
+		if isReady[op] {
+			ready = append(ready, op)
+		} else {
+			queued = append(queued, op)
+		}
+	}
+	return ready, queued
+}
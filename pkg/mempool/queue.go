@@ -44,7 +44,9 @@ func (q *userOpQueues) AddOp(entryPoint common.Address, op *userop.UserOperation
 	key := string(getUniqueKey(entryPoint, op.Sender, op.Nonce))
 
 	eps.all.AddOrUpdate(key, sortedset.SCORE(eps.all.GetCount()), op)
-	sss.AddOrUpdate(key, sortedset.SCORE(op.Nonce.Int64()), op)
+	// Senders can have parallel lanes of ops under different nonce keys. Only the sequence is used for the
+	// score since the full nonce may overflow an int64.
+	sss.AddOrUpdate(key, sortedset.SCORE(op.GetNonceSequence()), op)
 }
 
 func (q *userOpQueues) GetOps(entryPoint common.Address, sender common.Address) []*userop.UserOperation {
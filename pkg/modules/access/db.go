@@ -0,0 +1,59 @@
+package access
+
+import (
+	"errors"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+)
+
+var (
+	keyPrefix = dbutils.JoinValues("access")
+)
+
+func getRuleKey(entity common.Address) []byte {
+	return []byte(dbutils.JoinValues(keyPrefix, entity.String()))
+}
+
+func getRule(txn *badger.Txn, entity common.Address) (Rule, error) {
+	item, err := txn.Get(getRuleKey(entity))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return None, nil
+	} else if err != nil {
+		return None, err
+	}
+
+	var rule Rule
+	err = item.Value(func(val []byte) error {
+		rule = Rule(val)
+		return nil
+	})
+	return rule, err
+}
+
+func setRule(txn *badger.Txn, entity common.Address, rule Rule) error {
+	if rule == None {
+		return txn.Delete(getRuleKey(entity))
+	}
+	return txn.Set(getRuleKey(entity), []byte(rule))
+}
+
+func getAllRules(txn *badger.Txn) (map[common.Address]Rule, error) {
+	it := txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	rules := make(map[common.Address]Rule)
+	prefix := []byte(keyPrefix)
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		key := dbutils.SplitValues(string(it.Item().Key()))
+		err := it.Item().Value(func(val []byte) error {
+			rules[common.HexToAddress(key[len(key)-1])] = Rule(val)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
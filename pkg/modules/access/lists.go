@@ -0,0 +1,153 @@
+// Package access implements a persisted allowlist and denylist for senders, paymasters, and factories.
+// Allowlisted entities are exempt from throttling and banning by reputation while denylisted entities are
+// always rejected.
+package access
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Rule is the access rule applied to an entity.
+type Rule string
+
+const (
+	None  Rule = ""
+	Allow Rule = "allow"
+	Deny  Rule = "deny"
+)
+
+// Dump is every entity currently on the allowlist and denylist.
+type Dump struct {
+	Allowed []common.Address `json:"allowed"`
+	Denied  []common.Address `json:"denied"`
+}
+
+// Lists provides Client and Bundler modules to enforce access rules on the entities of every
+// UserOperation.
+type Lists struct {
+	db *badger.DB
+}
+
+// New returns an instance of Lists that persists access rules to the given DB.
+func New(db *badger.DB) *Lists {
+	return &Lists{db}
+}
+
+// Set applies a rule to the given entities. An entity can only be on one list at a time and setting the rule
+// to None removes it from both.
+func (l *Lists) Set(rule Rule, entities ...common.Address) error {
+	if rule != None && rule != Allow && rule != Deny {
+		return fmt.Errorf("access: unrecognized rule %s", rule)
+	}
+
+	return l.db.Update(func(txn *badger.Txn) error {
+		for _, entity := range entities {
+			if err := setRule(txn, entity, rule); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetRule returns the rule applied to an entity. None is returned if the entity is not on either list.
+func (l *Lists) GetRule(entity common.Address) (Rule, error) {
+	var rule Rule
+	err := l.db.View(func(txn *badger.Txn) error {
+		var err error
+		rule, err = getRule(txn, entity)
+		return err
+	})
+	return rule, err
+}
+
+// IsAllowed returns true if the entity is on the allowlist.
+func (l *Lists) IsAllowed(entity common.Address) (bool, error) {
+	rule, err := l.GetRule(entity)
+	return rule == Allow, err
+}
+
+// Dump returns every entity on the allowlist and denylist.
+func (l *Lists) Dump() (*Dump, error) {
+	d := &Dump{Allowed: []common.Address{}, Denied: []common.Address{}}
+	err := l.db.View(func(txn *badger.Txn) error {
+		rules, err := getAllRules(txn)
+		if err != nil {
+			return err
+		}
+
+		for entity, rule := range rules {
+			if rule == Allow {
+				d.Allowed = append(d.Allowed, entity)
+			} else if rule == Deny {
+				d.Denied = append(d.Denied, entity)
+			}
+		}
+		return nil
+	})
+	return d, err
+}
+
+// getDenied returns the first sender, factory, or paymaster of an op that is on the denylist. The zero
+// address is returned if there are none.
+func (l *Lists) getDenied(op *userop.UserOperation) (common.Address, error) {
+	var denied common.Address
+	err := l.db.View(func(txn *badger.Txn) error {
+		for _, entity := range []common.Address{op.Sender, op.GetFactory(), op.GetPaymaster()} {
+			if entity == common.HexToAddress("0x") {
+				continue
+			}
+
+			rule, err := getRule(txn, entity)
+			if err != nil {
+				return err
+			} else if rule == Deny {
+				denied = entity
+				return nil
+			}
+		}
+		return nil
+	})
+	return denied, err
+}
+
+// CheckDenied returns a UserOpHandler that is used by the Client to reject userOps with a sender, factory,
+// or paymaster on the denylist. This should be used before SimulateOp.
+func (l *Lists) CheckDenied() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		denied, err := l.getDenied(ctx.UserOp)
+		if err != nil {
+			return err
+		} else if denied != common.HexToAddress("0x") {
+			return errors.NewRPCError(
+				errors.BANNED_OR_THROTTLED_PAYMASTER,
+				fmt.Sprintf("entity: %s is denied", denied),
+				denied,
+			)
+		}
+		return nil
+	}
+}
+
+// DropDenied returns a BatchHandler that is used by the Bundler to drop userOps from the mempool that have a
+// sender, factory, or paymaster which was added to the denylist after the op was received.
+func (l *Lists) DropDenied() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		end := len(ctx.Batch) - 1
+		for i := end; i >= 0; i-- {
+			denied, err := l.getDenied(ctx.Batch[i])
+			if err != nil {
+				return err
+			} else if denied != common.HexToAddress("0x") {
+				ctx.MarkOpIndexForRemoval(i, fmt.Sprintf("entity: %s is denied", denied))
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,111 @@
+package access
+
+import (
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestSetRule calls (*Lists).Set and verifies that an entity is only on one list at a time and can be
+// removed from both.
+func TestSetRule(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	l := New(db)
+
+	entity := testutils.ValidAddress1
+	for _, rule := range []Rule{Allow, Deny, None} {
+		if err := l.Set(rule, entity); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if got, err := l.GetRule(entity); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		} else if got != rule {
+			t.Fatalf("got rule %q, want %q", got, rule)
+		}
+	}
+
+	if err := l.Set(Rule("unknown"), entity); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestDump calls (*Lists).Dump and verifies that entities are returned on the correct list.
+func TestDump(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	l := New(db)
+
+	if err := l.Set(Allow, testutils.ValidAddress1, testutils.ValidAddress2); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := l.Set(Deny, testutils.ValidAddress3); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	d, err := l.Dump()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(d.Allowed) != 2 {
+		t.Fatalf("got %d allowed, want 2", len(d.Allowed))
+	} else if len(d.Denied) != 1 || d.Denied[0] != testutils.ValidAddress3 {
+		t.Fatalf("got denied %v, want [%s]", d.Denied, testutils.ValidAddress3)
+	}
+}
+
+// TestCheckDenied calls (*Lists).CheckDenied on an op with a denied factory. Expects error.
+func TestCheckDenied(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	l := New(db)
+
+	op := testutils.MockValidInitUserOp()
+	ctx := modules.NewUserOpHandlerContext(op, nil, testutils.ValidAddress1, testutils.ChainID)
+	if err := l.CheckDenied()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if err := l.Set(Deny, op.GetFactory()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := l.CheckDenied()(ctx); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestDropDenied calls (*Lists).DropDenied and verifies that only ops with a denied entity are marked for
+// removal.
+func TestDropDenied(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	l := New(db)
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Sender = testutils.ValidAddress2
+	op2.PaymasterAndData = testutils.ValidAddress3.Bytes()
+	if err := l.Set(Deny, testutils.ValidAddress3); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	if err := l.DropDenied()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(ctx.Batch) != 1 || !testutils.IsOpsEqual(ctx.Batch[0], op1) {
+		t.Fatalf("got batch length %d, want op1 only", len(ctx.Batch))
+	}
+	if reason, ok := ctx.GetRemovalReason(op2); !ok || reason == "" {
+		t.Fatal("got no removal reason, want reason")
+	}
+}
@@ -3,17 +3,39 @@ package batch
 import (
 	"sort"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
-// SortByNonce returns a BatchHandlerFunc that ensures ops with same sender is ordered by ascending nonce
-// regardless of gas price.
+type nonceLane struct {
+	sender common.Address
+	key    string
+}
+
+// SortByNonce returns a BatchHandlerFunc that ensures ops with same sender and nonce key is ordered by
+// ascending sequence regardless of gas price. Each op keeps a slot in the batch that belongs to its sender
+// and nonce key, so ops from different senders or nonce keys keep their relative order.
 func SortByNonce() modules.BatchHandlerFunc {
 	return func(ctx *modules.BatchHandlerCtx) error {
-		sort.SliceStable(ctx.Batch, func(i, j int) bool {
-			return ctx.Batch[i].Sender == ctx.Batch[j].Sender &&
-				ctx.Batch[i].Nonce.Cmp(ctx.Batch[j].Nonce) == -1
-		})
+		lanes := make(map[nonceLane][]*userop.UserOperation)
+		slots := make(map[nonceLane][]int)
+		for i, op := range ctx.Batch {
+			l := nonceLane{op.Sender, op.GetNonceKey().String()}
+			lanes[l] = append(lanes[l], op)
+			slots[l] = append(slots[l], i)
+		}
+
+		batch := make([]*userop.UserOperation, len(ctx.Batch))
+		for l, ops := range lanes {
+			sort.SliceStable(ops, func(i, j int) bool {
+				return ops[i].GetNonceSequence() < ops[j].GetNonceSequence()
+			})
+			for i, slot := range slots[l] {
+				batch[slot] = ops[i]
+			}
+		}
+		ctx.Batch = batch
 
 		return nil
 	}
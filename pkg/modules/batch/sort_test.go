@@ -0,0 +1,54 @@
+package batch
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func mockOp(sender int64, key int64, seq int64) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = testutils.ValidAddress1
+	if sender == 2 {
+		op.Sender = testutils.ValidAddress2
+	}
+	op.Nonce = big.NewInt(0).Add(big.NewInt(0).Lsh(big.NewInt(key), 64), big.NewInt(seq))
+	return op
+}
+
+// TestSortByNonceInterleavedSenders calls batch.SortByNonce on a batch with interleaved senders and nonce
+// keys in reverse sequence order. Expects each lane in ascending sequence while every slot keeps its lane.
+func TestSortByNonceInterleavedSenders(t *testing.T) {
+	a2 := mockOp(1, 0, 2)
+	b1 := mockOp(2, 0, 1)
+	a1 := mockOp(1, 0, 1)
+	k1 := mockOp(1, 1, 0)
+	b0 := mockOp(2, 0, 0)
+	a0 := mockOp(1, 0, 0)
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{a2, b1, a1, k1, b0, a0},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+
+	if err := SortByNonce()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	want := []*userop.UserOperation{a0, b0, a1, k1, b1, a2}
+	if len(ctx.Batch) != len(want) {
+		t.Fatalf("got length %d, want %d", len(ctx.Batch), len(want))
+	}
+	for i, op := range ctx.Batch {
+		if op != want[i] {
+			t.Fatalf("got sender %s nonce %s at index %d, want sender %s nonce %s",
+				op.Sender, op.Nonce, i, want[i].Sender, want[i].Nonce)
+		}
+	}
+}
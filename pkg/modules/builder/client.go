@@ -12,6 +12,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/metachris/flashbotsrpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/aggregator"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
@@ -25,6 +26,7 @@ type BuilderClient struct {
 	rpc               *flashbotsrpc.FlashbotsRPC
 	beneficiary       common.Address
 	blocksInTheFuture int
+	aggregate         aggregator.AggregateSignaturesFunc
 }
 
 // New returns an instance of a BuilderClient with modules to send UserOperation bundles via the mev-boost
@@ -42,9 +44,17 @@ func New(
 		rpc:               fb,
 		beneficiary:       beneficiary,
 		blocksInTheFuture: blocksInTheFuture,
+		aggregate:         aggregator.NoopAggregateSignaturesFunc(),
 	}
 }
 
+// SetAggregateSignaturesFunc defines the function used to combine the signatures of UserOperations that
+// share a signature aggregator. The default implementation returns an error for any batch that requires
+// aggregation.
+func (b *BuilderClient) SetAggregateSignaturesFunc(fn aggregator.AggregateSignaturesFunc) {
+	b.aggregate = fn
+}
+
 // SendUserOperation returns a BatchHandler that is used by the Bundler to send batches to a block builder
 // that supports eth_callBundle and eth_sendBundle.
 func (b *BuilderClient) SendUserOperation() modules.BatchHandlerFunc {
@@ -62,17 +72,32 @@ func (b *BuilderClient) SendUserOperation() modules.BatchHandlerFunc {
 			GasLimit:    0,
 		}
 		for len(ctx.Batch) > 0 {
+			opts.Batch = ctx.Batch
+			opts.OpsPerAggregator = nil
+			if ctx.HasAggregators() {
+				opa, err := aggregator.GetOpsPerAggregator(ctx, b.aggregate)
+				if errors.Is(err, aggregator.ErrReverted) {
+					continue
+				} else if err != nil {
+					return err
+				}
+				opts.OpsPerAggregator = opa
+			}
+
 			est, revert, err := transaction.EstimateHandleOpsGas(&opts)
 
 			if err != nil {
 				return err
 			} else if revert != nil {
-				ctx.MarkOpIndexForRemoval(revert.OpIndex)
+				ctx.MarkOpIndexForRemoval(revert.OpIndex, revert.Reason)
 			} else {
 				opts.GasLimit = est
 				break
 			}
 		}
+		if len(ctx.Batch) == 0 {
+			return nil
+		}
 
 		// Calculate the max base fee up to a future block number.
 		bn, err := b.eth.BlockNumber(context.Background())
@@ -101,8 +126,8 @@ func (b *BuilderClient) SendUserOperation() modules.BatchHandlerFunc {
 			BlockNumber:      hexutil.EncodeBig(NxtBlkNum),
 			StateBlockNumber: "latest",
 		}
-		sim, err := b.rpc.FlashbotsCallBundle(b.eoa.PrivateKey, callBundleArgs)
-		if err != nil {
+		var sim flashbotsrpc.FlashbotsCallBundleResponse
+		if err := b.callWithSignature("eth_callBundle", &sim, callBundleArgs); err != nil {
 			return err
 		}
 		if len(sim.Results) != 1 {
@@ -121,8 +146,8 @@ func (b *BuilderClient) SendUserOperation() modules.BatchHandlerFunc {
 				Txs:         []string{rawTx},
 				BlockNumber: hexutil.EncodeBig(futureBlkNum),
 			}
-			_, err := b.rpc.FlashbotsSendBundle(b.eoa.PrivateKey, sendBundleArgs)
-			if err != nil {
+			var res flashbotsrpc.FlashbotsSendBundleResponse
+			if err := b.callWithSignature("eth_sendBundle", &res, sendBundleArgs); err != nil {
 				return err
 			}
 		}
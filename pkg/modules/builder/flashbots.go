@@ -0,0 +1,73 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/metachris/flashbotsrpc"
+)
+
+type rpcRequest struct {
+	ID      int    `json:"id"`
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage        `json:"result"`
+	Error  *flashbotsrpc.RpcError `json:"error"`
+}
+
+// callWithSignature sends a JSON-RPC request to the block builder with an X-Flashbots-Signature header signed
+// by the EOA. This mirrors flashbotsrpc.CallWithFlashbotsSignature without requiring access to a private key.
+func (b *BuilderClient) callWithSignature(method string, target any, params ...any) error {
+	body, err := json.Marshal(rpcRequest{ID: 1, JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	sig, err := b.eoa.SignFlashbotsPayload(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", b.rpc.URL(), bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("X-Flashbots-Signature", sig)
+	for k, v := range b.rpc.Headers {
+		req.Header.Add(k, v)
+	}
+
+	client := &http.Client{Timeout: b.rpc.Timeout}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	// On error, some relays respond with {"error":"message"} instead of a JSON-RPC error.
+	relayErr := new(flashbotsrpc.RelayErrorResponse)
+	if err := json.Unmarshal(data, relayErr); err == nil && relayErr.Error != "" {
+		return fmt.Errorf("%w: %s", flashbotsrpc.ErrRelayErrorResponse, relayErr.Error)
+	}
+
+	resp := new(rpcResponse)
+	if err := json.Unmarshal(data, resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%w: %s", flashbotsrpc.ErrRelayErrorResponse, resp.Error.Message)
+	}
+	return json.Unmarshal(resp.Result, target)
+}
@@ -2,6 +2,7 @@ package checks
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
@@ -11,6 +12,7 @@ import (
 var (
 	keyPrefix        = dbutils.JoinValues("checks")
 	codeHashesPrefix = dbutils.JoinValues(keyPrefix, "codeHashes")
+	aggregatorPrefix = dbutils.JoinValues(keyPrefix, "aggregator")
 )
 
 func getCodeHashesKey(userOpHash common.Hash) []byte {
@@ -54,3 +56,50 @@ func removeSavedCodeHashes(db *badger.DB, userOpHashes ...common.Hash) error {
 		return nil
 	})
 }
+
+func getAggregatorKey(userOpHash common.Hash) []byte {
+	return []byte(dbutils.JoinValues(aggregatorPrefix, userOpHash.String()))
+}
+
+// saveAggregator persists the aggregator of an op along with the sigForUserOp value returned from
+// validateUserOpSignature.
+func saveAggregator(db *badger.DB, userOpHash common.Hash, aggregator common.Address, sigForUserOp []byte) error {
+	return db.Update(func(txn *badger.Txn) error {
+		return txn.Set(getAggregatorKey(userOpHash), append(aggregator.Bytes(), sigForUserOp...))
+	})
+}
+
+func getSavedAggregator(db *badger.DB, userOpHash common.Hash) (common.Address, []byte, error) {
+	var aggregator common.Address
+	var sigForUserOp []byte
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(getAggregatorKey(userOpHash))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			if len(val) < common.AddressLength {
+				return fmt.Errorf("aggregator: invalid saved value length %d", len(val))
+			}
+			aggregator = common.BytesToAddress(val[:common.AddressLength])
+			sigForUserOp = append([]byte{}, val[common.AddressLength:]...)
+			return nil
+		})
+	})
+
+	return aggregator, sigForUserOp, err
+}
+
+func removeSavedAggregators(db *badger.DB, userOpHashes ...common.Hash) error {
+	return db.Update(func(txn *badger.Txn) error {
+		for _, userOpHash := range userOpHashes {
+			if err := txn.Delete(getAggregatorKey(userOpHash)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
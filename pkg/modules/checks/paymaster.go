@@ -11,8 +11,14 @@ import (
 // address that
 //
 //  1. currently has nonempty code on chain
-//  2. has a sufficient deposit to pay for the UserOperation
-func ValidatePaymasterAndData(op *userop.UserOperation, gc GetCodeFunc, gs GetStakeFunc) error {
+//  2. has a sufficient deposit to pay for the UserOperation along with every pending UserOperation in the
+//     mempool that it sponsors
+func ValidatePaymasterAndData(
+	op *userop.UserOperation,
+	pmOps []*userop.UserOperation,
+	gc GetCodeFunc,
+	gs GetStakeFunc,
+) error {
 	if len(op.PaymasterAndData) == 0 {
 		return nil
 	}
@@ -37,6 +43,9 @@ func ValidatePaymasterAndData(op *userop.UserOperation, gc GetCodeFunc, gs GetSt
 	if dep.Deposit.Cmp(op.GetMaxPrefund()) < 0 {
 		return errors.New("paymaster: not enough deposit to cover max prefund")
 	}
+	if dep.Deposit.Cmp(sumMaxPrefund(op, pmOps)) < 0 {
+		return errors.New("paymaster: not enough deposit to cover max prefund of all pending ops")
+	}
 
 	return nil
 }
@@ -1,16 +1,18 @@
 package checks
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // TestNilPaymasterAndData calls checks.ValidatePaymasterAndData with no paymaster set. Expects nil.
 func TestNilPaymasterAndData(t *testing.T) {
 	op := testutils.MockValidInitUserOp()
 	op.PaymasterAndData = []byte{}
-	err := ValidatePaymasterAndData(op, testutils.MockGetCodeZero, testutils.MockGetNotStakeZeroDeposit)
+	err := ValidatePaymasterAndData(op, nil, testutils.MockGetCodeZero, testutils.MockGetNotStakeZeroDeposit)
 
 	if err != nil {
 		t.Fatalf("got err %v, want nil", err)
@@ -21,7 +23,7 @@ func TestNilPaymasterAndData(t *testing.T) {
 func TestBadPaymasterAndData(t *testing.T) {
 	op := testutils.MockValidInitUserOp()
 	op.PaymasterAndData = []byte("1234")
-	err := ValidatePaymasterAndData(op, testutils.MockGetCodeZero, testutils.MockGetNotStakeZeroDeposit)
+	err := ValidatePaymasterAndData(op, nil, testutils.MockGetCodeZero, testutils.MockGetNotStakeZeroDeposit)
 
 	if err == nil {
 		t.Fatal("got nil, want err")
@@ -33,7 +35,7 @@ func TestBadPaymasterAndData(t *testing.T) {
 func TestZeroByteCodePaymasterAndData(t *testing.T) {
 	op := testutils.MockValidInitUserOp()
 	op.PaymasterAndData = op.Sender.Bytes()
-	err := ValidatePaymasterAndData(op, testutils.MockGetCodeZero, testutils.MockGetNotStakeZeroDeposit)
+	err := ValidatePaymasterAndData(op, nil, testutils.MockGetCodeZero, testutils.MockGetNotStakeZeroDeposit)
 
 	if err == nil {
 		t.Fatal("got nil, want err")
@@ -45,7 +47,7 @@ func TestZeroByteCodePaymasterAndData(t *testing.T) {
 func TestNonStakedZeroDepositPaymasterAndData(t *testing.T) {
 	op := testutils.MockValidInitUserOp()
 	op.PaymasterAndData = op.Sender.Bytes()
-	err := ValidatePaymasterAndData(op, testutils.MockGetCode, testutils.MockGetNotStakeZeroDeposit)
+	err := ValidatePaymasterAndData(op, nil, testutils.MockGetCode, testutils.MockGetNotStakeZeroDeposit)
 
 	if err == nil {
 		t.Fatal("got nil, want err")
@@ -57,7 +59,7 @@ func TestNonStakedZeroDepositPaymasterAndData(t *testing.T) {
 func TestZeroDepositPaymasterAndData(t *testing.T) {
 	op := testutils.MockValidInitUserOp()
 	op.PaymasterAndData = op.Sender.Bytes()
-	err := ValidatePaymasterAndData(op, testutils.MockGetCode, testutils.MockGetStakeZeroDeposit)
+	err := ValidatePaymasterAndData(op, nil, testutils.MockGetCode, testutils.MockGetStakeZeroDeposit)
 
 	if err == nil {
 		t.Fatal("got nil, want err")
@@ -69,7 +71,7 @@ func TestZeroDepositPaymasterAndData(t *testing.T) {
 func TestNotStakedPaymasterAndData(t *testing.T) {
 	op := testutils.MockValidInitUserOp()
 	op.PaymasterAndData = op.Sender.Bytes()
-	err := ValidatePaymasterAndData(op, testutils.MockGetCode, testutils.MockGetNotStake)
+	err := ValidatePaymasterAndData(op, nil, testutils.MockGetCode, testutils.MockGetNotStake)
 
 	if err != nil {
 		t.Fatalf("got %v, want nil", err)
@@ -81,9 +83,42 @@ func TestNotStakedPaymasterAndData(t *testing.T) {
 func TestPaymasterAndData(t *testing.T) {
 	op := testutils.MockValidInitUserOp()
 	op.PaymasterAndData = op.Sender.Bytes()
-	err := ValidatePaymasterAndData(op, testutils.MockGetCode, testutils.MockGetStake)
+	err := ValidatePaymasterAndData(op, nil, testutils.MockGetCode, testutils.MockGetStake)
 
 	if err != nil {
 		t.Fatalf("got err %v, want nil", err)
 	}
 }
+
+// TestPendingOpsPaymasterAndData calls checks.ValidatePaymasterAndData with paymaster that has sufficient
+// deposit for the op but not for all pending ops it sponsors. Expects error.
+func TestPendingOpsPaymasterAndData(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.PaymasterAndData = op.Sender.Bytes()
+	penOp := testutils.MockValidInitUserOp()
+	penOp.Sender = testutils.ValidAddress1
+	penOp.PaymasterAndData = op.Sender.Bytes()
+	penOp.MaxFeePerGas = big.NewInt(0).Set(testutils.OneETH)
+	pmOps := []*userop.UserOperation{penOp}
+	err := ValidatePaymasterAndData(op, pmOps, testutils.MockGetCode, testutils.MockGetStake)
+
+	if err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestReplacedPendingOpPaymasterAndData calls checks.ValidatePaymasterAndData with an op that replaces a
+// pending op which the paymaster does not have sufficient deposit for. Expects nil.
+func TestReplacedPendingOpPaymasterAndData(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.PaymasterAndData = op.Sender.Bytes()
+	penOp := testutils.MockValidInitUserOp()
+	penOp.PaymasterAndData = op.Sender.Bytes()
+	penOp.MaxFeePerGas = big.NewInt(0).Set(testutils.OneETH)
+	pmOps := []*userop.UserOperation{penOp}
+	err := ValidatePaymasterAndData(op, pmOps, testutils.MockGetCode, testutils.MockGetStake)
+
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
@@ -25,12 +26,15 @@ func calcNewThresholds(cap *big.Int, tip *big.Int) (newCap *big.Int, newTip *big
 // ValidatePendingOps checks the pending UserOperations by the same sender and only passes if:
 //
 //  1. Sender doesn't have another UserOperation already present in the pool.
-//  2. It replaces an existing UserOperation with same nonce and higher fee.
-//  3. Sender is staked and is allowed uncapped UserOperations in the pool.
+//  2. It replaces an existing UserOperation with same nonce key and sequence and higher fee.
+//  3. Sender meets the stake requirement and is allowed uncapped UserOperations in the pool.
+//
+// The limit on UserOperations for an unstaked sender applies across all nonce keys.
 func ValidatePendingOps(
 	op *userop.UserOperation,
 	penOps []*userop.UserOperation,
 	maxOpsForUnstakedSender int,
+	req stake.Requirement,
 	gs GetStakeFunc,
 ) error {
 	dep, err := gs(op.Sender)
@@ -41,7 +45,8 @@ func ValidatePendingOps(
 	if len(penOps) > 0 {
 		var oldOp *userop.UserOperation
 		for _, penOp := range penOps {
-			if op.Nonce.Cmp(penOp.Nonce) == 0 {
+			if op.GetNonceKey().Cmp(penOp.GetNonceKey()) == 0 &&
+				op.GetNonceSequence() == penOp.GetNonceSequence() {
 				oldOp = penOp
 			}
 		}
@@ -55,10 +60,12 @@ func ValidatePendingOps(
 					minPriceBump,
 				)
 			}
-		} else if !dep.Staked && len(penOps) >= maxOpsForUnstakedSender {
+		} else if !req.IsStaked(dep) && len(penOps) >= maxOpsForUnstakedSender {
 			return fmt.Errorf(
-				"pending ops: sender must be staked to have more than %d ops in the mempool",
+				"pending ops: sender must have a %s to have more than %d ops in the mempool: %w",
+				req,
 				maxOpsForUnstakedSender,
+				stake.ErrNotStaked,
 			)
 		}
 	}
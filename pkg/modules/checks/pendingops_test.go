@@ -1,11 +1,13 @@
 package checks
 
 import (
+	"errors"
 	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
@@ -17,6 +19,7 @@ func TestNoPendingOps(t *testing.T) {
 		op,
 		penOps,
 		testutils.MaxOpsForUnstakedSender,
+		stake.Requirement{},
 		testutils.MockGetNotStakeZeroDeposit,
 	)
 
@@ -36,6 +39,7 @@ func TestPendingOpsNotStaked(t *testing.T) {
 		op,
 		penOps,
 		testutils.MaxOpsForUnstakedSender,
+		stake.Requirement{},
 		testutils.MockGetNotStakeZeroDeposit,
 	)
 
@@ -55,6 +59,7 @@ func TestPendingOpsStaked(t *testing.T) {
 		op,
 		penOps,
 		testutils.MaxOpsForUnstakedSender,
+		stake.Requirement{},
 		testutils.MockGetStakeZeroDeposit,
 	)
 
@@ -62,3 +67,46 @@ func TestPendingOpsStaked(t *testing.T) {
 		t.Fatalf("got err %v, want nil", err)
 	}
 }
+
+// TestPendingOpsStakeTooLow calls checks.ValidatePendingOps with pending UserOperations but sender stake is
+// below the minimum requirement. Expect stake.ErrNotStaked.
+func TestPendingOpsStakeTooLow(t *testing.T) {
+	penOp := testutils.MockValidInitUserOp()
+	penOps := []*userop.UserOperation{penOp}
+	op := testutils.MockValidInitUserOp()
+	op.Nonce = big.NewInt(0).Add(penOp.Nonce, common.Big1)
+	err := ValidatePendingOps(
+		op,
+		penOps,
+		testutils.MaxOpsForUnstakedSender,
+		stake.Requirement{MinStake: big.NewInt(0).Mul(testutils.OneETH, common.Big2)},
+		testutils.MockGetStakeZeroDeposit,
+	)
+
+	if !errors.Is(err, stake.ErrNotStaked) {
+		t.Fatalf("got %v, want %v", err, stake.ErrNotStaked)
+	}
+}
+
+// TestPendingOpsDifferentNonceKey calls checks.ValidatePendingOps with a pending UserOperation that has the
+// same sequence under a different nonce key. Expect error since it is not a replacement and the sender is
+// not staked.
+func TestPendingOpsDifferentNonceKey(t *testing.T) {
+	penOp := testutils.MockValidInitUserOp()
+	penOps := []*userop.UserOperation{penOp}
+	op := testutils.MockValidInitUserOp()
+	op.Nonce = big.NewInt(0).Lsh(common.Big1, 64)
+	op.MaxFeePerGas = big.NewInt(0).Mul(penOp.MaxFeePerGas, common.Big2)
+	op.MaxPriorityFeePerGas = big.NewInt(0).Mul(penOp.MaxPriorityFeePerGas, common.Big2)
+	err := ValidatePendingOps(
+		op,
+		penOps,
+		testutils.MaxOpsForUnstakedSender,
+		stake.Requirement{},
+		testutils.MockGetNotStakeZeroDeposit,
+	)
+
+	if err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
@@ -0,0 +1,65 @@
+package checks
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// isReplacedBy returns true if penOp would be replaced by op in the mempool.
+func isReplacedBy(penOp *userop.UserOperation, op *userop.UserOperation) bool {
+	return penOp.Sender == op.Sender && penOp.Nonce.Cmp(op.Nonce) == 0
+}
+
+// sumMaxPrefund returns the max prefund of op along with every pending op that it would not replace.
+func sumMaxPrefund(op *userop.UserOperation, penOps []*userop.UserOperation) *big.Int {
+	sum := op.GetMaxPrefund()
+	for _, penOp := range penOps {
+		if !isReplacedBy(penOp, op) {
+			sum.Add(sum, penOp.GetMaxPrefund())
+		}
+	}
+	return sum
+}
+
+// ValidateSenderPrefund checks that a sender paying for its own gas has enough EntryPoint deposit and balance
+// to cover the max prefund of the UserOperation along with every other pending UserOperation by the same
+// sender that also does not use a paymaster. The check is skipped if there are no other pending
+// UserOperations since simulation already covers a single op.
+func ValidateSenderPrefund(
+	op *userop.UserOperation,
+	penOps []*userop.UserOperation,
+	gs GetStakeFunc,
+	gb GetBalanceFunc,
+) error {
+	if op.GetPaymaster() != common.HexToAddress("0x") {
+		return nil
+	}
+
+	selfPaid := []*userop.UserOperation{}
+	for _, penOp := range penOps {
+		if penOp.GetPaymaster() == common.HexToAddress("0x") && !isReplacedBy(penOp, op) {
+			selfPaid = append(selfPaid, penOp)
+		}
+	}
+	if len(selfPaid) == 0 {
+		return nil
+	}
+
+	dep, err := gs(op.Sender)
+	if err != nil {
+		return err
+	}
+	bal, err := gb(op.Sender)
+	if err != nil {
+		return err
+	}
+
+	funds := big.NewInt(0).Add(dep.Deposit, bal)
+	if funds.Cmp(sumMaxPrefund(op, selfPaid)) < 0 {
+		return errors.New("sender: not enough deposit and balance to cover max prefund of all pending ops")
+	}
+	return nil
+}
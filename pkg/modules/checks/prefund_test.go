@@ -0,0 +1,81 @@
+package checks
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func mockGetBalance(bal *big.Int) GetBalanceFunc {
+	return func(addr common.Address) (*big.Int, error) {
+		return bal, nil
+	}
+}
+
+func mockPendingSelfPaidOp(nonce int64) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Nonce = big.NewInt(nonce)
+	op.MaxFeePerGas = big.NewInt(0).Set(testutils.OneETH)
+	return op
+}
+
+// TestNoPendingOpsSenderPrefund calls checks.ValidateSenderPrefund with no pending ops and zero funds.
+// Expects nil.
+func TestNoPendingOpsSenderPrefund(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	err := ValidateSenderPrefund(
+		op,
+		[]*userop.UserOperation{},
+		testutils.MockGetNotStakeZeroDeposit,
+		mockGetBalance(big.NewInt(0)),
+	)
+
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestInsufficientFundsSenderPrefund calls checks.ValidateSenderPrefund with pending ops that cost more than
+// the sender's deposit and balance. Expects error.
+func TestInsufficientFundsSenderPrefund(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.Nonce = big.NewInt(1)
+	penOps := []*userop.UserOperation{mockPendingSelfPaidOp(0)}
+	err := ValidateSenderPrefund(op, penOps, testutils.MockGetNotStake, mockGetBalance(big.NewInt(0)))
+
+	if err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestSufficientFundsSenderPrefund calls checks.ValidateSenderPrefund with pending ops that are covered by
+// the sender's deposit and balance. Expects nil.
+func TestSufficientFundsSenderPrefund(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.Nonce = big.NewInt(1)
+	penOp := mockPendingSelfPaidOp(0)
+	bal := big.NewInt(0).Mul(penOp.GetMaxPrefund(), big.NewInt(2))
+	penOps := []*userop.UserOperation{penOp}
+	err := ValidateSenderPrefund(op, penOps, testutils.MockGetNotStakeZeroDeposit, mockGetBalance(bal))
+
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestPaymasterOpSenderPrefund calls checks.ValidateSenderPrefund with an op that uses a paymaster. Expects
+// nil since the sender does not pay for its own gas.
+func TestPaymasterOpSenderPrefund(t *testing.T) {
+	op := testutils.MockValidInitUserOp()
+	op.Nonce = big.NewInt(1)
+	op.PaymasterAndData = testutils.ValidAddress1.Bytes()
+	penOps := []*userop.UserOperation{mockPendingSelfPaidOp(0)}
+	err := ValidateSenderPrefund(op, penOps, testutils.MockGetNotStakeZeroDeposit, mockGetBalance(big.NewInt(0)))
+
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
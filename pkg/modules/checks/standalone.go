@@ -3,6 +3,8 @@
 package checks
 
 import (
+	stdError "errors"
+	"fmt"
 	"math/big"
 	"time"
 
@@ -10,8 +12,11 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/pkg/aggregator"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/reverts"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
 	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
 	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
@@ -32,7 +37,9 @@ type Standalone struct {
 	maxVerificationGas      *big.Int
 	maxBatchGasLimit        *big.Int
 	maxOpsForUnstakedSender int
-	signer                  *signer.EOA
+	signer                  *signer.Provider
+	tracingLevel            simulation.TracingLevel
+	stakeReq                stake.Requirement
 }
 
 // New returns a Standalone instance with methods that can be used in Client and Bundler modules to perform
@@ -44,10 +51,33 @@ func New(
 	maxVerificationGas *big.Int,
 	maxBatchGasLimit *big.Int,
 	maxOpsForUnstakedSender int,
-	signer *signer.EOA,
+	signer *signer.Provider,
 ) *Standalone {
 	eth := ethclient.NewClient(rpc)
-	return &Standalone{db, rpc, eth, ov, maxVerificationGas, maxBatchGasLimit, maxOpsForUnstakedSender, signer}
+	return &Standalone{
+		db,
+		rpc,
+		eth,
+		ov,
+		maxVerificationGas,
+		maxBatchGasLimit,
+		maxOpsForUnstakedSender,
+		signer,
+		simulation.TracingFull,
+		stake.Requirement{},
+	}
+}
+
+// SetTracingLevel defines which validation rules are enforced when tracing new UserOps. The default value is
+// TracingFull.
+func (s *Standalone) SetTracingLevel(level simulation.TracingLevel) {
+	s.tracingLevel = level
+}
+
+// SetStakeRequirement defines the minimum stake and unstake delay for an entity to be treated as staked. The
+// default value only requires a non-zero stake.
+func (s *Standalone) SetStakeRequirement(req stake.Requirement) {
+	s.stakeReq = req
 }
 
 // ValidateOpValues returns a UserOpHandler that runs through some first line sanity checks for new UserOps
@@ -55,7 +85,9 @@ func New(
 func (s *Standalone) ValidateOpValues() modules.UserOpHandlerFunc {
 	return func(ctx *modules.UserOpHandlerCtx) error {
 		penOps := ctx.GetPendingOps()
+		pmOps := ctx.GetPendingPaymasterOps()
 		gc := getCodeWithEthClient(s.eth)
+		gb := getBalanceWithEthClient(s.eth)
 		gbf := gasprice.GetBaseFeeWithEthClient(s.eth)
 		gs, err := getStakeWithEthClient(ctx, s.eth)
 		if err != nil {
@@ -64,15 +96,20 @@ func (s *Standalone) ValidateOpValues() modules.UserOpHandlerFunc {
 
 		g := new(errgroup.Group)
 		g.Go(func() error { return ValidateSender(ctx.UserOp, gc) })
-		g.Go(func() error { return ValidateInitCode(ctx.UserOp, gs) })
+		g.Go(func() error { return ValidateInitCode(ctx.UserOp) })
 		g.Go(func() error { return ValidateVerificationGas(ctx.UserOp, s.ov, s.maxVerificationGas) })
-		g.Go(func() error { return ValidatePaymasterAndData(ctx.UserOp, gc, gs) })
+		g.Go(func() error { return ValidatePaymasterAndData(ctx.UserOp, pmOps, gc, gs) })
 		g.Go(func() error { return ValidateCallGasLimit(ctx.UserOp, s.ov) })
 		g.Go(func() error { return ValidateFeePerGas(ctx.UserOp, gbf) })
-		g.Go(func() error { return ValidatePendingOps(ctx.UserOp, penOps, s.maxOpsForUnstakedSender, gs) })
+		g.Go(func() error {
+			return ValidatePendingOps(ctx.UserOp, penOps, s.maxOpsForUnstakedSender, s.stakeReq, gs)
+		})
+		g.Go(func() error { return ValidateSenderPrefund(ctx.UserOp, penOps, gs, gb) })
 		g.Go(func() error { return ValidateGasAvailable(ctx.UserOp, s.maxBatchGasLimit) })
 
-		if err := g.Wait(); err != nil {
+		if err := g.Wait(); stdError.Is(err, stake.ErrNotStaked) {
+			return errors.NewRPCError(errors.INVALID_PAYMASTER_STAKE, err.Error(), err.Error())
+		} else if err != nil {
 			return errors.NewRPCError(errors.INVALID_FIELDS, err.Error(), err.Error())
 		}
 		return nil
@@ -85,7 +122,7 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 		gc := getCodeWithEthClient(s.eth)
 		g := new(errgroup.Group)
 		g.Go(func() error {
-			sim, err := simulation.SimulateValidation(s.rpc, ctx.EntryPoint, ctx.UserOp, s.signer)
+			sim, err := simulation.SimulateValidation(s.rpc, ctx.EntryPoint, ctx.UserOp, s.signer.Get(), nil, nil)
 
 			if err != nil {
 				return errors.NewRPCError(errors.REJECTED_BY_EP_OR_ACCOUNT, err.Error(), err.Error())
@@ -105,9 +142,23 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 					nil,
 				)
 			}
+			if sim.AggregatorInfo != nil {
+				return s.validateAggregator(ctx, sim.AggregatorInfo)
+			}
 			return nil
 		})
 		g.Go(func() error {
+			// The factory stake is only needed to enforce storage rules while tracing.
+			if f := ctx.UserOp.GetFactory(); f != common.HexToAddress("0x") && ctx.GetDepositInfo(f) == nil {
+				gs, err := getStakeWithEthClient(ctx, s.eth)
+				if err != nil {
+					return err
+				}
+				if _, err := gs(f); err != nil {
+					return err
+				}
+			}
+
 			ic, err := simulation.TraceSimulateValidation(
 				s.rpc,
 				ctx.EntryPoint,
@@ -118,8 +169,12 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 					ctx.UserOp.Sender:         ctx.GetDepositInfo(ctx.UserOp.Sender),
 					ctx.UserOp.GetPaymaster(): ctx.GetDepositInfo(ctx.UserOp.GetPaymaster()),
 				},
+				s.stakeReq,
+				s.tracingLevel,
 			)
-			if err != nil {
+			if stdError.Is(err, stake.ErrNotStaked) {
+				return errors.NewRPCError(errors.INVALID_PAYMASTER_STAKE, err.Error(), err.Error())
+			} else if err != nil {
 				return errors.NewRPCError(errors.BANNED_OPCODE, err.Error(), err.Error())
 			}
 
@@ -134,6 +189,38 @@ func (s *Standalone) SimulateOp() modules.UserOpHandlerFunc {
 	}
 }
 
+func (s *Standalone) validateAggregator(
+	ctx *modules.UserOpHandlerCtx,
+	info *reverts.AggregatorStakeInfo,
+) error {
+	if info.StakeInfo == nil ||
+		!s.stakeReq.Meets(info.StakeInfo.Stake, info.StakeInfo.UnstakeDelaySec.Uint64()) {
+		return errors.NewRPCError(
+			errors.INVALID_AGGREGATOR,
+			fmt.Sprintf("aggregator: %s does not meet the %s", info.Aggregator, s.stakeReq),
+			info.Aggregator,
+		)
+	}
+
+	// The returned sigForUserOp replaces the signature of the op when it is included in an aggregated bundle.
+	sigForUserOp, err := aggregator.ValidateUserOpSignature(s.rpc, info.Aggregator, ctx.UserOp)
+	if err != nil {
+		return errors.NewRPCError(
+			errors.INVALID_AGGREGATOR,
+			fmt.Sprintf("aggregator: %s", err),
+			info.Aggregator,
+		)
+	}
+
+	ctx.SetAggregator(info.Aggregator)
+	return saveAggregator(
+		s.db,
+		ctx.UserOp.GetUserOpHash(ctx.EntryPoint, ctx.ChainID),
+		info.Aggregator,
+		sigForUserOp,
+	)
+}
+
 // CodeHashes returns a BatchHandler that verifies the code for any interacted contracts has not changed since
 // the first simulation.
 func (s *Standalone) CodeHashes() modules.BatchHandlerFunc {
@@ -153,7 +240,7 @@ func (s *Standalone) CodeHashes() modules.BatchHandlerFunc {
 				return err
 			}
 			if changed {
-				ctx.MarkOpIndexForRemoval(i)
+				ctx.MarkOpIndexForRemoval(i, "code hashes changed since validation")
 			}
 		}
 		return nil
@@ -187,7 +274,7 @@ func (s *Standalone) PaymasterDeposit() modules.BatchHandlerFunc {
 
 			deps[pm] = big.NewInt(0).Sub(deps[pm], op.GetMaxPrefund())
 			if deps[pm].Cmp(common.Big0) < 0 {
-				ctx.MarkOpIndexForRemoval(i)
+				ctx.MarkOpIndexForRemoval(i, "paymaster deposit too low")
 			}
 		}
 
@@ -195,6 +282,24 @@ func (s *Standalone) PaymasterDeposit() modules.BatchHandlerFunc {
 	}
 }
 
+// Aggregators returns a BatchHandler that adds the signature aggregator of each op to the context and groups
+// the batch by aggregator. This must be used before any module that sends the batch to the EntryPoint.
+func (s *Standalone) Aggregators() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		for _, op := range ctx.Batch {
+			agg, sig, err := getSavedAggregator(s.db, op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID))
+			if err != nil {
+				return err
+			}
+			ctx.SetAggregator(op, agg)
+			ctx.SetSigForUserOp(op, sig)
+		}
+
+		ctx.GroupByAggregator()
+		return nil
+	}
+}
+
 // Clean returns a BatchHandler that clears the DB of data that is no longer required. This should be one of
 // the last modules executed by the Bundler.
 func (s *Standalone) Clean() modules.BatchHandlerFunc {
@@ -206,6 +311,9 @@ func (s *Standalone) Clean() modules.BatchHandlerFunc {
 			hashes = append(hashes, op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID))
 		}
 
-		return removeSavedCodeHashes(s.db, hashes...)
+		if err := removeSavedCodeHashes(s.db, hashes...); err != nil {
+			return err
+		}
+		return removeSavedAggregators(s.db, hashes...)
 	}
 }
@@ -0,0 +1,97 @@
+package checks
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/simulation"
+	"github.com/stackup-wallet/stackup-bundler/pkg/gas"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// TestSimulateOpUsesCurrentSigner calls (*Standalone).SimulateOp after the EOA in the signer Provider has
+// been replaced. Expects simulation to be called from the new EOA.
+func TestSimulateOpUsesCurrentSigner(t *testing.T) {
+	var mu sync.Mutex
+	callers := []common.Address{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			panic(err)
+		}
+		if req.Method == "eth_call" && len(req.Params) > 0 {
+			var call struct {
+				From common.Address `json:"from"`
+			}
+			// Ignore calls without a sender such as fetching deposit info.
+			err := json.Unmarshal(req.Params[0], &call)
+			if err == nil && call.From != common.HexToAddress("0x") {
+				mu.Lock()
+				callers = append(callers, call.From)
+				mu.Unlock()
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"error":   map[string]any{"code": -32000, "message": "mock"},
+		})
+	}))
+	defer srv.Close()
+	c, err := rpc.Dial(srv.URL)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	db := testutils.DBMock()
+	defer db.Close()
+
+	eoas := []*signer.EOA{}
+	for i := 0; i < 2; i++ {
+		pk, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		eoas = append(eoas, signer.NewEOA(signer.NewLocalSigner(pk)))
+	}
+	current := signer.NewProvider(eoas[0])
+	s := New(
+		db,
+		c,
+		gas.NewDefaultOverhead(),
+		big.NewInt(4000000),
+		big.NewInt(30000000),
+		testutils.MaxOpsForUnstakedSender,
+		current,
+	)
+	s.SetTracingLevel(simulation.TracingOff)
+	current.Set(eoas[1])
+
+	op := testutils.MockValidInitUserOp()
+	ctx := modules.NewUserOpHandlerContext(op, nil, testutils.ValidAddress1, testutils.ChainID)
+	_ = s.SimulateOp()(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(callers) == 0 {
+		t.Fatal("got no eth_call, want simulation call")
+	}
+	for _, from := range callers {
+		if from != eoas[1].Address {
+			t.Fatalf("got from %s, want %s", from, eoas[1].Address)
+		}
+	}
+}
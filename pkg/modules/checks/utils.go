@@ -2,6 +2,7 @@ package checks
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -15,6 +16,9 @@ type GetCodeFunc = func(addr common.Address) ([]byte, error)
 // GetStakeFunc provides a general interface for retrieving the EntryPoint stake for a given address.
 type GetStakeFunc = func(entity common.Address) (*entrypoint.IStakeManagerDepositInfo, error)
 
+// GetBalanceFunc provides a general interface for retrieving the native token balance for a given address.
+type GetBalanceFunc = func(addr common.Address) (*big.Int, error)
+
 // getCodeWithEthClient returns a GetCodeFunc that uses an eth client to call eth_getCode.
 func getCodeWithEthClient(eth *ethclient.Client) GetCodeFunc {
 	return func(addr common.Address) ([]byte, error) {
@@ -22,6 +26,13 @@ func getCodeWithEthClient(eth *ethclient.Client) GetCodeFunc {
 	}
 }
 
+// getBalanceWithEthClient returns a GetBalanceFunc that uses an eth client to call eth_getBalance.
+func getBalanceWithEthClient(eth *ethclient.Client) GetBalanceFunc {
+	return func(addr common.Address) (*big.Int, error) {
+		return eth.BalanceAt(context.Background(), addr, nil)
+	}
+}
+
 // getStakeWithEthClient returns a GetStakeFunc that uses an EntryPoint binding to get stake info and adds it
 // to the current context.
 func getStakeWithEthClient(ctx *modules.UserOpHandlerCtx, eth *ethclient.Client) (GetStakeFunc, error) {
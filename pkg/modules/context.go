@@ -6,21 +6,30 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
 	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
 )
 
 // BatchHandlerCtx is the object passed to BatchHandler functions during the Bundler's Run process. It
 // also contains a Data field for adding arbitrary key-value pairs to the context. These values will be
 // logged by the Bundler at the end of each run.
+//
+// Signer is the EOA assigned by the Bundler to send the batch. It is nil if the Bundler does not use a
+// signer pool, in which case modules should fallback to their own EOA.
 type BatchHandlerCtx struct {
-	Batch          []*userop.UserOperation
-	PendingRemoval []*userop.UserOperation
-	EntryPoint     common.Address
-	ChainID        *big.Int
-	BaseFee        *big.Int
-	Tip            *big.Int
-	GasPrice       *big.Int
-	Data           map[string]any
+	Batch            []*userop.UserOperation
+	PendingRemoval   []*userop.UserOperation
+	PendingInclusion []*userop.UserOperation
+	EntryPoint       common.Address
+	ChainID          *big.Int
+	BaseFee          *big.Int
+	Tip              *big.Int
+	GasPrice         *big.Int
+	Signer           *signer.EOA
+	Data             map[string]any
+	aggregators      map[common.Hash]common.Address
+	sigsForUserOps   map[common.Hash][]byte
+	removalReasons   map[common.Hash]string
 }
 
 // NewBatchHandlerContext creates a new BatchHandlerCtx using a copy of the given batch.
@@ -36,20 +45,25 @@ func NewBatchHandlerContext(
 	copy = append(copy, batch...)
 
 	return &BatchHandlerCtx{
-		Batch:          copy,
-		PendingRemoval: []*userop.UserOperation{},
-		EntryPoint:     entryPoint,
-		ChainID:        chainID,
-		BaseFee:        baseFee,
-		Tip:            tip,
-		GasPrice:       gasPrice,
-		Data:           make(map[string]any),
+		Batch:            copy,
+		PendingRemoval:   []*userop.UserOperation{},
+		PendingInclusion: []*userop.UserOperation{},
+		EntryPoint:       entryPoint,
+		ChainID:          chainID,
+		BaseFee:          baseFee,
+		Tip:              tip,
+		GasPrice:         gasPrice,
+		Data:             make(map[string]any),
+		aggregators:      make(map[common.Hash]common.Address),
+		sigsForUserOps:   make(map[common.Hash][]byte),
+		removalReasons:   make(map[common.Hash]string),
 	}
 }
 
 // MarkOpIndexForRemoval will remove the op by index from the batch and add it to the pending removal array.
-// This should be used for ops that are not to be included on-chain and dropped from the mempool.
-func (c *BatchHandlerCtx) MarkOpIndexForRemoval(index int) {
+// This should be used for ops that are not to be included on-chain and dropped from the mempool. The reason
+// is recorded and can be retrieved with GetRemovalReason.
+func (c *BatchHandlerCtx) MarkOpIndexForRemoval(index int, reason string) {
 	batch := []*userop.UserOperation{}
 	var op *userop.UserOperation
 	for i, curr := range c.Batch {
@@ -65,6 +79,77 @@ func (c *BatchHandlerCtx) MarkOpIndexForRemoval(index int) {
 
 	c.Batch = batch
 	c.PendingRemoval = append(c.PendingRemoval, op)
+	c.removalReasons[op.GetUserOpHash(c.EntryPoint, c.ChainID)] = reason
+}
+
+// GetRemovalReason returns the reason an op was marked for removal. The second return value is false if the
+// op was not dropped with MarkOpIndexForRemoval (e.g. it was removed from the mempool after being included
+// on-chain).
+func (c *BatchHandlerCtx) GetRemovalReason(op *userop.UserOperation) (string, bool) {
+	reason, ok := c.removalReasons[op.GetUserOpHash(c.EntryPoint, c.ChainID)]
+	return reason, ok
+}
+
+// MarkBatchPendingInclusion will move all ops in the batch to the pending inclusion array. This should be used
+// for ops that have been sent on-chain but are not yet final. These ops will stay in the mempool.
+func (c *BatchHandlerCtx) MarkBatchPendingInclusion() {
+	c.PendingInclusion = append(c.PendingInclusion, c.Batch...)
+	c.Batch = []*userop.UserOperation{}
+}
+
+// SetAggregator records the signature aggregator used by an op in the batch.
+func (c *BatchHandlerCtx) SetAggregator(op *userop.UserOperation, aggregator common.Address) {
+	c.aggregators[op.GetUserOpHash(c.EntryPoint, c.ChainID)] = aggregator
+}
+
+// GetAggregator returns the signature aggregator used by an op in the batch. If the op does not use an
+// aggregator, the zero address is returned.
+func (c *BatchHandlerCtx) GetAggregator(op *userop.UserOperation) common.Address {
+	return c.aggregators[op.GetUserOpHash(c.EntryPoint, c.ChainID)]
+}
+
+// SetSigForUserOp records the signature returned by the aggregator's validateUserOpSignature for an op in the
+// batch.
+func (c *BatchHandlerCtx) SetSigForUserOp(op *userop.UserOperation, sig []byte) {
+	c.sigsForUserOps[op.GetUserOpHash(c.EntryPoint, c.ChainID)] = sig
+}
+
+// GetSigForUserOp returns the signature that should replace the signature of an op when it is sent with
+// handleAggregatedOps. The second return value is false if none was recorded.
+func (c *BatchHandlerCtx) GetSigForUserOp(op *userop.UserOperation) ([]byte, bool) {
+	sig, ok := c.sigsForUserOps[op.GetUserOpHash(c.EntryPoint, c.ChainID)]
+	return sig, ok
+}
+
+// HasAggregators returns true if at least one op in the batch uses a signature aggregator.
+func (c *BatchHandlerCtx) HasAggregators() bool {
+	for _, op := range c.Batch {
+		if c.GetAggregator(op) != common.HexToAddress("0x") {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupByAggregator reorders the batch so that ops using the same signature aggregator are next to each
+// other. Groups are ordered by the first appearance of each aggregator and the relative order of ops within
+// each group is preserved.
+func (c *BatchHandlerCtx) GroupByAggregator() {
+	order := []common.Address{}
+	groups := make(map[common.Address][]*userop.UserOperation)
+	for _, op := range c.Batch {
+		agg := c.GetAggregator(op)
+		if _, ok := groups[agg]; !ok {
+			order = append(order, agg)
+		}
+		groups[agg] = append(groups[agg], op)
+	}
+
+	batch := []*userop.UserOperation{}
+	for _, agg := range order {
+		batch = append(batch, groups[agg]...)
+	}
+	c.Batch = batch
 }
 
 // UserOpHandlerCtx is the object passed to UserOpHandler functions during the Client's SendUserOperation
@@ -75,6 +160,8 @@ type UserOpHandlerCtx struct {
 	ChainID    *big.Int
 	deposits   sync.Map
 	pendingOps []*userop.UserOperation
+	pmOps      []*userop.UserOperation
+	aggregator common.Address
 }
 
 // NewUserOpHandlerContext creates a new UserOpHandlerCtx using a given op.
@@ -109,6 +196,29 @@ func (c *UserOpHandlerCtx) GetDepositInfo(entity common.Address) *entrypoint.ISt
 	return dep.(*entrypoint.IStakeManagerDepositInfo)
 }
 
+// SetPendingPaymasterOps sets all pending UserOperations in the mempool that use the same paymaster as
+// UserOp.
+func (c *UserOpHandlerCtx) SetPendingPaymasterOps(ops []*userop.UserOperation) {
+	c.pmOps = append([]*userop.UserOperation{}, ops...)
+}
+
+// GetPendingPaymasterOps returns all pending UserOperations in the mempool that use the same paymaster as
+// UserOp. It is empty if UserOp does not use a paymaster.
+func (c *UserOpHandlerCtx) GetPendingPaymasterOps() []*userop.UserOperation {
+	return c.pmOps
+}
+
+// SetAggregator records the signature aggregator returned from simulating the op.
+func (c *UserOpHandlerCtx) SetAggregator(aggregator common.Address) {
+	c.aggregator = aggregator
+}
+
+// GetAggregator returns the signature aggregator used by the op. If the op does not use an aggregator or it
+// has not been simulated yet, the zero address is returned.
+func (c *UserOpHandlerCtx) GetAggregator() common.Address {
+	return c.aggregator
+}
+
 // GetPendingOps returns all pending UserOperations in the mempool by the same UserOp.Sender.
 func (c *UserOpHandlerCtx) GetPendingOps() []*userop.UserOperation {
 	return c.pendingOps
@@ -59,3 +59,81 @@ func TestGetPendingOps(t *testing.T) {
 		}
 	}
 }
+
+// TestGroupBatchByAggregator calls (c *BatchHandlerCtx).GroupByAggregator and verifies that ops sharing the
+// same aggregator are next to each other while keeping their relative order.
+func TestGroupBatchByAggregator(t *testing.T) {
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(1)
+	op3 := testutils.MockValidInitUserOp()
+	op3.Nonce = big.NewInt(2)
+	op4 := testutils.MockValidInitUserOp()
+	op4.Nonce = big.NewInt(3)
+	batch := []*userop.UserOperation{op1, op2, op3, op4}
+	ctx := NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+	ctx.SetAggregator(op2, testutils.ValidAddress2)
+	ctx.SetAggregator(op4, testutils.ValidAddress2)
+
+	if !ctx.HasAggregators() {
+		t.Fatal("got false, want true")
+	}
+
+	ctx.GroupByAggregator()
+	want := []*userop.UserOperation{op1, op3, op2, op4}
+	if len(ctx.Batch) != len(want) {
+		t.Fatalf("got length %d, want %d", len(ctx.Batch), len(want))
+	}
+	for i, op := range ctx.Batch {
+		if !testutils.IsOpsEqual(op, want[i]) {
+			t.Fatalf("ops not equal: %s", testutils.GetOpsDiff(op, want[i]))
+		}
+	}
+}
+
+// TestMarkBatchPendingInclusion calls (c *BatchHandlerCtx).MarkBatchPendingInclusion and verifies that all ops
+// in the batch are moved to the pending inclusion array and not marked for removal.
+func TestMarkBatchPendingInclusion(t *testing.T) {
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(1)
+	batch := []*userop.UserOperation{op1, op2}
+	ctx := NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+
+	ctx.MarkBatchPendingInclusion()
+	if len(ctx.Batch) != 0 {
+		t.Fatalf("got batch length %d, want 0", len(ctx.Batch))
+	}
+	if len(ctx.PendingRemoval) != 0 {
+		t.Fatalf("got pending removal length %d, want 0", len(ctx.PendingRemoval))
+	}
+	if len(ctx.PendingInclusion) != len(batch) {
+		t.Fatalf("got pending inclusion length %d, want %d", len(ctx.PendingInclusion), len(batch))
+	}
+	for i, op := range ctx.PendingInclusion {
+		if !testutils.IsOpsEqual(op, batch[i]) {
+			t.Fatalf("ops not equal: %s", testutils.GetOpsDiff(op, batch[i]))
+		}
+	}
+}
+
+// TestMarkOpIndexForRemovalReason calls (c *BatchHandlerCtx).MarkOpIndexForRemoval and verifies that the
+// reason can be retrieved for the removed op only.
+func TestMarkOpIndexForRemovalReason(t *testing.T) {
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(1)
+	batch := []*userop.UserOperation{op1, op2}
+	ctx := NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+
+	ctx.MarkOpIndexForRemoval(0, "expired")
+	if len(ctx.PendingRemoval) != 1 || !testutils.IsOpsEqual(ctx.PendingRemoval[0], op1) {
+		t.Fatalf("got pending removal %v, want [op1]", ctx.PendingRemoval)
+	}
+	if reason, ok := ctx.GetRemovalReason(op1); !ok || reason != "expired" {
+		t.Fatalf("got reason %s, want expired", reason)
+	}
+	if _, ok := ctx.GetRemovalReason(op2); ok {
+		t.Fatal("got true, want false")
+	}
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// IsAllowedFunc provides a general interface for checking if an entity is exempt from throttling and banning.
+type IsAllowedFunc = func(entity common.Address) (bool, error)
+
+// NoopIsAllowedFunc returns false and nil error. With this function no entities are exempt.
+func NoopIsAllowedFunc() IsAllowedFunc {
+	return func(entity common.Address) (bool, error) {
+		return false, nil
+	}
+}
+
+// getStatus returns the status of an entity based on its reputation unless it is exempt.
+func (r *Reputation) getStatus(txn *badger.Txn, entity common.Address) (status, error) {
+	if allowed, err := r.isAllowed(entity); err != nil {
+		return ok, err
+	} else if allowed {
+		return ok, nil
+	}
+	return getStatus(txn, entity)
+}
@@ -0,0 +1,356 @@
+// Package entities implements modules for reputation scoring and throttling/banning of paymasters, factories,
+// aggregators, and staked senders as specified in EIP-4337.
+package entities
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/stake"
+	"github.com/stackup-wallet/stackup-bundler/pkg/errors"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Entry is the reputation of a single entity as returned by the debug_bundler_dumpReputation RPC method.
+// Status is ignored when setting reputation since it is derived from the counters.
+type Entry struct {
+	Address     common.Address `json:"address"`
+	OpsSeen     hexutil.Uint64 `json:"opsSeen"`
+	OpsIncluded hexutil.Uint64 `json:"opsIncluded"`
+	Status      string         `json:"status,omitempty"`
+}
+
+// Reputation provides Client and Bundler modules to track the status of every entity seen in a
+// UserOperation.
+type Reputation struct {
+	db        *badger.DB
+	eth       *ethclient.Client
+	mempool   *mempool.Mempool
+	isAllowed IsAllowedFunc
+	stakeReq  stake.Requirement
+	mu        sync.Mutex
+	firstSeen map[common.Address]map[common.Hash]uint64
+}
+
+// New returns an instance of a Reputation object to track and appropriately process userOps by entity
+// status. Any paymaster reputation stored by a previous version is migrated on initialization.
+func New(db *badger.DB, eth *ethclient.Client, mem *mempool.Mempool) (*Reputation, error) {
+	if err := db.Update(migrateLegacyOpsCounts); err != nil {
+		return nil, err
+	}
+
+	return &Reputation{
+		db:        db,
+		eth:       eth,
+		mempool:   mem,
+		isAllowed: NoopIsAllowedFunc(),
+		firstSeen: make(map[common.Address]map[common.Hash]uint64),
+	}, nil
+}
+
+// SetIsAllowedFunc defines the function used to check if an entity is exempt from throttling and banning.
+func (r *Reputation) SetIsAllowedFunc(fn IsAllowedFunc) {
+	r.isAllowed = fn
+}
+
+// SetStakeRequirement defines the minimum stake and unstake delay for a sender to be treated as staked. The
+// default value only requires a non-zero stake.
+func (r *Reputation) SetStakeRequirement(req stake.Requirement) {
+	r.stakeReq = req
+}
+
+// CheckStatus returns a UserOpHandler that is used by the Client to determine if the userOp is allowed based
+// on the status of its sender, factory, and paymaster.
+//  1. ok: Entity is allowed
+//  2. throttled: No new ops referencing the entity are allowed if the mempool already has 4. And they can
+//     only stay in the pool for 10 blocks
+//  3. banned: No ops referencing the entity are allowed
+//
+// This should be used before SimulateOp so that ops from a banned entity are rejected without tracing.
+func (r *Reputation) CheckStatus() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		return r.checkEntities(ctx, getEntities(ctx.UserOp, common.HexToAddress("0x"))...)
+	}
+}
+
+// CheckAggregatorStatus returns a UserOpHandler that is used by the Client to determine if the userOp is
+// allowed based on the status of its signature aggregator. This should be used after SimulateOp once the
+// aggregator is known.
+func (r *Reputation) CheckAggregatorStatus() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		agg := ctx.GetAggregator()
+		if agg == common.HexToAddress("0x") {
+			return nil
+		}
+		return r.checkEntities(ctx, agg)
+	}
+}
+
+func (r *Reputation) checkEntities(ctx *modules.UserOpHandlerCtx, entities ...common.Address) error {
+	return r.db.Update(func(txn *badger.Txn) error {
+		for _, entity := range entities {
+			status, err := r.getStatus(txn, entity)
+			if err != nil {
+				return err
+			}
+
+			if status == banned {
+				return errors.NewRPCError(
+					errors.BANNED_OR_THROTTLED_PAYMASTER,
+					fmt.Sprintf("entity: %s is banned", entity),
+					entity,
+				)
+			} else if status == throttled {
+				if err := r.checkMempoolCount(ctx, entity); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// checkMempoolCount returns an error if the mempool has reached the limit of ops referencing a throttled
+// entity. An existing op that would be replaced by the incoming one is not counted.
+func (r *Reputation) checkMempoolCount(ctx *modules.UserOpHandlerCtx, entity common.Address) error {
+	ops, err := r.mempool.DumpAll(ctx.EntryPoint)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, op := range ops {
+		if op.Sender == ctx.UserOp.Sender && op.Nonce.Cmp(ctx.UserOp.Nonce) == 0 {
+			continue
+		}
+		if hasEntity(op, common.HexToAddress("0x"), entity) {
+			count++
+		}
+	}
+
+	if count >= throttledEntityMempoolCount {
+		return errors.NewRPCError(
+			errors.BANNED_OR_THROTTLED_PAYMASTER,
+			fmt.Sprintf("entity: %s is throttled", entity),
+			entity,
+		)
+	}
+	return nil
+}
+
+// IncOpsSeen returns a UserOpHandler that is used by the Client to increment the opsSeen counter of every
+// entity in the userOp. The sender is only counted if it meets the stake requirement based on the deposit
+// info already in the context. This should be used after ValidateOpValues which loads the sender's deposit.
+func (r *Reputation) IncOpsSeen() modules.UserOpHandlerFunc {
+	return func(ctx *modules.UserOpHandlerCtx) error {
+		entities := getEntities(ctx.UserOp, ctx.GetAggregator())
+		if !r.stakeReq.IsStaked(ctx.GetDepositInfo(ctx.UserOp.Sender)) {
+			entities = entities[1:]
+		}
+		return r.db.Update(func(txn *badger.Txn) error {
+			return incrementOpsSeenByEntities(txn, entities...)
+		})
+	}
+}
+
+// FilterByStatus returns a BatchHandler that is used by the Bundler to drop ops referencing a banned entity.
+// Ops referencing a throttled entity are dropped if they are not included within 10 blocks and at most 4
+// are allowed in a single batch. Any extra ops are left in the mempool for a later batch.
+func (r *Reputation) FilterByStatus() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		if err := r.pruneFirstSeen(ctx.EntryPoint, ctx.ChainID); err != nil {
+			return err
+		}
+
+		return r.db.Update(func(txn *badger.Txn) error {
+			statuses := make(map[common.Address]status)
+			getCachedStatus := func(entity common.Address) (status, error) {
+				if s, ok := statuses[entity]; ok {
+					return s, nil
+				}
+				s, err := r.getStatus(txn, entity)
+				statuses[entity] = s
+				return s, err
+			}
+
+			var head *uint64
+			counts := make(addressCounter)
+			reasons := make(map[*userop.UserOperation]string)
+			deferred := make(map[*userop.UserOperation]bool)
+			for _, op := range ctx.Batch {
+				for _, entity := range getEntities(op, ctx.GetAggregator(op)) {
+					s, err := getCachedStatus(entity)
+					if err != nil {
+						return err
+					}
+
+					if s == banned {
+						reasons[op] = fmt.Sprintf("entity: %s is banned", entity)
+						break
+					} else if s != throttled {
+						continue
+					}
+
+					if head == nil {
+						bn, err := r.eth.BlockNumber(context.Background())
+						if err != nil {
+							return err
+						}
+						head = &bn
+					}
+					if r.isThrottledOpExpired(ctx.EntryPoint, op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID), *head) {
+						reasons[op] = fmt.Sprintf("entity: %s is throttled", entity)
+						break
+					} else if counts[entity] >= throttledEntityBundleCount {
+						deferred[op] = true
+					}
+				}
+
+				if _, ok := reasons[op]; !ok && !deferred[op] {
+					for _, entity := range getEntities(op, ctx.GetAggregator(op)) {
+						counts[entity]++
+					}
+				}
+			}
+
+			end := len(ctx.Batch) - 1
+			for i := end; i >= 0; i-- {
+				if reason, ok := reasons[ctx.Batch[i]]; ok {
+					ctx.MarkOpIndexForRemoval(i, reason)
+				}
+			}
+
+			batch := []*userop.UserOperation{}
+			for _, op := range ctx.Batch {
+				if !deferred[op] {
+					batch = append(batch, op)
+				}
+			}
+			ctx.Batch = batch
+			return nil
+		})
+	}
+}
+
+// isThrottledOpExpired records the block an op referencing a throttled entity was first seen by the Bundler
+// and returns true once it has been pending for too long.
+func (r *Reputation) isThrottledOpExpired(ep common.Address, hash common.Hash, head uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen, ok := r.firstSeen[ep]
+	if !ok {
+		seen = make(map[common.Hash]uint64)
+		r.firstSeen[ep] = seen
+	}
+	first, ok := seen[hash]
+	if !ok {
+		seen[hash] = head
+		return false
+	}
+	return head >= first+throttledEntityLiveBlocks
+}
+
+// pruneFirstSeen forgets every op that is no longer in the mempool regardless of how it was removed (e.g.
+// included, replaced, evicted, or expired).
+func (r *Reputation) pruneFirstSeen(ep common.Address, chainID *big.Int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := r.firstSeen[ep]
+	if len(seen) == 0 {
+		return nil
+	}
+
+	ops, err := r.mempool.DumpAll(ep)
+	if err != nil {
+		return err
+	}
+	pending := make(map[common.Hash]bool)
+	for _, op := range ops {
+		pending[op.GetUserOpHash(ep, chainID)] = true
+	}
+	for hash := range seen {
+		if !pending[hash] {
+			delete(seen, hash)
+		}
+	}
+	return nil
+}
+
+// IncOpsIncluded returns a BatchHandler used by the Bundler to increment opsIncluded counters for all
+// relevant entities in the batch. Ops removed from the mempool without a reason have been included on-chain
+// and are also counted. This module should be used last once batches have been sent.
+func (r *Reputation) IncOpsIncluded() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		included := append([]*userop.UserOperation{}, ctx.Batch...)
+		for _, op := range ctx.PendingRemoval {
+			if _, ok := ctx.GetRemovalReason(op); !ok {
+				included = append(included, op)
+			}
+		}
+
+		c := make(addressCounter)
+		for _, op := range included {
+			for _, entity := range getEntities(op, ctx.GetAggregator(op)) {
+				c[entity]++
+			}
+		}
+
+		return r.db.Update(func(txn *badger.Txn) error {
+			return incrementOpsIncludedByEntities(txn, c)
+		})
+	}
+}
+
+// Dump returns the current reputation of every entity that has been seen.
+func (r *Reputation) Dump() ([]*Entry, error) {
+	var entries []*Entry
+	err := r.db.Update(func(txn *badger.Txn) error {
+		var err error
+		entries, err = getAllEntries(txn)
+		return err
+	})
+	return entries, err
+}
+
+// SetEntries overrides the opsSeen and opsIncluded counters for the given entities.
+func (r *Reputation) SetEntries(entries ...*Entry) error {
+	return r.db.Update(func(txn *badger.Txn) error {
+		for _, entry := range entries {
+			e := badger.NewEntry(
+				getOpsCountKey(entry.Address),
+				getOpsCountValue(int(entry.OpsSeen), int(entry.OpsIncluded), now().Unix()),
+			)
+			if err := txn.SetEntry(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Clear removes the reputation of every entity.
+func (r *Reputation) Clear() error {
+	r.mu.Lock()
+	r.firstSeen = make(map[common.Address]map[common.Hash]uint64)
+	r.mu.Unlock()
+
+	return r.db.Update(func(txn *badger.Txn) error {
+		for _, entity := range getAllEntities(txn) {
+			if err := txn.Delete(getOpsCountKey(entity)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,422 @@
+package entities
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/mempool"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func setOpsCount(t *testing.T, db *badger.DB, entity common.Address, opsSeen int, opsIncluded int) {
+	err := db.Update(func(txn *badger.Txn) error {
+		return txn.Set(getOpsCountKey(entity), getOpsCountValue(opsSeen, opsIncluded, now().Unix()))
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+func getOpsCount(t *testing.T, db *badger.DB, entity common.Address) (int, int) {
+	var opsSeen, opsIncluded int
+	err := db.Update(func(txn *badger.Txn) error {
+		var err error
+		opsSeen, opsIncluded, _, _, err = getOpsCountByEntity(txn, entity)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	return opsSeen, opsIncluded
+}
+
+func newReputation(t *testing.T, db *badger.DB) (*Reputation, *mempool.Mempool, func()) {
+	srv := testutils.EthMock(testutils.MethodMocks{"eth_blockNumber": "0x1"})
+	eth, err := ethclient.Dial(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem, err := mempool.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := New(db, eth, mem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rep, mem, srv.Close
+}
+
+func mockOpWithPaymaster(sender common.Address) *userop.UserOperation {
+	op := testutils.MockValidInitUserOp()
+	op.Sender = sender
+	op.InitCode = []byte{}
+	op.PaymasterAndData = testutils.ValidAddress3.Bytes()
+	return op
+}
+
+// TestNewMigratesLegacyCounts calls entities.New on a database with paymaster reputation stored under the
+// legacy prefix and verifies that it is kept.
+func TestNewMigratesLegacyCounts(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+
+	err := db.Update(func(txn *badger.Txn) error {
+		key := dbutils.JoinValues(legacyOpsCountPrefix, testutils.ValidAddress3.String())
+		return txn.Set([]byte(key), getOpsCountValue(700, 1, now().Unix()))
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	_, _, done := newReputation(t, db)
+	defer done()
+
+	if seen, included := getOpsCount(t, db, testutils.ValidAddress3); seen != 700 || included != 1 {
+		t.Fatalf("got counts %d:%d, want 700:1", seen, included)
+	}
+	err = db.View(func(txn *badger.Txn) error {
+		key := dbutils.JoinValues(legacyOpsCountPrefix, testutils.ValidAddress3.String())
+		if _, err := txn.Get([]byte(key)); err != badger.ErrKeyNotFound {
+			t.Fatalf("got %v, want %v", err, badger.ErrKeyNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
+
+// TestGetStatus verifies that an entity is throttled or banned once its inclusion rate falls below the
+// expected slack.
+func TestGetStatus(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+
+	cases := []struct {
+		opsSeen int
+		want    status
+	}{
+		{0, ok},
+		{100, ok},
+		{200, throttled},
+		{500, throttled},
+		{700, banned},
+	}
+	for _, c := range cases {
+		setOpsCount(t, db, testutils.ValidAddress1, c.opsSeen, 0)
+		err := db.Update(func(txn *badger.Txn) error {
+			s, err := getStatus(txn, testutils.ValidAddress1)
+			if err != nil {
+				return err
+			} else if s != c.want {
+				t.Fatalf("got status %d for %d opsSeen, want %d", s, c.opsSeen, c.want)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+}
+
+// TestDecayWithFrequentReads reads an entity every few minutes over several hours and verifies that the
+// counters are still decayed once for every hour that has passed.
+func TestDecayWithFrequentReads(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+
+	start := time.Now()
+	clock := start
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	setOpsCount(t, db, testutils.ValidAddress1, 240, 48)
+	for clock.Sub(start) < 3*time.Hour {
+		clock = clock.Add(5 * time.Minute)
+		getOpsCount(t, db, testutils.ValidAddress1)
+	}
+
+	// 240 -> 230 -> 221 -> 212 and 48 -> 46 -> 45 -> 44 after three hours.
+	if seen, included := getOpsCount(t, db, testutils.ValidAddress1); seen != 212 || included != 44 {
+		t.Fatalf("got counts %d:%d, want 212:44", seen, included)
+	}
+}
+
+// TestCheckStatusBanned calls (*Reputation).CheckStatus on an op with a banned factory. Expects error.
+func TestCheckStatusBanned(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, _, done := newReputation(t, db)
+	defer done()
+
+	op := testutils.MockValidInitUserOp()
+	setOpsCount(t, db, op.GetFactory(), 1000, 0)
+
+	ctx := modules.NewUserOpHandlerContext(op, nil, testutils.ValidAddress1, testutils.ChainID)
+	if err := rep.CheckStatus()(ctx); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestCheckAggregatorStatusBanned calls (*Reputation).CheckStatus and (*Reputation).CheckAggregatorStatus on
+// an op with a banned aggregator. Expects error only from CheckAggregatorStatus.
+func TestCheckAggregatorStatusBanned(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, _, done := newReputation(t, db)
+	defer done()
+
+	op := testutils.MockValidInitUserOp()
+	setOpsCount(t, db, testutils.ValidAddress3, 1000, 0)
+
+	ctx := modules.NewUserOpHandlerContext(op, nil, testutils.ValidAddress1, testutils.ChainID)
+	ctx.SetAggregator(testutils.ValidAddress3)
+	if err := rep.CheckStatus()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := rep.CheckAggregatorStatus()(ctx); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestCheckStatusThrottled calls (*Reputation).CheckStatus on an op with a throttled paymaster. Expects error
+// only if the mempool already has the maximum number of ops using the paymaster.
+func TestCheckStatusThrottled(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, mem, done := newReputation(t, db)
+	defer done()
+
+	ep := testutils.ValidAddress1
+	setOpsCount(t, db, testutils.ValidAddress3, 200, 0)
+	for i := 0; i < throttledEntityMempoolCount; i++ {
+		op := mockOpWithPaymaster(common.BigToAddress(big.NewInt(int64(i + 1))))
+		ctx := modules.NewUserOpHandlerContext(op, nil, ep, testutils.ChainID)
+		if err := rep.CheckStatus()(ctx); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if _, err := mem.AddOp(ep, op); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+	}
+
+	op := mockOpWithPaymaster(testutils.ValidAddress2)
+	ctx := modules.NewUserOpHandlerContext(op, nil, ep, testutils.ChainID)
+	if err := rep.CheckStatus()(ctx); err == nil {
+		t.Fatal("got nil, want err")
+	}
+
+	replace := mockOpWithPaymaster(common.BigToAddress(common.Big1))
+	ctx = modules.NewUserOpHandlerContext(replace, nil, ep, testutils.ChainID)
+	if err := rep.CheckStatus()(ctx); err != nil {
+		t.Fatalf("got %v, want nil for replacement op", err)
+	}
+}
+
+// TestIncOpsSeenUnstakedSender calls (*Reputation).IncOpsSeen and verifies that the factory is counted but an
+// unstaked sender is not.
+func TestIncOpsSeenUnstakedSender(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, _, done := newReputation(t, db)
+	defer done()
+
+	op := testutils.MockValidInitUserOp()
+	ctx := modules.NewUserOpHandlerContext(op, nil, testutils.ValidAddress1, testutils.ChainID)
+	ctx.AddDepositInfo(op.Sender, testutils.NonStakedDepositInfo)
+	if err := rep.IncOpsSeen()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if seen, _ := getOpsCount(t, db, op.GetFactory()); seen != 1 {
+		t.Fatalf("got factory opsSeen %d, want 1", seen)
+	}
+	if seen, _ := getOpsCount(t, db, op.Sender); seen != 0 {
+		t.Fatalf("got sender opsSeen %d, want 0", seen)
+	}
+}
+
+// TestFilterByStatus calls (*Reputation).FilterByStatus and verifies that ops with a banned entity are
+// dropped and ops over the bundle limit for a throttled entity are deferred.
+func TestFilterByStatus(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, _, done := newReputation(t, db)
+	defer done()
+
+	setOpsCount(t, db, testutils.ValidAddress3, 200, 0)
+	batch := []*userop.UserOperation{}
+	for i := 0; i < throttledEntityBundleCount+1; i++ {
+		batch = append(batch, mockOpWithPaymaster(common.BigToAddress(big.NewInt(int64(i+1)))))
+	}
+	banned := testutils.MockValidInitUserOp()
+	setOpsCount(t, db, banned.GetFactory(), 1000, 0)
+	batch = append(batch, banned)
+
+	ctx := modules.NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+	if err := rep.FilterByStatus()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(ctx.Batch) != throttledEntityBundleCount {
+		t.Fatalf("got batch length %d, want %d", len(ctx.Batch), throttledEntityBundleCount)
+	}
+	if len(ctx.PendingRemoval) != 1 || !testutils.IsOpsEqual(ctx.PendingRemoval[0], banned) {
+		t.Fatalf("got %d ops pending removal, want banned op", len(ctx.PendingRemoval))
+	}
+	if _, ok := ctx.GetRemovalReason(banned); !ok {
+		t.Fatal("got no removal reason, want reason")
+	}
+}
+
+// TestFilterByStatusPrunesRemovedOps calls (*Reputation).FilterByStatus and verifies that a throttled op is
+// no longer tracked once it has been removed from the mempool outside of a batch.
+func TestFilterByStatusPrunesRemovedOps(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, mem, done := newReputation(t, db)
+	defer done()
+
+	setOpsCount(t, db, testutils.ValidAddress3, 200, 0)
+	op := mockOpWithPaymaster(testutils.ValidAddress2)
+	if _, err := mem.AddOp(testutils.ValidAddress1, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	batch := []*userop.UserOperation{op}
+	ctx := modules.NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+	if err := rep.FilterByStatus()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if l := len(rep.firstSeen[testutils.ValidAddress1]); l != 1 {
+		t.Fatalf("got %d tracked ops, want 1", l)
+	}
+
+	if err := mem.RemoveOps(testutils.ValidAddress1, op); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	ctx = modules.NewBatchHandlerContext(nil, testutils.ValidAddress1, testutils.ChainID, nil, nil, nil)
+	if err := rep.FilterByStatus()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if l := len(rep.firstSeen[testutils.ValidAddress1]); l != 0 {
+		t.Fatalf("got %d tracked ops, want 0", l)
+	}
+}
+
+// TestIncOpsIncluded calls (*Reputation).IncOpsIncluded and verifies that included ops are counted for known
+// entities while dropped ops and unknown entities are skipped.
+func TestIncOpsIncluded(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, _, done := newReputation(t, db)
+	defer done()
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.Nonce = big.NewInt(1)
+	op3 := testutils.MockValidInitUserOp()
+	op3.Nonce = big.NewInt(2)
+	setOpsCount(t, db, op1.GetFactory(), 3, 0)
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2, op3},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	ctx.MarkOpIndexForRemoval(2, "dropped")
+	ctx.PendingRemoval = append(ctx.PendingRemoval, op2)
+	ctx.Batch = ctx.Batch[:1]
+	if err := rep.IncOpsIncluded()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if _, included := getOpsCount(t, db, op1.GetFactory()); included != 2 {
+		t.Fatalf("got factory opsIncluded %d, want 2", included)
+	}
+	if seen, included := getOpsCount(t, db, op1.Sender); seen != 0 || included != 0 {
+		t.Fatalf("got sender counts %d:%d, want 0:0", seen, included)
+	}
+}
+
+// TestSetEntriesAndDump calls (*Reputation).SetEntries and verifies that (*Reputation).Dump returns the
+// overridden counters with a derived status.
+func TestSetEntriesAndDump(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, _, done := newReputation(t, db)
+	defer done()
+
+	err := rep.SetEntries(
+		&Entry{Address: testutils.ValidAddress1, OpsSeen: 100, OpsIncluded: 10},
+		&Entry{Address: testutils.ValidAddress2, OpsSeen: 1000, OpsIncluded: 0, Status: "ok"},
+	)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	entries, err := rep.Dump()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	want := map[common.Address]string{
+		testutils.ValidAddress1: "ok",
+		testutils.ValidAddress2: "banned",
+	}
+	for _, e := range entries {
+		if e.Status != want[e.Address] {
+			t.Fatalf("got status %s for %s, want %s", e.Status, e.Address, want[e.Address])
+		}
+	}
+}
+
+// TestClear calls (*Reputation).Clear and verifies that all entities are removed.
+func TestClear(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, _, done := newReputation(t, db)
+	defer done()
+
+	setOpsCount(t, db, testutils.ValidAddress1, 1000, 0)
+	if err := rep.Clear(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if entries, err := rep.Dump(); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(entries) != 0 {
+		t.Fatalf("got %d entries, want 0", len(entries))
+	}
+}
+
+// TestCheckStatusAllowed calls (*Reputation).CheckStatus on an op with a banned factory that is exempt.
+// Expects nil.
+func TestCheckStatusAllowed(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	rep, _, done := newReputation(t, db)
+	defer done()
+
+	op := testutils.MockValidInitUserOp()
+	setOpsCount(t, db, op.GetFactory(), 1000, 0)
+	rep.SetIsAllowedFunc(func(entity common.Address) (bool, error) {
+		return entity == op.GetFactory(), nil
+	})
+
+	ctx := modules.NewUserOpHandlerContext(op, nil, testutils.ValidAddress1, testutils.ChainID)
+	if err := rep.CheckStatus()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
@@ -0,0 +1,292 @@
+package entities
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+type addressCounter map[common.Address]int
+
+type status int64
+
+const (
+	ok status = iota
+	throttled
+	banned
+)
+
+func (s status) String() string {
+	switch s {
+	case throttled:
+		return "throttled"
+	case banned:
+		return "banned"
+	default:
+		return "ok"
+	}
+}
+
+const minInclusionRateDenominator = 10
+const throttlingSlack = 10
+const banSlack = 50
+const emaHours = 24
+
+// throttledEntityMempoolCount is the max number of ops in the mempool that can reference a throttled entity.
+const throttledEntityMempoolCount = 4
+
+// throttledEntityBundleCount is the max number of ops in a single bundle that can reference a throttled
+// entity.
+const throttledEntityBundleCount = 4
+
+// throttledEntityLiveBlocks is the number of blocks an op referencing a throttled entity can stay in the
+// mempool before it is dropped.
+const throttledEntityLiveBlocks = 10
+
+var (
+	opsCountPrefix = dbutils.JoinValues("entity", "opsCount")
+
+	// legacyOpsCountPrefix was used when reputation was only tracked for paymasters.
+	legacyOpsCountPrefix = dbutils.JoinValues("paymaster", "opsCount")
+)
+
+func getOpsCountKey(entity common.Address) []byte {
+	return []byte(dbutils.JoinValues(opsCountPrefix, entity.String()))
+}
+
+// now returns the current time and is a variable so that tests can simulate the passing of time.
+var now = time.Now
+
+func getOpsCountValue(opsSeen int, opsIncluded int, lastUpdated int64) []byte {
+	return []byte(
+		dbutils.JoinValues(strconv.Itoa(opsSeen), strconv.Itoa(opsIncluded), fmt.Sprint(lastUpdated)),
+	)
+}
+
+// applyExpWeights decays the counters by 1/24 for every whole hour since they were last updated. The stored
+// timestamp is only advanced by the hours that were applied so that the remainder is carried over to the
+// next read. The entry is not rewritten if less than an hour has passed.
+func applyExpWeights(
+	txn *badger.Txn,
+	key []byte,
+	value []byte,
+) (opsSeen int, opsIncluded int, lastUpdated int64, err error) {
+	counts := dbutils.SplitValues(string(value))
+	opsSeen, err = strconv.Atoi(counts[0])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	opsIncluded, err = strconv.Atoi(counts[1])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	lastUpdated, err = strconv.ParseInt(counts[2], 10, 64)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	hours := int(now().Sub(time.Unix(lastUpdated, 0)).Hours())
+	if hours <= 0 {
+		return opsSeen, opsIncluded, lastUpdated, nil
+	}
+	for i := hours; i > 0; i-- {
+		if opsSeen < 24 && opsIncluded < 24 {
+			break
+		}
+
+		opsSeen -= opsSeen / emaHours
+		opsIncluded -= opsIncluded / emaHours
+	}
+	lastUpdated += int64(hours) * int64(time.Hour/time.Second)
+
+	e := badger.NewEntry(key, getOpsCountValue(opsSeen, opsIncluded, lastUpdated))
+	err = txn.SetEntry(e)
+
+	return opsSeen, opsIncluded, lastUpdated, err
+}
+
+// getOpsCountByEntity returns the decayed counters of an entity along with the time they were last decayed.
+// If the entity has not been seen, the counters are zero and lastUpdated is the current time.
+func getOpsCountByEntity(
+	txn *badger.Txn,
+	entity common.Address,
+) (opsSeen int, opsIncluded int, lastUpdated int64, found bool, err error) {
+	key := getOpsCountKey(entity)
+	item, err := txn.Get(key)
+	if err != nil && err == badger.ErrKeyNotFound {
+		return 0, 0, now().Unix(), false, nil
+	} else if err != nil {
+		return 0, 0, 0, false, err
+	}
+
+	var value []byte
+	err = item.Value(func(val []byte) error {
+		value = append([]byte{}, val...)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+
+	opsSeen, opsIncluded, lastUpdated, err = applyExpWeights(txn, key, value)
+	return opsSeen, opsIncluded, lastUpdated, true, err
+}
+
+func incrementOpsSeenByEntities(txn *badger.Txn, entities ...common.Address) error {
+	for _, entity := range entities {
+		opsSeen, opsIncluded, lastUpdated, _, err := getOpsCountByEntity(txn, entity)
+		if err != nil {
+			return err
+		}
+
+		e := badger.NewEntry(getOpsCountKey(entity), getOpsCountValue(opsSeen+1, opsIncluded, lastUpdated))
+		if err := txn.SetEntry(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// incrementOpsIncludedByEntities increments the opsIncluded counter for each entity. Entities that have never
+// been seen by the Client (e.g. an unstaked sender) are skipped.
+func incrementOpsIncludedByEntities(txn *badger.Txn, count addressCounter) error {
+	for entity, n := range count {
+		opsSeen, opsIncluded, lastUpdated, found, err := getOpsCountByEntity(txn, entity)
+		if err != nil {
+			return err
+		} else if !found {
+			continue
+		}
+
+		e := badger.NewEntry(getOpsCountKey(entity), getOpsCountValue(opsSeen, opsIncluded+n, lastUpdated))
+		if err := txn.SetEntry(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateLegacyOpsCounts moves paymaster counters stored under the legacy prefix to the entity prefix. A
+// legacy counter is discarded if the entity already has one.
+func migrateLegacyOpsCounts(txn *badger.Txn) error {
+	opts := badger.DefaultIteratorOptions
+	it := txn.NewIterator(opts)
+
+	prefix := []byte(legacyOpsCountPrefix)
+	keys := [][]byte{}
+	values := [][]byte{}
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		value, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			it.Close()
+			return err
+		}
+		keys = append(keys, it.Item().KeyCopy(nil))
+		values = append(values, value)
+	}
+	it.Close()
+
+	for i, key := range keys {
+		split := dbutils.SplitValues(string(key))
+		newKey := getOpsCountKey(common.HexToAddress(split[len(split)-1]))
+		if _, err := txn.Get(newKey); err == badger.ErrKeyNotFound {
+			if err := txn.Set(newKey, values[i]); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getStatus(txn *badger.Txn, entity common.Address) (status, error) {
+	opsSeen, opsIncluded, _, _, err := getOpsCountByEntity(txn, entity)
+	if err != nil {
+		return ok, err
+	}
+	return calcStatus(opsSeen, opsIncluded), nil
+}
+
+func calcStatus(opsSeen int, opsIncluded int) status {
+	if opsSeen == 0 {
+		return ok
+	}
+
+	minExpectedIncluded := opsSeen / minInclusionRateDenominator
+	if minExpectedIncluded <= opsIncluded+throttlingSlack {
+		return ok
+	} else if minExpectedIncluded <= opsIncluded+banSlack {
+		return throttled
+	} else {
+		return banned
+	}
+}
+
+func getAllEntities(txn *badger.Txn) []common.Address {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	prefix := []byte(opsCountPrefix)
+	entities := []common.Address{}
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		key := dbutils.SplitValues(string(it.Item().Key()))
+		entities = append(entities, common.HexToAddress(key[len(key)-1]))
+	}
+	return entities
+}
+
+func getAllEntries(txn *badger.Txn) ([]*Entry, error) {
+	entries := []*Entry{}
+	for _, entity := range getAllEntities(txn) {
+		opsSeen, opsIncluded, _, _, err := getOpsCountByEntity(txn, entity)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &Entry{
+			Address:     entity,
+			OpsSeen:     hexutil.Uint64(opsSeen),
+			OpsIncluded: hexutil.Uint64(opsIncluded),
+			Status:      calcStatus(opsSeen, opsIncluded).String(),
+		})
+	}
+
+	return entries, nil
+}
+
+// getEntities returns the factory, paymaster, and aggregator used by an op along with the sender. Any entity
+// that is not used is omitted.
+func getEntities(op *userop.UserOperation, aggregator common.Address) []common.Address {
+	entities := []common.Address{op.Sender}
+	for _, addr := range []common.Address{op.GetFactory(), op.GetPaymaster(), aggregator} {
+		if addr != common.HexToAddress("0x") {
+			entities = append(entities, addr)
+		}
+	}
+	return entities
+}
+
+// hasEntity returns true if the op references the given entity.
+func hasEntity(op *userop.UserOperation, aggregator common.Address, entity common.Address) bool {
+	for _, addr := range getEntities(op, aggregator) {
+		if addr == entity {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package expire
 
 import (
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -10,6 +11,7 @@ import (
 type ExpireHandler struct {
 	seenAt map[common.Hash]time.Time
 	ttl    time.Duration
+	mu     sync.Mutex
 }
 
 // New returns an ExpireHandler which contains a BatchHandlerFunc to track and drop UserOperations that have
@@ -31,7 +33,7 @@ func (e *ExpireHandler) DropExpired() modules.BatchHandlerFunc {
 			if seenAt, ok := e.seenAt[hash]; !ok {
 				e.seenAt[hash] = time.Now()
 			} else if seenAt.Add(e.ttl).Before(time.Now()) {
-				ctx.MarkOpIndexForRemoval(i)
+				ctx.MarkOpIndexForRemoval(i, "expired")
 			}
 		}
 		return nil
@@ -0,0 +1,98 @@
+package gasprice
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Fees is a suggested maxFeePerGas and maxPriorityFeePerGas for a UserOperation. PreVerificationGas is only
+// set if the suggestion was made for a specific UserOperation. It includes the L1 data cost on rollups which
+// depends on the suggested fees.
+type Fees struct {
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas"`
+	PreVerificationGas   *big.Int `json:"preVerificationGas,omitempty"`
+}
+
+// FeeSuggestion is a set of fees for a UserOperation in increasing order of inclusion speed. BaseFee is nil
+// on networks that do not support EIP-1559.
+type FeeSuggestion struct {
+	BaseFee  *big.Int `json:"baseFee,omitempty"`
+	Slow     *Fees    `json:"slow"`
+	Standard *Fees    `json:"standard"`
+	Fast     *Fees    `json:"fast"`
+}
+
+// Tiers returns all suggested fees from slowest to fastest.
+func (s *FeeSuggestion) Tiers() []*Fees {
+	return []*Fees{s.Slow, s.Standard, s.Fast}
+}
+
+type tier struct {
+	// tipPercent is the percentage of the suggested gas tip or legacy gas price to use.
+	tipPercent int64
+
+	// baseFeeEighths is the multiple of basefee in eighths to allow for. Basefee can increase by at most 1/8
+	// per block.
+	baseFeeEighths int64
+}
+
+var (
+	// slowTier allows for basefee to increase for 1 block.
+	slowTier = tier{tipPercent: 100, baseFeeEighths: 9}
+
+	// standardTier allows for 2x basefee which matches the max fee required by the bundler for its own
+	// transaction in transaction.SuggestMeanGasFeeCap.
+	standardTier = tier{tipPercent: 110, baseFeeEighths: 16}
+
+	// fastTier is the same as standardTier with a higher tip.
+	fastTier = tier{tipPercent: 150, baseFeeEighths: 16}
+)
+
+func percent(v *big.Int, p int64) *big.Int {
+	return big.NewInt(0).Div(big.NewInt(0).Mul(v, big.NewInt(p)), big.NewInt(100))
+}
+
+func (t tier) dynamicFees(bf *big.Int, tip *big.Int) *Fees {
+	mpf := percent(tip, t.tipPercent)
+	mbf := big.NewInt(0).Div(big.NewInt(0).Mul(bf, big.NewInt(t.baseFeeEighths)), big.NewInt(8))
+	return &Fees{
+		MaxFeePerGas:         big.NewInt(0).Add(mbf, mpf),
+		MaxPriorityFeePerGas: mpf,
+	}
+}
+
+func (t tier) legacyFees(gp *big.Int) *Fees {
+	p := percent(gp, t.tipPercent)
+	return &Fees{
+		MaxFeePerGas:         p,
+		MaxPriorityFeePerGas: big.NewInt(0).Set(p),
+	}
+}
+
+// SuggestFees returns slow, standard, and fast fees for a UserOperation given the current basefee and gas tip.
+// Every tier is at least the effective gas price expected by the bundler so that ops are not considered
+// underpriced. If basefee is nil, the legacy gas price is used instead and maxFeePerGas is equal to
+// maxPriorityFeePerGas as required by checks.ValidateFeePerGas.
+func SuggestFees(bf *big.Int, tip *big.Int, gp *big.Int) (*FeeSuggestion, error) {
+	tiers := []tier{slowTier, standardTier, fastTier}
+	fees := []*Fees{}
+	if bf != nil && tip != nil {
+		for _, t := range tiers {
+			fees = append(fees, t.dynamicFees(bf, tip))
+		}
+	} else if gp != nil {
+		for _, t := range tiers {
+			fees = append(fees, t.legacyFees(gp))
+		}
+	} else {
+		return nil, errors.New("gasprice: fee suggestion is not supported")
+	}
+
+	return &FeeSuggestion{
+		BaseFee:  bf,
+		Slow:     fees[0],
+		Standard: fees[1],
+		Fast:     fees[2],
+	}, nil
+}
@@ -0,0 +1,75 @@
+package gasprice_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules/gasprice"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestSuggestFeesDynamic verifies that every suggested tier is in increasing order and that a UserOperation
+// using any tier is not filtered out by FilterUnderpriced.
+func TestSuggestFeesDynamic(t *testing.T) {
+	bf := big.NewInt(100)
+	tip := big.NewInt(10)
+	fees, err := gasprice.SuggestFees(bf, tip, nil)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if fees.Standard.MaxFeePerGas.Cmp(big.NewInt(211)) != 0 {
+		t.Fatalf("got standard maxFeePerGas %s, want 211", fees.Standard.MaxFeePerGas)
+	}
+
+	batch := []*userop.UserOperation{}
+	for i, f := range fees.Tiers() {
+		if i > 0 && f.MaxPriorityFeePerGas.Cmp(fees.Tiers()[i-1].MaxPriorityFeePerGas) < 0 {
+			t.Fatalf("got tier %d maxPriorityFeePerGas lower than previous tier", i)
+		}
+		if f.MaxPriorityFeePerGas.Cmp(f.MaxFeePerGas) > 0 {
+			t.Fatalf("got tier %d maxPriorityFeePerGas greater than maxFeePerGas", i)
+		}
+
+		op := testutils.MockValidInitUserOp()
+		op.MaxFeePerGas = f.MaxFeePerGas
+		op.MaxPriorityFeePerGas = f.MaxPriorityFeePerGas
+		batch = append(batch, op)
+	}
+
+	ctx := modules.NewBatchHandlerContext(batch, testutils.ValidAddress1, testutils.ChainID, bf, tip, nil)
+	if err := gasprice.FilterUnderpriced()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if len(ctx.Batch) != len(batch) {
+		t.Fatalf("got batch length %d, want %d", len(ctx.Batch), len(batch))
+	}
+}
+
+// TestSuggestFeesLegacy verifies that maxFeePerGas is equal to maxPriorityFeePerGas for every tier if basefee
+// is not supported.
+func TestSuggestFeesLegacy(t *testing.T) {
+	gp := big.NewInt(100)
+	fees, err := gasprice.SuggestFees(nil, nil, gp)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	for i, f := range fees.Tiers() {
+		if f.MaxFeePerGas.Cmp(f.MaxPriorityFeePerGas) != 0 {
+			t.Fatalf("got tier %d maxFeePerGas not equal to maxPriorityFeePerGas", i)
+		}
+		if f.MaxFeePerGas.Cmp(gp) < 0 {
+			t.Fatalf("got tier %d maxFeePerGas %s, want at least %s", i, f.MaxFeePerGas, gp)
+		}
+	}
+}
+
+// TestSuggestFeesNotSupported verifies that an error is returned if neither basefee nor gas price is
+// available.
+func TestSuggestFeesNotSupported(t *testing.T) {
+	if _, err := gasprice.SuggestFees(nil, nil, nil); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
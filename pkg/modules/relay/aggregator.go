@@ -0,0 +1,13 @@
+package relay
+
+import (
+	"github.com/stackup-wallet/stackup-bundler/pkg/aggregator"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+)
+
+// getOpsPerAggregator splits a batch that has already been grouped by aggregator into the format expected by
+// handleAggregatedOps() using the relayer's AggregateSignaturesFunc.
+func (r *Relayer) getOpsPerAggregator(ctx *modules.BatchHandlerCtx) ([]transaction.UserOpsPerAggregator, error) {
+	return aggregator.GetOpsPerAggregator(ctx, r.aggregate)
+}
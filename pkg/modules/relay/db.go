@@ -0,0 +1,123 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+var (
+	keyPrefix      = dbutils.JoinValues("relayer")
+	inflightPrefix = dbutils.JoinValues(keyPrefix, "inflight")
+)
+
+// inflightTx is a handleOps transaction from the relayer EOA that has been sent but is not yet final.
+type inflightTx struct {
+	From         common.Address    `json:"from"`
+	Nonce        uint64            `json:"nonce"`
+	EntryPoint   common.Address    `json:"entryPoint"`
+	TxHashes     []common.Hash     `json:"txHashes"`
+	CancelHashes []common.Hash     `json:"cancelHashes"`
+	UserOpHashes []common.Hash     `json:"userOpHashes"`
+	UserOps      []json.RawMessage `json:"userOps"`
+	To           common.Address    `json:"to"`
+	Data         hexutil.Bytes     `json:"data"`
+	GasLimit     uint64            `json:"gasLimit"`
+	Fees         transaction.Fees  `json:"fees"`
+	SentAt       uint64            `json:"sentAt"`
+}
+
+// isCancelled returns true if the handleOps transaction has been replaced with a self transfer.
+func (i *inflightTx) isCancelled() bool {
+	return len(i.CancelHashes) > 0
+}
+
+// getUserOps decodes the UserOperations in the transaction.
+func (i *inflightTx) getUserOps() ([]*userop.UserOperation, error) {
+	ops := []*userop.UserOperation{}
+	for _, raw := range i.UserOps {
+		data := make(map[string]any)
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, err
+		}
+
+		op, err := userop.New(data)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func encodeUserOps(ops []*userop.UserOperation) ([]json.RawMessage, error) {
+	raw := []json.RawMessage{}
+	for _, op := range ops {
+		data, err := op.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, data)
+	}
+	return raw, nil
+}
+
+func getInflightKey(from common.Address, nonce uint64) []byte {
+	return []byte(dbutils.JoinValues(inflightPrefix, from.String(), fmt.Sprint(nonce)))
+}
+
+func saveInflightTx(db *badger.DB, tx *inflightTx) error {
+	return db.Update(func(txn *badger.Txn) error {
+		data, err := json.Marshal(tx)
+		if err != nil {
+			return err
+		}
+
+		return txn.Set(getInflightKey(tx.From, tx.Nonce), data)
+	})
+}
+
+// getInflightTxs returns all saved transactions from every EOA in ascending nonce order.
+func getInflightTxs(db *badger.DB) ([]*inflightTx, error) {
+	txs := []*inflightTx{}
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		it := txn.NewIterator(opts)
+		prefix := []byte(inflightPrefix + ":")
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var tx inflightTx
+				if err := json.Unmarshal(val, &tx); err != nil {
+					return err
+				}
+				txs = append(txs, &tx)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Nonce < txs[j].Nonce })
+	return txs, nil
+}
+
+func removeInflightTx(db *badger.DB, from common.Address, nonce uint64) error {
+	return db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(getInflightKey(from, nonce))
+	})
+}
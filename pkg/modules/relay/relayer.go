@@ -3,13 +3,17 @@
 package relay
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
+	"sync"
 	"time"
 
+	"github.com/dgraph-io/badger/v3"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/pkg/aggregator"
 	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
 	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
 	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
@@ -25,47 +29,125 @@ import (
 // propagated through the network and it is impossible to prevent collisions from multiple bundlers trying to
 // relay the same ops.
 type Relayer struct {
-	eoa         *signer.EOA
-	eth         *ethclient.Client
-	chainID     *big.Int
-	beneficiary common.Address
-	logger      logr.Logger
-	waitTimeout time.Duration
+	db                  *badger.DB
+	eoa                 *signer.Provider
+	eth                 *ethclient.Client
+	chainID             *big.Int
+	beneficiary         common.Address
+	logger              logr.Logger
+	waitTimeout         time.Duration
+	resubmitAfterBlocks uint64
+	finalityBlocks      uint64
+	aggregate           aggregator.AggregateSignaturesFunc
+	signers             *signer.Pool
+	retiring            map[common.Address]*signer.EOA
+	retireInterval      time.Duration
+	mu                  sync.Mutex
+	eoaMu               sync.RWMutex
 }
 
 // New initializes a new EOA relayer for sending batches to the EntryPoint.
 func New(
-	eoa *signer.EOA,
+	db *badger.DB,
+	eoa *signer.Provider,
 	eth *ethclient.Client,
 	chainID *big.Int,
 	beneficiary common.Address,
 	l logr.Logger,
 ) *Relayer {
 	return &Relayer{
-		eoa:         eoa,
-		eth:         eth,
-		chainID:     chainID,
-		beneficiary: beneficiary,
-		logger:      l.WithName("relayer"),
-		waitTimeout: DefaultWaitTimeout,
+		db:                  db,
+		eoa:                 eoa,
+		eth:                 eth,
+		chainID:             chainID,
+		beneficiary:         beneficiary,
+		logger:              l.WithName("relayer"),
+		waitTimeout:         DefaultWaitTimeout,
+		resubmitAfterBlocks: DefaultResubmitAfterBlocks,
+		finalityBlocks:      DefaultFinalityBlocks,
+		aggregate:           aggregator.NoopAggregateSignaturesFunc(),
+		signers:             nil,
+		retiring:            make(map[common.Address]*signer.EOA),
+		retireInterval:      DefaultRetireInterval,
 	}
 }
 
-// SetWaitTimeout sets the total time to wait for a transaction to be included. When a timeout is reached, the
-// BatchHandler will throw an error if the transaction has not been included or has been included but with a
-// failed status.
+// SetWaitTimeout sets the total time to wait for a transaction to be included. The BatchHandler will throw an
+// error if the transaction has been included but with a failed status. When a timeout is reached, or the
+// transaction is not yet final, the transaction is tracked on future runs by TrackTransactions and its batch
+// stays in the mempool until a final receipt is seen.
 //
-// The default value is 30 seconds. Setting the value to 0 will skip waiting for a transaction to be included.
+// The default value is 30 seconds. Setting the value to 0 will skip waiting for a transaction to be included
+// and the transaction will not be tracked. All ops in the batch will be dropped regardless of the outcome.
 func (r *Relayer) SetWaitTimeout(timeout time.Duration) {
 	r.waitTimeout = timeout
 }
 
+// SetResubmitAfterBlocks sets the number of blocks to wait for a tracked transaction to be included before it
+// is resubmitted with bumped fees. The default value is 3.
+func (r *Relayer) SetResubmitAfterBlocks(blocks uint64) {
+	r.resubmitAfterBlocks = blocks
+}
+
+// SetFinalityBlocks sets the number of blocks that must follow the block including a tracked transaction for
+// its receipt to be considered final. The default value is 0 (i.e. final once included).
+func (r *Relayer) SetFinalityBlocks(blocks uint64) {
+	r.finalityBlocks = blocks
+}
+
+// SetSignerPool defines the pool of EOAs that the Bundler assigns batches to. Each batch is sent from the EOA
+// set on the BatchHandlerCtx and the nonce and balance of that EOA are recorded in the pool after sending. If
+// a batch has no assigned EOA, the relayer's own EOA is used.
+func (r *Relayer) SetSignerPool(pool *signer.Pool) {
+	r.signers = pool
+}
+
+// getSigner returns the EOA used to send a batch.
+func (r *Relayer) getSigner(ctx *modules.BatchHandlerCtx) *signer.EOA {
+	if ctx.Signer != nil {
+		return ctx.Signer
+	}
+	return r.getEOA()
+}
+
+// getEOA returns the relayer's default EOA.
+func (r *Relayer) getEOA() *signer.EOA {
+	r.eoaMu.RLock()
+	defer r.eoaMu.RUnlock()
+
+	return r.eoa.Get()
+}
+
+// lookupSigner returns the EOA with the given address or nil if the relayer does not hold it.
+func (r *Relayer) lookupSigner(addr common.Address) *signer.EOA {
+	r.eoaMu.RLock()
+	eoa, retiring := r.eoa.Get(), r.retiring[addr]
+	r.eoaMu.RUnlock()
+	if addr == eoa.Address {
+		return eoa
+	} else if retiring != nil {
+		return retiring
+	}
+	if r.signers != nil {
+		return r.signers.Get(addr)
+	}
+	return nil
+}
+
+// SetAggregateSignaturesFunc defines the function used to combine the signatures of UserOperations that
+// share the same aggregator. By default, batches with aggregated UserOperations are not supported and those
+// UserOperations will be dropped.
+func (r *Relayer) SetAggregateSignaturesFunc(fn aggregator.AggregateSignaturesFunc) {
+	r.aggregate = fn
+}
+
 // SendUserOperation returns a BatchHandler that is used by the Bundler to send batches in a regular EOA
 // transaction.
 func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
 	return func(ctx *modules.BatchHandlerCtx) error {
+		eoa := r.getSigner(ctx)
 		opts := transaction.Opts{
-			EOA:         r.eoa,
+			EOA:         eoa,
 			Eth:         r.eth,
 			ChainID:     ctx.ChainID,
 			EntryPoint:  ctx.EntryPoint,
@@ -75,7 +157,7 @@ func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
 			Tip:         ctx.Tip,
 			GasPrice:    ctx.GasPrice,
 			GasLimit:    0,
-			WaitTimeout: r.waitTimeout,
+			WaitTimeout: 0,
 		}
 		// r.logger.Info("Sending batch to EntryPoint", map[string]int{
 		// 	"batch_size": len(ctx.Batch),
@@ -84,13 +166,26 @@ func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
 		// Estimate gas for handleOps() and drop all userOps that cause unexpected reverts.
 		estRev := []string{}
 		for len(ctx.Batch) > 0 {
+			opts.Batch = ctx.Batch
+			opts.OpsPerAggregator = nil
+			if ctx.HasAggregators() {
+				opa, err := r.getOpsPerAggregator(ctx)
+				if errors.Is(err, aggregator.ErrReverted) {
+					estRev = append(estRev, err.Error())
+					continue
+				} else if err != nil {
+					return err
+				}
+				opts.OpsPerAggregator = opa
+			}
+
 			r.logger.Info(fmt.Sprintf("Sending batch to EntryPoint, batch_size: %d", len(ctx.Batch)))
 			est, revert, err := transaction.EstimateHandleOpsGas(&opts)
 
 			if err != nil {
 				return err
 			} else if revert != nil {
-				ctx.MarkOpIndexForRemoval(revert.OpIndex)
+				ctx.MarkOpIndexForRemoval(revert.OpIndex, revert.Reason)
 				estRev = append(estRev, revert.Reason)
 			} else {
 				opts.GasLimit = est + 1500000
@@ -102,13 +197,37 @@ func (r *Relayer) SendUserOperation() modules.BatchHandlerFunc {
 		// Call handleOps() with gas estimate. Any userOps that cause a revert at this stage will be
 		// caught and dropped in the next iteration.
 		if len(ctx.Batch) > 0 {
-			if txn, err := transaction.HandleOps(&opts); err != nil {
+			send := transaction.HandleOps
+			if len(opts.OpsPerAggregator) > 0 {
+				send = transaction.HandleAggregatedOps
+			}
+
+			if r.waitTimeout > 0 {
+				nonce, err := r.GetNextNonce(eoa.Address)
+				if err != nil {
+					return err
+				}
+				opts.Nonce = nonce
+			}
+
+			txn, err := send(&opts)
+			if err != nil {
+				return err
+			}
+			ctx.Data["txn_hash"] = txn.Hash().String()
+			ctx.Data["txn_signer"] = eoa.Address.String()
+			r.logger.Info("Batch sent to EntryPoint", "txn_hash", ctx.Data["txn_hash"], "signer", eoa.Address.String())
+			if err := r.recordSignerState(eoa, txn.Nonce()+1); err != nil {
 				return err
-			} else {
-				ctx.Data["txn_hash"] = txn.Hash().String()
 			}
 
-			r.logger.Info("Batch sent to EntryPoint", "txn_hash", ctx.Data["txn_hash"])
+			if r.waitTimeout > 0 {
+				if final, err := r.waitForFinality(ctx, txn); err != nil {
+					return err
+				} else if !final {
+					ctx.MarkBatchPendingInclusion()
+				}
+			}
 		}
 
 		return nil
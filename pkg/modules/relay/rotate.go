@@ -0,0 +1,146 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// SetRetireInterval defines the time between each check for whether a rotated EOA has no more pending
+// transactions. The default value is 5 seconds.
+func (r *Relayer) SetRetireInterval(interval time.Duration) {
+	r.retireInterval = interval
+}
+
+// RotateSigner replaces the relayer's default EOA with next. The shared signer Provider is updated so that
+// every other component using it will also switch to next. New batches are no longer assigned to the
+// previous EOA but it is kept until all of its pending handleOps transactions have been confirmed so that they
+// can still be resubmitted or cancelled by TrackTransactions. Once drained, the previous EOA is removed and,
+// if sweep is true, its remaining balance is transferred to next.
+//
+// The returned channel receives the outcome of retiring the previous EOA and is closed afterwards.
+func (r *Relayer) RotateSigner(next *signer.EOA, sweep bool) (<-chan error, error) {
+	r.eoaMu.Lock()
+	prev := r.eoa.Get()
+	if next.Address == prev.Address {
+		r.eoaMu.Unlock()
+		return nil, errors.New("relay: signer is already in use")
+	} else if _, ok := r.retiring[next.Address]; ok {
+		r.eoaMu.Unlock()
+		return nil, errors.New("relay: signer is being retired")
+	}
+	r.eoa.Set(next)
+	r.retiring[prev.Address] = prev
+	r.eoaMu.Unlock()
+
+	if r.signers != nil {
+		r.signers.Add(next)
+		r.signers.Retire(prev.Address)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer close(done)
+
+		l := r.logger.WithValues("prev_signer", prev.Address.String(), "next_signer", next.Address.String())
+		l.Info("signer rotation started")
+		err := r.retire(prev, next, sweep)
+		if err != nil {
+			l.Error(err, "signer rotation error")
+		} else {
+			l.Info("signer rotation completed")
+		}
+		done <- err
+	}()
+	return done, nil
+}
+
+// retire blocks until the EOA has no pending transactions and then removes it from the relayer.
+func (r *Relayer) retire(prev *signer.EOA, next *signer.EOA, sweep bool) error {
+	ticker := time.NewTicker(r.retireInterval)
+	defer ticker.Stop()
+	for {
+		drained, err := r.isDrained(prev)
+		if err != nil {
+			r.logger.Error(err, "signer rotation check error", "prev_signer", prev.Address.String())
+		} else if drained {
+			break
+		}
+		<-ticker.C
+	}
+
+	if sweep {
+		if err := r.sweep(prev, next); err != nil {
+			return err
+		}
+	}
+
+	if r.signers != nil {
+		r.signers.Remove(prev.Address)
+	}
+	r.eoaMu.Lock()
+	delete(r.retiring, prev.Address)
+	r.eoaMu.Unlock()
+	return nil
+}
+
+// isDrained returns true if the EOA is not sending a batch, has no tracked transactions, and has no pending
+// transactions in the node's txpool.
+func (r *Relayer) isDrained(eoa *signer.EOA) (bool, error) {
+	if r.signers != nil && r.signers.IsBusy(eoa.Address) {
+		return false, nil
+	}
+
+	txs, err := getInflightTxs(r.db)
+	if err != nil {
+		return false, err
+	}
+	for _, tx := range txs {
+		if tx.From == eoa.Address {
+			return false, nil
+		}
+	}
+
+	pending, err := r.eth.PendingNonceAt(context.Background(), eoa.Address)
+	if err != nil {
+		return false, err
+	}
+	confirmed, err := r.eth.NonceAt(context.Background(), eoa.Address, nil)
+	if err != nil {
+		return false, err
+	}
+	return pending == confirmed, nil
+}
+
+// sweep transfers the full balance of prev, less the cost of the transfer, to next.
+func (r *Relayer) sweep(prev *signer.EOA, next *signer.EOA) error {
+	fees, err := transaction.SuggestFees(r.eth)
+	if err != nil {
+		return err
+	}
+	bal, err := r.eth.BalanceAt(context.Background(), prev.Address, nil)
+	if err != nil {
+		return err
+	}
+	amount := big.NewInt(0).Sub(bal, fees.MaxCost(transaction.TransferGasLimit))
+	if amount.Sign() <= 0 {
+		return nil
+	}
+
+	txn, err := transaction.Transfer(prev, r.eth, r.chainID, next.Address, amount, fees)
+	if err != nil {
+		return err
+	}
+	r.logger.Info(
+		"signer balance swept",
+		"prev_signer", prev.Address.String(),
+		"next_signer", next.Address.String(),
+		"amount", amount.String(),
+		"txn_hash", txn.Hash().String(),
+	)
+	return nil
+}
@@ -0,0 +1,53 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+)
+
+// TestRotateSignerUpdatesProvider calls (*Relayer).RotateSigner and verifies that the shared signer Provider
+// returns the new EOA so that other components using it switch over as well.
+func TestRotateSignerUpdatesProvider(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	srv := testutils.EthMock(testutils.MethodMocks{
+		"eth_getTransactionCount": "0x0",
+	})
+	defer srv.Close()
+	eth, err := ethclient.Dial(srv.URL)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	eoas := []*signer.EOA{}
+	for i := 0; i < 2; i++ {
+		pk, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		eoas = append(eoas, signer.NewEOA(signer.NewLocalSigner(pk)))
+	}
+	current := signer.NewProvider(eoas[0])
+	r := New(db, current, eth, testutils.ChainID, testutils.ValidAddress1, logr.Discard())
+	r.SetRetireInterval(time.Millisecond)
+
+	done, err := r.RotateSigner(eoas[1], false)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if got := current.Get(); got != eoas[1] {
+		t.Fatalf("got %s, want %s", got.Address, eoas[1].Address)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if r.lookupSigner(eoas[0].Address) != nil {
+		t.Fatal("got previous signer, want removed after retiring")
+	}
+}
@@ -0,0 +1,297 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stackup-wallet/stackup-bundler/pkg/entrypoint/transaction"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/signer"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+type nonceLane struct {
+	sender common.Address
+	key    string
+}
+
+// TrackTransactions returns a BatchHandler that is used by the Bundler to reconcile handleOps transactions
+// from previous runs that are still in flight. This module should be used as a tracker module so that it
+// runs on every trigger regardless of whether a new batch is ready.
+//
+// For each tracked transaction:
+//
+//   - If a receipt is final with a success status, its UserOperations are dropped from the mempool.
+//   - If a receipt is final with a failed status or for a cancellation, its UserOperations are released back
+//     to the mempool.
+//   - If no receipt is found after the resubmit interval, the transaction is resent with bumped fees. If the
+//     batch would now revert, the transaction is cancelled with a zero value transfer to the EOA instead.
+func (r *Relayer) TrackTransactions() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		txs, err := getInflightTxs(r.db)
+		if err != nil {
+			return err
+		}
+		if len(txs) == 0 {
+			return nil
+		}
+
+		head, err := r.eth.BlockNumber(context.Background())
+		if err != nil {
+			return err
+		}
+
+		confirmed := make(map[common.Address]uint64)
+		for _, tx := range txs {
+			if tx.EntryPoint != ctx.EntryPoint {
+				continue
+			}
+			if _, ok := confirmed[tx.From]; !ok {
+				nonce, err := r.eth.NonceAt(context.Background(), tx.From, nil)
+				if err != nil {
+					return err
+				}
+				confirmed[tx.From] = nonce
+			}
+
+			if err := r.reconcile(ctx, tx, head, confirmed[tx.From]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ExcludeInflight returns a BatchHandler that is used by the Bundler to exclude UserOperations in a pending
+// transaction from the current batch. Any other UserOperation with the same sender and nonce key as one in a
+// pending transaction is also excluded since it can't be valid until the pending one is included. This
+// module should be used first.
+func (r *Relayer) ExcludeInflight() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		txs, err := getInflightTxs(r.db)
+		if err != nil {
+			return err
+		}
+
+		inflight := make(map[nonceLane]bool)
+		for _, tx := range txs {
+			if tx.EntryPoint != ctx.EntryPoint {
+				continue
+			}
+			ops, err := tx.getUserOps()
+			if err != nil {
+				return err
+			}
+			for _, op := range ops {
+				inflight[nonceLane{op.Sender, op.GetNonceKey().String()}] = true
+			}
+		}
+
+		batch := []*userop.UserOperation{}
+		for _, op := range ctx.Batch {
+			if !inflight[nonceLane{op.Sender, op.GetNonceKey().String()}] {
+				batch = append(batch, op)
+			}
+		}
+		ctx.Batch = batch
+		return nil
+	}
+}
+
+// getReceipt returns the receipt of any transaction sent for the tracked nonce. A nil receipt is returned if
+// none have been included yet.
+func (r *Relayer) getReceipt(tx *inflightTx) (*types.Receipt, error) {
+	hashes := append([]common.Hash{}, tx.TxHashes...)
+	hashes = append(hashes, tx.CancelHashes...)
+	for _, hash := range hashes {
+		receipt, err := r.eth.TransactionReceipt(context.Background(), hash)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return receipt, nil
+	}
+	return nil, nil
+}
+
+// reconcile checks the status of a tracked transaction and stops tracking it once it is final or its nonce
+// has been used.
+func (r *Relayer) reconcile(
+	ctx *modules.BatchHandlerCtx,
+	tx *inflightTx,
+	head uint64,
+	confirmed uint64,
+) error {
+	l := r.logger.WithValues("signer", tx.From.String(), "nonce", tx.Nonce)
+	receipt, err := r.getReceipt(tx)
+	if err != nil {
+		return err
+	}
+
+	if receipt != nil {
+		if head < receipt.BlockNumber.Uint64()+r.finalityBlocks {
+			return nil
+		}
+
+		if receipt.Status == types.ReceiptStatusSuccessful && containsHash(tx.TxHashes, receipt.TxHash) {
+			ops, err := tx.getUserOps()
+			if err != nil {
+				return err
+			}
+			ctx.PendingRemoval = append(ctx.PendingRemoval, ops...)
+		}
+		l.Info("Tracked transaction final", "txn_hash", receipt.TxHash.String(), "status", receipt.Status)
+		return removeInflightTx(r.db, tx.From, tx.Nonce)
+	} else if confirmed > tx.Nonce {
+		// Nonce was used by a transaction that is not tracked.
+		l.Info("Tracked transaction nonce used by unknown transaction")
+		return removeInflightTx(r.db, tx.From, tx.Nonce)
+	} else if head < tx.SentAt+r.resubmitAfterBlocks {
+		return nil
+	}
+
+	eoa := r.lookupSigner(tx.From)
+	if eoa == nil {
+		l.Info("Tracked transaction signer not found, skipping resubmit")
+		return nil
+	}
+
+	fees := transaction.BumpFees(tx.Fees, ctx.BaseFee, ctx.Tip, ctx.GasPrice)
+	cancel := tx.isCancelled()
+	if !cancel {
+		_, err := r.eth.EstimateGas(context.Background(), ethereum.CallMsg{
+			From: eoa.Address,
+			To:   &tx.To,
+			Data: tx.Data,
+		})
+		cancel = err != nil
+	}
+
+	var sent *types.Transaction
+	if cancel {
+		sent, err = transaction.Cancel(eoa, r.eth, r.chainID, tx.Nonce, fees)
+	} else {
+		sent, err = transaction.SendWithNonce(eoa, r.eth, r.chainID, tx.Nonce, tx.To, tx.Data, tx.GasLimit, fees)
+	}
+	if err != nil {
+		// The previous transaction may have been included in the meantime. Try again on the next run.
+		l.Error(err, "Tracked transaction resubmit error")
+		return nil
+	}
+
+	if cancel {
+		tx.CancelHashes = append(tx.CancelHashes, sent.Hash())
+		l.Info("Tracked transaction cancelled", "txn_hash", sent.Hash().String())
+	} else {
+		tx.TxHashes = append(tx.TxHashes, sent.Hash())
+		l.Info("Tracked transaction resubmitted", "txn_hash", sent.Hash().String())
+	}
+	tx.Fees = fees
+	tx.SentAt = head
+	return saveInflightTx(r.db, tx)
+}
+
+// GetNextNonce returns the nonce for the next transaction from the EOA accounting for all its tracked
+// transactions and the last nonce recorded for it in the signer pool. Other senders of transactions from a
+// pool EOA should use this to avoid replacing a batch that is still in flight.
+func (r *Relayer) GetNextNonce(from common.Address) (*big.Int, error) {
+	nonce, err := r.eth.NonceAt(context.Background(), from, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.signers != nil {
+		if recorded, ok := r.signers.GetNonce(from); ok && recorded > nonce {
+			nonce = recorded
+		}
+	}
+
+	txs, err := getInflightTxs(r.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range txs {
+		if tx.From == from && tx.Nonce >= nonce {
+			nonce = tx.Nonce + 1
+		}
+	}
+	return big.NewInt(0).SetUint64(nonce), nil
+}
+
+// waitForFinality waits for a sent transaction to be included until the wait timeout. If the transaction is
+// not final by then, it is saved for tracking on future runs and false is returned.
+func (r *Relayer) waitForFinality(ctx *modules.BatchHandlerCtx, txn *types.Transaction) (bool, error) {
+	wctx, cancel := context.WithTimeout(context.Background(), r.waitTimeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(wctx, r.eth, txn)
+	if err == nil && receipt.Status == types.ReceiptStatusFailed {
+		// Return an error here so that the current batch stays in the mempool. In the next bundler iteration,
+		// the offending userOps will be dropped during gas estimation.
+		return false, errors.New("transaction: failed status")
+	} else if err == nil && r.finalityBlocks == 0 {
+		return true, nil
+	}
+
+	head, err := r.eth.BlockNumber(context.Background())
+	if err != nil {
+		return false, err
+	}
+	ops, err := encodeUserOps(ctx.Batch)
+	if err != nil {
+		return false, err
+	}
+	hashes := []common.Hash{}
+	for _, op := range ctx.Batch {
+		hashes = append(hashes, op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID))
+	}
+
+	return false, saveInflightTx(r.db, &inflightTx{
+		From:         r.getSigner(ctx).Address,
+		Nonce:        txn.Nonce(),
+		EntryPoint:   ctx.EntryPoint,
+		TxHashes:     []common.Hash{txn.Hash()},
+		CancelHashes: []common.Hash{},
+		UserOpHashes: hashes,
+		UserOps:      ops,
+		To:           *txn.To(),
+		Data:         txn.Data(),
+		GasLimit:     txn.Gas(),
+		Fees:         transaction.GetFees(txn),
+		SentAt:       head,
+	})
+}
+
+// recordSignerState updates the nonce and balance of an EOA in the signer pool after a transaction is sent.
+func (r *Relayer) recordSignerState(eoa *signer.EOA, nonce uint64) error {
+	if r.signers == nil {
+		return nil
+	}
+
+	bal, err := r.eth.BalanceAt(context.Background(), eoa.Address, nil)
+	if err != nil {
+		return err
+	}
+	r.signers.SetNonce(eoa.Address, nonce)
+	r.signers.SetBalance(eoa.Address, bal)
+	return nil
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
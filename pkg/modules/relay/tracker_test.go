@@ -0,0 +1,80 @@
+package relay
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/go-logr/logr"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestExcludeInflightLanes calls (*Relayer).ExcludeInflight with a tracked transaction that is not yet
+// included. Expects every op with the same sender and nonce key as an op in that transaction to be excluded
+// from the batch while ops from other lanes remain.
+func TestExcludeInflightLanes(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	srv := testutils.EthMock(testutils.MethodMocks{
+		"eth_blockNumber":           "0x1",
+		"eth_getTransactionCount":   "0x0",
+		"eth_getTransactionReceipt": nil,
+	})
+	defer srv.Close()
+	eth, err := ethclient.Dial(srv.URL)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	ep := testutils.ValidAddress1
+
+	sent := testutils.MockValidInitUserOp()
+	next := testutils.MockValidInitUserOp()
+	next.Nonce = big.NewInt(1)
+	otherKey := testutils.MockValidInitUserOp()
+	otherKey.Nonce = big.NewInt(0).Lsh(big.NewInt(1), 64)
+	otherSender := testutils.MockValidInitUserOp()
+	otherSender.Sender = testutils.ValidAddress3
+
+	raw, err := encodeUserOps([]*userop.UserOperation{sent})
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if err := saveInflightTx(db, &inflightTx{
+		From:         testutils.ValidAddress2,
+		Nonce:        0,
+		EntryPoint:   ep,
+		TxHashes:     []common.Hash{common.HexToHash("0x01")},
+		CancelHashes: []common.Hash{},
+		UserOpHashes: []common.Hash{sent.GetUserOpHash(ep, testutils.ChainID)},
+		UserOps:      raw,
+		SentAt:       1,
+	}); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	r := New(db, nil, eth, testutils.ChainID, testutils.ValidAddress2, logr.Discard())
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{sent, next, otherKey, otherSender},
+		ep,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	if err := r.ExcludeInflight()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(ctx.Batch) != 2 {
+		t.Fatalf("got batch length %d, want 2", len(ctx.Batch))
+	}
+	if !testutils.IsOpsEqual(ctx.Batch[0], otherKey) {
+		t.Fatalf("got %s, want op with other nonce key", ctx.Batch[0].Nonce)
+	}
+	if !testutils.IsOpsEqual(ctx.Batch[1], otherSender) {
+		t.Fatalf("got %s, want op from other sender", ctx.Batch[1].Sender)
+	}
+}
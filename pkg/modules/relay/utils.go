@@ -5,5 +5,8 @@ import (
 )
 
 var (
-	DefaultWaitTimeout = 30 * time.Second
+	DefaultWaitTimeout         = 30 * time.Second
+	DefaultResubmitAfterBlocks = uint64(3)
+	DefaultFinalityBlocks      = uint64(0)
+	DefaultRetireInterval      = 5 * time.Second
 )
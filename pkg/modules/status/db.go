@@ -0,0 +1,50 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/dbutils"
+)
+
+var (
+	keyPrefix = dbutils.JoinValues("status")
+)
+
+func getRecordKey(hash common.Hash) []byte {
+	return []byte(dbutils.JoinValues(keyPrefix, hash.String()))
+}
+
+func getRecord(txn *badger.Txn, hash common.Hash) (*Record, error) {
+	item, err := txn.Get(getRecordKey(hash))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var rec Record
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func saveRecord(txn *badger.Txn, rec *Record, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	e := badger.NewEntry(getRecordKey(rec.UserOpHash), data)
+	if ttl > 0 {
+		e = e.WithTTL(ttl)
+	}
+	return txn.SetEntry(e)
+}
@@ -0,0 +1,159 @@
+// Package status implements modules for recording the lifecycle of every UserOperation seen by the bundler so
+// that it can still be queried after the op has left the mempool.
+package status
+
+import (
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// Status is the stage of a UserOperation in the bundler.
+type Status string
+
+const (
+	// Pending is a UserOperation that has been accepted into the mempool.
+	Pending Status = "pending"
+
+	// Submitted is a UserOperation that has been sent on-chain in a transaction that is not yet final.
+	Submitted Status = "submitted"
+
+	// Included is a UserOperation that has been included on-chain and removed from the mempool.
+	Included Status = "included"
+
+	// Dropped is a UserOperation that has been removed from the mempool without being included on-chain.
+	Dropped Status = "dropped"
+)
+
+// Record is the last known status of a UserOperation. TransactionHash is the hash of the last handleOps
+// transaction sent by the bundler that contained the op. Reason is only set for dropped ops.
+type Record struct {
+	UserOpHash      common.Hash    `json:"userOpHash"`
+	EntryPoint      common.Address `json:"entryPoint"`
+	Status          Status         `json:"status"`
+	TransactionHash *common.Hash   `json:"transactionHash,omitempty"`
+	Reason          string         `json:"reason,omitempty"`
+	UpdatedAt       int64          `json:"updatedAt"`
+}
+
+// Tracker provides Client and Bundler modules to persist the status of every UserOperation.
+type Tracker struct {
+	db  *badger.DB
+	ttl time.Duration
+}
+
+// New returns an instance of a Tracker that stores status records for the given TTL. A TTL of 0 means records
+// are never expired.
+func New(db *badger.DB, ttl time.Duration) *Tracker {
+	return &Tracker{db, ttl}
+}
+
+func (t *Tracker) update(
+	txn *badger.Txn,
+	hash common.Hash,
+	ep common.Address,
+	status Status,
+	txHash *common.Hash,
+	reason string,
+) error {
+	rec, err := getRecord(txn, hash)
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		rec = &Record{UserOpHash: hash, EntryPoint: ep}
+	}
+
+	rec.Status = status
+	rec.Reason = reason
+	if txHash != nil {
+		rec.TransactionHash = txHash
+	}
+	rec.UpdatedAt = time.Now().Unix()
+	return saveRecord(txn, rec, t.ttl)
+}
+
+// MarkAdded records an op that has been added to the mempool as pending. Every op in dropped was removed from
+// the mempool to make room for it (e.g. replaced with the same nonce or evicted at capacity) and is recorded
+// as dropped with the given reason. This should only be called once the op has been added successfully.
+func (t *Tracker) MarkAdded(ep common.Address, hash common.Hash, dropped map[common.Hash]string) error {
+	return t.db.Update(func(txn *badger.Txn) error {
+		for prev, reason := range dropped {
+			if err := t.update(txn, prev, ep, Dropped, nil, reason); err != nil {
+				return err
+			}
+		}
+
+		return t.update(txn, hash, ep, Pending, nil, "")
+	})
+}
+
+// Track returns a BatchHandler that is used by the Bundler to record the outcome of each run.
+//  1. Ops pending inclusion are recorded as submitted with the transaction hash.
+//  2. Ops remaining in the batch after a transaction was sent are recorded as included.
+//  3. Ops marked for removal with a reason are recorded as dropped.
+//  4. Ops removed without a reason are recorded as included since they were tracked to a final transaction.
+//
+// This should be executed after all other modules that modify the batch.
+func (t *Tracker) Track() modules.BatchHandlerFunc {
+	return func(ctx *modules.BatchHandlerCtx) error {
+		var txHash *common.Hash
+		if h, ok := ctx.Data["txn_hash"].(string); ok {
+			hash := common.HexToHash(h)
+			txHash = &hash
+		}
+
+		return t.db.Update(func(txn *badger.Txn) error {
+			save := func(ops []*userop.UserOperation, status Status, txHash *common.Hash) error {
+				for _, op := range ops {
+					hash := op.GetUserOpHash(ctx.EntryPoint, ctx.ChainID)
+					reason, _ := ctx.GetRemovalReason(op)
+					if err := t.update(txn, hash, ctx.EntryPoint, status, txHash, reason); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			if txHash != nil {
+				if err := save(ctx.PendingInclusion, Submitted, txHash); err != nil {
+					return err
+				}
+				if err := save(ctx.Batch, Included, txHash); err != nil {
+					return err
+				}
+			}
+
+			dropped := []*userop.UserOperation{}
+			included := []*userop.UserOperation{}
+			for _, op := range ctx.PendingRemoval {
+				if _, ok := ctx.GetRemovalReason(op); ok {
+					dropped = append(dropped, op)
+				} else {
+					included = append(included, op)
+				}
+			}
+			if err := save(dropped, Dropped, nil); err != nil {
+				return err
+			}
+			return save(included, Included, nil)
+		})
+	}
+}
+
+// GetStatus returns the last known status of a UserOperation. If no record exists, nil is returned.
+func (t *Tracker) GetStatus(hash common.Hash) (*Record, error) {
+	var rec *Record
+	err := t.db.View(func(txn *badger.Txn) error {
+		var err error
+		rec, err = getRecord(txn, hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
@@ -0,0 +1,137 @@
+package status
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/modules"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+func getStatus(t *testing.T, tr *Tracker, op *userop.UserOperation) *Record {
+	rec, err := tr.GetStatus(op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if rec == nil {
+		t.Fatal("got nil, want record")
+	}
+	return rec
+}
+
+// TestMarkAdded calls (*Tracker).MarkAdded and verifies that the op is recorded as pending and that every
+// replaced or evicted op is recorded as dropped with its reason.
+func TestMarkAdded(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	tr := New(db, time.Hour)
+
+	op1 := testutils.MockValidInitUserOp()
+	hash1 := op1.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	if err := tr.MarkAdded(testutils.ValidAddress1, hash1, nil); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if rec := getStatus(t, tr, op1); rec.Status != Pending {
+		t.Fatalf("got status %s, want %s", rec.Status, Pending)
+	}
+
+	op2 := testutils.MockValidInitUserOp()
+	op2.CallData = common.Hex2Bytes("dead")
+	hash2 := op2.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	if err := tr.MarkAdded(testutils.ValidAddress1, hash2, nil); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	op3 := testutils.MockValidInitUserOp()
+	op3.MaxFeePerGas = big.NewInt(0).Add(op1.MaxFeePerGas, common.Big1)
+	hash3 := op3.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)
+	dropped := map[common.Hash]string{
+		hash1: "replaced by " + hash3.String(),
+		hash2: "evicted by " + hash3.String(),
+	}
+	if err := tr.MarkAdded(testutils.ValidAddress1, hash3, dropped); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	for _, op := range []*userop.UserOperation{op1, op2} {
+		want := dropped[op.GetUserOpHash(testutils.ValidAddress1, testutils.ChainID)]
+		if rec := getStatus(t, tr, op); rec.Status != Dropped || rec.Reason != want {
+			t.Fatalf("got status %s with reason %q, want %s with reason %q", rec.Status, rec.Reason, Dropped, want)
+		}
+	}
+	if rec := getStatus(t, tr, op3); rec.Status != Pending {
+		t.Fatalf("got status %s, want %s", rec.Status, Pending)
+	}
+}
+
+// TestTrack calls (*Tracker).Track and verifies that ops are recorded as submitted, included, or dropped
+// based on the outcome of the Bundler run.
+func TestTrack(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	tr := New(db, time.Hour)
+
+	op1 := testutils.MockValidInitUserOp()
+	op2 := testutils.MockValidInitUserOp()
+	op2.CallData = common.Hex2Bytes("dead")
+	txHash := common.HexToHash("0x01")
+
+	ctx := modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{op1, op2},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	ctx.MarkOpIndexForRemoval(1, "expired")
+	ctx.MarkBatchPendingInclusion()
+	ctx.Data["txn_hash"] = txHash.String()
+	if err := tr.Track()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if rec := getStatus(t, tr, op1); rec.Status != Submitted {
+		t.Fatalf("got status %s, want %s", rec.Status, Submitted)
+	} else if rec.TransactionHash == nil || *rec.TransactionHash != txHash {
+		t.Fatalf("got transaction hash %v, want %s", rec.TransactionHash, txHash)
+	}
+	if rec := getStatus(t, tr, op2); rec.Status != Dropped {
+		t.Fatalf("got status %s, want %s", rec.Status, Dropped)
+	} else if rec.Reason != "expired" {
+		t.Fatalf("got reason %q, want expired", rec.Reason)
+	}
+
+	ctx = modules.NewBatchHandlerContext(
+		[]*userop.UserOperation{},
+		testutils.ValidAddress1,
+		testutils.ChainID,
+		nil,
+		nil,
+		nil,
+	)
+	ctx.PendingRemoval = append(ctx.PendingRemoval, op1)
+	if err := tr.Track()(ctx); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if rec := getStatus(t, tr, op1); rec.Status != Included {
+		t.Fatalf("got status %s, want %s", rec.Status, Included)
+	} else if rec.TransactionHash == nil || *rec.TransactionHash != txHash {
+		t.Fatalf("got transaction hash %v, want %s", rec.TransactionHash, txHash)
+	}
+}
+
+// TestGetStatusUnknown calls (*Tracker).GetStatus and verifies that nil is returned for an unknown op.
+func TestGetStatusUnknown(t *testing.T) {
+	db := testutils.DBMock()
+	defer db.Close()
+	tr := New(db, time.Hour)
+
+	rec, err := tr.GetStatus(common.HexToHash("0x01"))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	} else if rec != nil {
+		t.Fatalf("got %v, want nil", rec)
+	}
+}
@@ -0,0 +1,64 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LocalSigner is a Signer that holds an ECDSA private key in memory.
+type LocalSigner struct {
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+}
+
+// NewLocalSigner returns a Signer for an ECDSA private key.
+func NewLocalSigner(privateKey *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{
+		privateKey: privateKey,
+		address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+	}
+}
+
+// NewFromKeystore returns an EOA from an encrypted keystore file and the passphrase to decrypt it.
+func NewFromKeystore(path string, passphrase string) (*EOA, error) {
+	keyjson, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keystore.DecryptKey(keyjson, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEOA(NewLocalSigner(key.PrivateKey)), nil
+}
+
+// Address returns the address of the private key.
+func (s *LocalSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx returns a signed copy of the transaction for the given chain.
+func (s *LocalSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.privateKey)
+}
+
+// SignFlashbotsPayload returns the value of the X-Flashbots-Signature header for a request body.
+func (s *LocalSigner) SignFlashbotsPayload(body []byte) (string, error) {
+	hashedBody := crypto.Keccak256Hash(body).Hex()
+	sig, err := crypto.Sign(accounts.TextHash([]byte(hashedBody)), s.privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return s.address.Hex() + ":" + hexutil.Encode(sig), nil
+}
@@ -0,0 +1,249 @@
+package signer
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type member struct {
+	eoa      *EOA
+	busy     bool
+	paused   bool
+	retired  bool
+	nonce    uint64
+	hasNonce bool
+	balance  *big.Int
+}
+
+// Pool is a set of EOAs that can be used to sign and send transactions in parallel. Each EOA can only be
+// acquired by one user at a time so that its nonce is not shared between concurrent transactions.
+type Pool struct {
+	mu      sync.Mutex
+	members []*member
+	next    int
+}
+
+// NewPool returns a Pool with the given EOAs. Duplicate addresses are ignored.
+func NewPool(eoas ...*EOA) *Pool {
+	p := &Pool{members: []*member{}}
+	for _, eoa := range eoas {
+		if p.get(eoa.Address) == nil {
+			p.members = append(p.members, &member{eoa: eoa})
+		}
+	}
+	return p
+}
+
+func (p *Pool) get(addr common.Address) *member {
+	for _, m := range p.members {
+		if m.eoa.Address == addr {
+			return m
+		}
+	}
+	return nil
+}
+
+// Size returns the number of EOAs in the pool.
+func (p *Pool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.members)
+}
+
+// EOAs returns all EOAs in the pool.
+func (p *Pool) EOAs() []*EOA {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	eoas := []*EOA{}
+	for _, m := range p.members {
+		eoas = append(eoas, m.eoa)
+	}
+	return eoas
+}
+
+// Get returns the EOA in the pool with the given address or nil if it does not exist.
+func (p *Pool) Get(addr common.Address) *EOA {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		return m.eoa
+	}
+	return nil
+}
+
+// Add appends an EOA to the pool. It is a no-op if the address is already in the pool.
+func (p *Pool) Add(eoa *EOA) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.get(eoa.Address) == nil {
+		p.members = append(p.members, &member{eoa: eoa})
+	}
+}
+
+// Remove deletes the EOA with the given address from the pool.
+func (p *Pool) Remove(addr common.Address) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, m := range p.members {
+		if m.eoa.Address == addr {
+			p.members = append(p.members[:i], p.members[i+1:]...)
+			if p.next > i {
+				p.next--
+			}
+			if p.next >= len(p.members) {
+				p.next = 0
+			}
+			return
+		}
+	}
+}
+
+// Acquire returns the next idle EOA in round-robin order and marks it as busy. Paused and retired EOAs are
+// skipped. If all EOAs are busy, paused or retired, nil is returned.
+func (p *Pool) Acquire() *EOA {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := 0; i < len(p.members); i++ {
+		m := p.members[(p.next+i)%len(p.members)]
+		if !m.busy && !m.paused && !m.retired {
+			m.busy = true
+			p.next = (p.next + i + 1) % len(p.members)
+			return m.eoa
+		}
+	}
+	return nil
+}
+
+// Release marks an acquired EOA as idle.
+func (p *Pool) Release(eoa *EOA) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(eoa.Address); m != nil {
+		m.busy = false
+	}
+}
+
+// IsBusy returns true if an EOA in the pool is currently acquired.
+func (p *Pool) IsBusy(addr common.Address) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		return m.busy
+	}
+	return false
+}
+
+// Retire marks an EOA in the pool as retired. Unlike a paused EOA, a retired EOA is never acquired again but
+// can still be looked up to reconcile transactions it has already sent.
+func (p *Pool) Retire(addr common.Address) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		m.retired = true
+	}
+}
+
+// IsRetired returns true if an EOA in the pool has been retired.
+func (p *Pool) IsRetired(addr common.Address) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		return m.retired
+	}
+	return false
+}
+
+// SetPaused marks an EOA in the pool as paused. A paused EOA will not be acquired until it is unpaused.
+func (p *Pool) SetPaused(addr common.Address, paused bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		m.paused = paused
+	}
+}
+
+// IsPaused returns true if an EOA in the pool is paused.
+func (p *Pool) IsPaused(addr common.Address) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		return m.paused
+	}
+	return false
+}
+
+// AllPaused returns true if every EOA in the pool that has not been retired is paused.
+func (p *Pool) AllPaused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	active := 0
+	for _, m := range p.members {
+		if m.retired {
+			continue
+		}
+		active++
+		if !m.paused {
+			return false
+		}
+	}
+	return active > 0
+}
+
+// SetNonce records the next nonce to be used by an EOA in the pool.
+func (p *Pool) SetNonce(addr common.Address, nonce uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		m.nonce = nonce
+		m.hasNonce = true
+	}
+}
+
+// GetNonce returns the last recorded nonce of an EOA in the pool. The second return value is false if no
+// nonce has been recorded.
+func (p *Pool) GetNonce(addr common.Address) (uint64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		return m.nonce, m.hasNonce
+	}
+	return 0, false
+}
+
+// SetBalance records the native balance of an EOA in the pool.
+func (p *Pool) SetBalance(addr common.Address, balance *big.Int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		m.balance = balance
+	}
+}
+
+// GetBalance returns the last recorded native balance of an EOA in the pool or nil if it is unknown.
+func (p *Pool) GetBalance(addr common.Address) *big.Int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m := p.get(addr); m != nil {
+		return m.balance
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package signer
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func newTestEOA(t *testing.T) *EOA {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eoa, err := New(hex.EncodeToString(crypto.FromECDSA(pk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return eoa
+}
+
+// TestPoolAcquireRoundRobin verifies that EOAs are acquired in round-robin order and that a busy EOA is not
+// returned until it is released.
+func TestPoolAcquireRoundRobin(t *testing.T) {
+	eoa1 := newTestEOA(t)
+	eoa2 := newTestEOA(t)
+	p := NewPool(eoa1, eoa2)
+
+	if got := p.Acquire(); got != eoa1 {
+		t.Fatalf("got %s, want %s", got.Address, eoa1.Address)
+	}
+	if got := p.Acquire(); got != eoa2 {
+		t.Fatalf("got %s, want %s", got.Address, eoa2.Address)
+	}
+	if got := p.Acquire(); got != nil {
+		t.Fatalf("got %s, want nil", got.Address)
+	}
+
+	p.Release(eoa1)
+	if got := p.Acquire(); got != eoa1 {
+		t.Fatalf("got %s, want %s", got.Address, eoa1.Address)
+	}
+}
+
+// TestPoolIgnoresDuplicates verifies that the same EOA is only added to the pool once.
+func TestPoolIgnoresDuplicates(t *testing.T) {
+	eoa := newTestEOA(t)
+	p := NewPool(eoa, eoa)
+
+	if p.Size() != 1 {
+		t.Fatalf("got size %d, want 1", p.Size())
+	}
+}
+
+// TestPoolSkipsPaused verifies that a paused EOA is not acquired until it is unpaused.
+func TestPoolSkipsPaused(t *testing.T) {
+	eoa1 := newTestEOA(t)
+	eoa2 := newTestEOA(t)
+	p := NewPool(eoa1, eoa2)
+	p.SetPaused(eoa1.Address, true)
+
+	if got := p.Acquire(); got != eoa2 {
+		t.Fatalf("got %s, want %s", got.Address, eoa2.Address)
+	}
+	p.Release(eoa2)
+
+	p.SetPaused(eoa2.Address, true)
+	if !p.AllPaused() {
+		t.Fatal("got false, want true")
+	}
+	if got := p.Acquire(); got != nil {
+		t.Fatalf("got %s, want nil", got.Address)
+	}
+}
+
+// TestPoolRetire verifies that a retired EOA is never acquired, is ignored by AllPaused, and can still be
+// looked up until it is removed.
+func TestPoolRetire(t *testing.T) {
+	eoa1 := newTestEOA(t)
+	eoa2 := newTestEOA(t)
+	p := NewPool(eoa1)
+	p.Add(eoa2)
+	p.Retire(eoa1.Address)
+	p.SetPaused(eoa1.Address, false)
+
+	if got := p.Acquire(); got != eoa2 {
+		t.Fatalf("got %s, want %s", got.Address, eoa2.Address)
+	}
+	if got := p.Acquire(); got != nil {
+		t.Fatalf("got %s, want nil", got.Address)
+	}
+	if got := p.Get(eoa1.Address); got != eoa1 {
+		t.Fatalf("got %v, want %s", got, eoa1.Address)
+	}
+
+	p.SetPaused(eoa2.Address, true)
+	if !p.AllPaused() {
+		t.Fatal("got false, want true")
+	}
+
+	p.Remove(eoa1.Address)
+	if p.Size() != 1 {
+		t.Fatalf("got size %d, want 1", p.Size())
+	}
+	if got := p.Get(eoa1.Address); got != nil {
+		t.Fatalf("got %s, want nil", got.Address)
+	}
+}
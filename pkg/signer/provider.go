@@ -0,0 +1,31 @@
+package signer
+
+import "sync"
+
+// Provider holds the bundler's current EOA. Components that sign or simulate on behalf of the bundler should
+// read the EOA from a shared Provider on each use so that they pick up a new EOA once the signer is rotated.
+type Provider struct {
+	mu  sync.RWMutex
+	eoa *EOA
+}
+
+// NewProvider returns a Provider with the given EOA.
+func NewProvider(eoa *EOA) *Provider {
+	return &Provider{eoa: eoa}
+}
+
+// Get returns the current EOA.
+func (p *Provider) Get() *EOA {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.eoa
+}
+
+// Set replaces the current EOA.
+func (p *Provider) Set(eoa *EOA) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.eoa = eoa
+}
@@ -0,0 +1,125 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RemoteSigner is a Signer that delegates signing to an external JSON-RPC service such as web3signer or Clef.
+// The private key is never held by the bundler.
+type RemoteSigner struct {
+	rpc     *rpc.Client
+	address common.Address
+}
+
+// remoteTxArgs are the fields for an eth_signTransaction request.
+type remoteTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId,omitempty"`
+}
+
+// NewRemoteSigner returns a Signer for an account held by an external JSON-RPC service.
+func NewRemoteSigner(client *rpc.Client, address common.Address) *RemoteSigner {
+	return &RemoteSigner{
+		rpc:     client,
+		address: address,
+	}
+}
+
+// NewRemote returns an EOA that signs through an external JSON-RPC service at the given url.
+func NewRemote(url string, address common.Address) (*EOA, error) {
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEOA(NewRemoteSigner(client, address)), nil
+}
+
+// Address returns the address of the remote account.
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx requests a signature for the transaction with eth_signTransaction. The response can either be the
+// raw signed transaction or an object with a raw field as returned by Clef.
+func (s *RemoteSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := remoteTxArgs{
+		From:    s.address,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   (*hexutil.Big)(tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.Type() == types.LegacyTxType {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	}
+
+	var res json.RawMessage
+	if err := s.rpc.CallContext(context.Background(), &res, "eth_signTransaction", args); err != nil {
+		return nil, err
+	}
+
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(res, &raw); err != nil {
+		var obj struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		if err := json.Unmarshal(res, &obj); err != nil {
+			return nil, err
+		}
+		raw = obj.Raw
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, err
+	} else if from != s.address {
+		return nil, fmt.Errorf("signer: remote signed with %s, want %s", from, s.address)
+	}
+	return signed, nil
+}
+
+// SignFlashbotsPayload requests a signature for the hash of the body with eth_sign.
+func (s *RemoteSigner) SignFlashbotsPayload(body []byte) (string, error) {
+	hashedBody := crypto.Keccak256Hash(body).Hex()
+
+	var sig hexutil.Bytes
+	err := s.rpc.CallContext(
+		context.Background(),
+		&sig,
+		"eth_sign",
+		s.address,
+		hexutil.Encode([]byte(hashedBody)),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return s.address.Hex() + ":" + sig.String(), nil
+}
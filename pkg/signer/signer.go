@@ -1,19 +1,42 @@
-// Package signer holds EOA private keys to can sign regular Ethereum transactions.
+// Package signer provides EOAs that can sign regular Ethereum transactions with a local private key, an
+// encrypted keystore file, or an external JSON-RPC signer.
 package signer
 
 import (
-	"crypto/ecdsa"
+	"context"
 	"errors"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
-// EOA is an instance of a ECDSA private key, public key, and address.
+// Signer provides a general interface for signing on behalf of an EOA.
+type Signer interface {
+	// Address returns the address of the EOA.
+	Address() common.Address
+
+	// SignTx returns a signed copy of the transaction for the given chain.
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+	// SignFlashbotsPayload returns the value of the X-Flashbots-Signature header for a request body.
+	SignFlashbotsPayload(body []byte) (string, error)
+}
+
+// EOA is an instance of an Ethereum account and the Signer used to sign on its behalf.
 type EOA struct {
-	PrivateKey *ecdsa.PrivateKey
-	PublicKey  *ecdsa.PublicKey
-	Address    common.Address
+	Address common.Address
+	signer  Signer
+}
+
+// NewEOA returns an EOA that signs with the given Signer.
+func NewEOA(s Signer) *EOA {
+	return &EOA{
+		Address: s.Address(),
+		signer:  s,
+	}
 }
 
 // New returns an EOA from a hex string of a ECDSA private key.
@@ -22,15 +45,47 @@ func New(pk string) (*EOA, error) {
 	if err != nil {
 		return nil, err
 	}
-	publicKey, ok := privateKey.Public().(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("cannot assert type: publicKey is not of type *ecdsa.PublicKey")
+
+	return NewEOA(NewLocalSigner(privateKey)), nil
+}
+
+// SignTx returns a signed copy of the transaction for the given chain.
+func (e *EOA) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return e.signer.SignTx(tx, chainID)
+}
+
+// SignFlashbotsPayload returns the value of the X-Flashbots-Signature header for a request body.
+func (e *EOA) SignFlashbotsPayload(body []byte) (string, error) {
+	return e.signer.SignFlashbotsPayload(body)
+}
+
+// NewTransactor returns TransactOpts for sending transactions from the EOA with contract bindings.
+func (e *EOA) NewTransactor(chainID *big.Int) (*bind.TransactOpts, error) {
+	if chainID == nil {
+		return nil, errors.New("signer: chain ID is required")
 	}
-	address := crypto.PubkeyToAddress(*publicKey)
 
-	return &EOA{
-		PrivateKey: privateKey,
-		PublicKey:  publicKey,
-		Address:    address,
+	return &bind.TransactOpts{
+		From: e.Address,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != e.Address {
+				return nil, bind.ErrNotAuthorized
+			}
+			return e.SignTx(tx, chainID)
+		},
+		Context: context.Background(),
 	}, nil
 }
+
+// NewCallTransactor returns TransactOpts from the EOA for building transactions with contract bindings that
+// are never sent. Transactions are left unsigned so that no requests are made to the Signer.
+func (e *EOA) NewCallTransactor() *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: e.Address,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return tx, nil
+		},
+		Context: context.Background(),
+		NoSend:  true,
+	}
+}
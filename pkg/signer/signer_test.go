@@ -0,0 +1,182 @@
+package signer
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var testChainID = big.NewInt(1)
+
+func newTestTx() *types.Transaction {
+	to := common.HexToAddress("0x1")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     1,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(0),
+	})
+}
+
+// testRemoteService is a JSON-RPC signing service backed by a local key.
+type testRemoteService struct {
+	signer *LocalSigner
+}
+
+func (s *testRemoteService) SignTransaction(ctx context.Context, args remoteTxArgs) (hexutil.Bytes, error) {
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   (*big.Int)(args.ChainID),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: (*big.Int)(args.MaxPriorityFeePerGas),
+		GasFeeCap: (*big.Int)(args.MaxFeePerGas),
+		Gas:       uint64(args.Gas),
+		To:        args.To,
+		Value:     (*big.Int)(args.Value),
+		Data:      args.Data,
+	})
+	signed, err := s.signer.SignTx(tx, (*big.Int)(args.ChainID))
+	if err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
+
+func (s *testRemoteService) Sign(
+	ctx context.Context,
+	addr common.Address,
+	data hexutil.Bytes,
+) (hexutil.Bytes, error) {
+	return crypto.Sign(accounts.TextHash(data), s.signer.privateKey)
+}
+
+func recoverFlashbotsSigner(t *testing.T, body []byte, header string) common.Address {
+	parts := strings.Split(header, ":")
+	if len(parts) != 2 {
+		t.Fatalf("got header %s, want address:signature", header)
+	}
+	sig, err := hexutil.Decode(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := accounts.TextHash([]byte(crypto.Keccak256Hash(body).Hex()))
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crypto.PubkeyToAddress(*pub)
+}
+
+// TestLocalSignerSignTx verifies that a transaction signed by a local key recovers to the same address.
+func TestLocalSignerSignTx(t *testing.T) {
+	eoa := newTestEOA(t)
+	signed, err := eoa.SignTx(newTestTx(), testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(testChainID), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != eoa.Address {
+		t.Fatalf("got %s, want %s", from, eoa.Address)
+	}
+}
+
+// TestLocalSignerSignFlashbotsPayload verifies that the X-Flashbots-Signature header recovers to the EOA.
+func TestLocalSignerSignFlashbotsPayload(t *testing.T) {
+	eoa := newTestEOA(t)
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_sendBundle","params":[]}`)
+	header, err := eoa.SignFlashbotsPayload(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(header, eoa.Address.Hex()+":") {
+		t.Fatalf("got header %s, want prefix %s", header, eoa.Address.Hex())
+	}
+	if got := recoverFlashbotsSigner(t, body, header); got != eoa.Address {
+		t.Fatalf("got %s, want %s", got, eoa.Address)
+	}
+}
+
+// TestRemoteSigner verifies that transactions and payloads can be signed through an external JSON-RPC
+// service.
+func TestRemoteSigner(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := NewLocalSigner(pk)
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", &testRemoteService{signer: local}); err != nil {
+		t.Fatal(err)
+	}
+	http := httptest.NewServer(server)
+	defer http.Close()
+
+	eoa, err := NewRemote(http.URL, local.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := eoa.SignTx(newTestTx(), testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(testChainID), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != eoa.Address {
+		t.Fatalf("got %s, want %s", from, eoa.Address)
+	}
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_callBundle","params":[]}`)
+	header, err := eoa.SignFlashbotsPayload(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recoverFlashbotsSigner(t, body, header); got != eoa.Address {
+		t.Fatalf("got %s, want %s", got, eoa.Address)
+	}
+}
+
+// TestRemoteSignerWrongAddress verifies that a transaction signed by a different account than requested is
+// rejected.
+func TestRemoteSignerWrongAddress(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", &testRemoteService{signer: NewLocalSigner(pk)}); err != nil {
+		t.Fatal(err)
+	}
+	http := httptest.NewServer(server)
+	defer http.Close()
+
+	eoa, err := NewRemote(http.URL, common.HexToAddress("0x2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eoa.SignTx(newTestTx(), testChainID); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
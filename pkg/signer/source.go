@@ -0,0 +1,37 @@
+package signer
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Source describes where the key for an EOA is held. Exactly one of PrivateKey, KeystoreFile or
+// RemoteSignerUrl should be set.
+type Source struct {
+	PrivateKey          string         `json:"privateKey"          mapstructure:"privateKey"`
+	KeystoreFile        string         `json:"keystoreFile"        mapstructure:"keystoreFile"`
+	KeystorePassphrase  string         `json:"keystorePassphrase"  mapstructure:"keystorePassphrase"`
+	RemoteSignerUrl     string         `json:"remoteSignerUrl"     mapstructure:"remoteSignerUrl"`
+	RemoteSignerAddress common.Address `json:"remoteSignerAddress" mapstructure:"remoteSignerAddress"`
+}
+
+// NewFromSource returns an EOA from whichever key source is set.
+func NewFromSource(s Source) (*EOA, error) {
+	n := 0
+	for _, v := range []string{s.PrivateKey, s.KeystoreFile, s.RemoteSignerUrl} {
+		if v != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return nil, errors.New("signer: exactly one of privateKey, keystoreFile or remoteSignerUrl must be set")
+	}
+
+	if s.KeystoreFile != "" {
+		return NewFromKeystore(s.KeystoreFile, s.KeystorePassphrase)
+	} else if s.RemoteSignerUrl != "" {
+		return NewRemote(s.RemoteSignerUrl, s.RemoteSignerAddress)
+	}
+	return New(s.PrivateKey)
+}
@@ -0,0 +1,49 @@
+package tracer
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Mode determines how the bundler collects the traces required for simulation.
+type Mode string
+
+const (
+	// JSMode passes the custom JS tracers to debug_traceCall.
+	JSMode Mode = "js"
+
+	// NativeMode uses the built-in callTracer, prestateTracer, and opcode logger and reconstructs the output of
+	// the custom JS tracers in Go. This is for nodes that do not support custom JS tracers.
+	NativeMode Mode = "native"
+)
+
+var (
+	modeMu sync.RWMutex
+	mode   = JSMode
+)
+
+// ParseMode returns the Mode for a given string value.
+func ParseMode(m string) (Mode, error) {
+	switch Mode(m) {
+	case JSMode, NativeMode:
+		return Mode(m), nil
+	default:
+		return "", fmt.Errorf("tracer mode: unknown value %s", m)
+	}
+}
+
+// SetMode sets the Mode used for all subsequent traces.
+func SetMode(m Mode) {
+	modeMu.Lock()
+	defer modeMu.Unlock()
+
+	mode = m
+}
+
+// GetMode returns the Mode used for tracing. Defaults to JSMode.
+func GetMode() Mode {
+	modeMu.RLock()
+	defer modeMu.RUnlock()
+
+	return mode
+}
@@ -0,0 +1,484 @@
+package tracer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// StructLog is a single step from the default opcode logger of debug_traceCall.
+type StructLog struct {
+	Pc      uint64   `json:"pc"`
+	Op      string   `json:"op"`
+	Gas     uint64   `json:"gas"`
+	GasCost uint64   `json:"gasCost"`
+	Depth   int      `json:"depth"`
+	Error   string   `json:"error,omitempty"`
+	Stack   []string `json:"stack"`
+	Memory  []string `json:"memory"`
+}
+
+// StructLogReturn is the return value from performing an EVM trace with the default opcode logger.
+type StructLogReturn struct {
+	Gas         uint64      `json:"gas"`
+	Failed      bool        `json:"failed"`
+	ReturnValue string      `json:"returnValue"`
+	StructLogs  []StructLog `json:"structLogs"`
+}
+
+// CallFrame is a single call from the built-in callTracer.
+type CallFrame struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *hexutil.Big   `json:"value,omitempty"`
+	Gas     hexutil.Uint64 `json:"gas"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Input   hexutil.Bytes  `json:"input"`
+	Output  hexutil.Bytes  `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Calls   []CallFrame    `json:"calls,omitempty"`
+}
+
+// PrestateAccount is the state of a single account from the built-in prestateTracer.
+type PrestateAccount struct {
+	Code hexutil.Bytes `json:"code,omitempty"`
+}
+
+// PrestateReturn is the return value from performing an EVM trace with the built-in prestateTracer.
+type PrestateReturn = map[common.Address]PrestateAccount
+
+// NativeTraces holds the results of all the built-in tracers required to reconstruct the output of the
+// custom JS tracers.
+type NativeTraces struct {
+	StructLogs *StructLogReturn
+	Calls      *CallFrame
+	Prestate   PrestateReturn
+}
+
+var (
+	ignoredOpCodes = regexp.MustCompile(
+		`^(DUP\d+|PUSH\d+|SWAP\d+|POP|ADD|SUB|MUL|DIV|EQ|LTE?|S?GTE?|SLT|SH[LR]|AND|OR|NOT|ISZERO)$`,
+	)
+	callOpCodes = regexp.MustCompile(`^(EXT.*|CALL|CALLCODE|DELEGATECALL|STATICCALL)$`)
+
+	userOperationEventTopics0 = "0x49628fd1471006c1482da88028e9ce4dbb080b815c9b0344d39e5a8e6ec1419f"
+	validationMarker          = 1
+	executionMarker           = 3
+	maxCallDataLength         = 1000
+)
+
+// frameInfo holds context on a CallFrame that is only known from the opcode logs.
+type frameInfo struct {
+	Frame *CallFrame
+
+	// Marker is the number of NUMBER opcodes at the top level before the frame was entered.
+	Marker int
+
+	// Depth is the depth of the last step executed before the frame exited. This is the depth of the frame
+	// itself, unless no opcodes were executed within it in which case it is the depth of the caller.
+	Depth int
+
+	// Storage is the address whose storage is accessed within the frame.
+	Storage common.Address
+}
+
+func isCallFrameOp(op string) bool {
+	switch op {
+	case "CALL", "CALLCODE", "DELEGATECALL", "STATICCALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+		return true
+	default:
+		return false
+	}
+}
+
+func isPrecompiled(addr common.Address) bool {
+	n := addr.Big()
+	return n.Sign() > 0 && n.Cmp(big.NewInt(0x0a)) <= 0
+}
+
+func toHexSlice(b []byte, max int) string {
+	h := hexutil.Encode(b)
+	if len(h) > max {
+		return h[:max]
+	}
+	return h
+}
+
+// peek returns the nth item from the top of the stack.
+func peek(log *StructLog, n int) (*big.Int, error) {
+	i := len(log.Stack) - 1 - n
+	if i < 0 {
+		return nil, fmt.Errorf("tracer: stack underflow at pc %d", log.Pc)
+	}
+
+	v, ok := big.NewInt(0).SetString(strings.TrimPrefix(log.Stack[i], "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("tracer: invalid stack value %s", log.Stack[i])
+	}
+	return v, nil
+}
+
+// memorySlice returns the memory in the range of the offset and length at the top of the stack.
+func memorySlice(log *StructLog) ([]byte, error) {
+	ofs, err := peek(log, 0)
+	if err != nil {
+		return nil, err
+	}
+	length, err := peek(log, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	mem, err := hex.DecodeString(strings.Join(log.Memory, ""))
+	if err != nil {
+		return nil, fmt.Errorf("tracer: %s", err)
+	}
+	start, end := ofs.Uint64(), ofs.Uint64()+length.Uint64()
+	if end > uint64(len(mem)) {
+		padded := make([]byte, end)
+		copy(padded, mem)
+		mem = padded
+	}
+	return mem[start:end], nil
+}
+
+func logInfo(log *StructLog) (*LogInfo, error) {
+	count, err := strconv.Atoi(strings.TrimPrefix(log.Op, "LOG"))
+	if err != nil {
+		return nil, fmt.Errorf("tracer: %s", err)
+	}
+
+	topics := []string{}
+	for i := 0; i < count; i++ {
+		t, err := peek(log, 2+i)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, fmt.Sprintf("0x%x", t))
+	}
+
+	data, err := memorySlice(log)
+	if err != nil {
+		return nil, err
+	}
+	return &LogInfo{Topics: topics, Data: hexutil.Encode(data)}, nil
+}
+
+// flattenCalls returns all nested CallFrames in the order they were entered.
+func flattenCalls(frame *CallFrame) []*CallFrame {
+	out := []*CallFrame{}
+	for i := range frame.Calls {
+		out = append(out, &frame.Calls[i])
+		out = append(out, flattenCalls(&frame.Calls[i])...)
+	}
+	return out
+}
+
+// matchFrames walks through the opcode logs and pairs every nested CallFrame with the context in which it
+// was entered. It also returns the address whose storage is accessed at each step.
+func matchFrames(traces *NativeTraces) (map[*CallFrame]*frameInfo, []common.Address, error) {
+	frames := flattenCalls(traces.Calls)
+	infos := make(map[*CallFrame]*frameInfo)
+	open := []*frameInfo{{Frame: traces.Calls, Depth: 1, Storage: traces.Calls.To}}
+	marker := 0
+	next := 0
+
+	logs := traces.StructLogs.StructLogs
+	addrs := make([]common.Address, len(logs))
+	for i := range logs {
+		log := &logs[i]
+		for len(open) > 1 && log.Depth < open[len(open)-1].Depth {
+			open = open[:len(open)-1]
+		}
+		addrs[i] = open[len(open)-1].Storage
+
+		if log.Depth == 1 && log.Op == "NUMBER" {
+			marker++
+		}
+		if !isCallFrameOp(log.Op) || next >= len(frames) {
+			continue
+		}
+
+		frame := frames[next]
+		switch {
+		case log.Op == "SELFDESTRUCT":
+			if frame.Type != "SELFDESTRUCT" {
+				continue
+			}
+		case !strings.HasPrefix(log.Op, "CREATE"):
+			to, err := peek(log, 1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if common.BigToAddress(to) != frame.To {
+				// The call failed before a frame was entered.
+				continue
+			}
+		}
+		next++
+
+		info := &frameInfo{Frame: frame, Marker: marker, Depth: log.Depth, Storage: frame.To}
+		if log.Op == "DELEGATECALL" || log.Op == "CALLCODE" {
+			info.Storage = addrs[i]
+		}
+		infos[frame] = info
+
+		if i+1 < len(logs) && logs[i+1].Depth == log.Depth+1 {
+			info.Depth = log.Depth + 1
+			open = append(open, info)
+		}
+	}
+
+	for _, frame := range frames {
+		if _, ok := infos[frame]; !ok {
+			return nil, nil, fmt.Errorf("tracer: unable to match call frame to %s", frame.To)
+		}
+	}
+	return infos, addrs, nil
+}
+
+// codeSizes returns the size of deployed code for every account touched during the trace. This includes
+// contracts that were created within the trace.
+func codeSizes(traces *NativeTraces) map[common.Address]int {
+	out := make(map[common.Address]int)
+	for addr, acc := range traces.Prestate {
+		out[addr] = len(acc.Code)
+	}
+	for _, frame := range flattenCalls(traces.Calls) {
+		if strings.HasPrefix(frame.Type, "CREATE") && frame.Error == "" {
+			out[frame.To] = len(frame.Output)
+		}
+	}
+	return out
+}
+
+func collectCalls(frame *CallFrame) []CallInfo {
+	out := []CallInfo{}
+	for i := range frame.Calls {
+		c := &frame.Calls[i]
+		var value any
+		if c.Value != nil {
+			value = c.Value.ToInt()
+		}
+		out = append(out, CallInfo{
+			Type:   c.Type,
+			From:   c.From,
+			To:     c.To,
+			Method: toHexSlice(c.Input, 10),
+			Gas:    float64(c.Gas),
+			Value:  value,
+		})
+		out = append(out, collectCalls(c)...)
+
+		exit := "RETURN"
+		if c.Error != "" {
+			exit = "REVERT"
+		}
+		out = append(out, CallInfo{
+			Type:    exit,
+			GasUsed: float64(c.GasUsed),
+			Data:    toHexSlice(c.Output, maxCallDataLength),
+		})
+	}
+	return out
+}
+
+// NewBundlerCollectorReturn reconstructs the output of BundlerCollectorTracer.js from the results of the
+// built-in opcode logger, callTracer, and prestateTracer.
+func NewBundlerCollectorReturn(traces *NativeTraces) (*BundlerCollectorReturn, error) {
+	_, addrs, err := matchFrames(traces)
+	if err != nil {
+		return nil, err
+	}
+	sizes := codeSizes(traces)
+
+	res := &BundlerCollectorReturn{
+		NumberLevels: []NumberLevelInfo{},
+		Keccak:       []string{},
+		Calls:        collectCalls(traces.Calls),
+		Logs:         []LogInfo{},
+		Debug:        []any{},
+	}
+	newLevel := func() {
+		res.NumberLevels = append(res.NumberLevels, NumberLevelInfo{
+			Opcodes:      Counts{},
+			Access:       AccessMap{},
+			ContractSize: Counts{},
+		})
+	}
+	newLevel()
+
+	lastOp := ""
+	lastTopLevelOp := ""
+	logs := traces.StructLogs.StructLogs
+	for i := range logs {
+		log := &logs[i]
+		level := &res.NumberLevels[len(res.NumberLevels)-1]
+
+		if callOpCodes.MatchString(log.Op) {
+			idx := 1
+			if strings.HasPrefix(log.Op, "EXT") {
+				idx = 0
+			}
+			v, err := peek(log, idx)
+			if err != nil {
+				return nil, err
+			}
+			addr := common.BigToAddress(v)
+			addrHex := strings.ToLower(addr.Hex())
+			if level.ContractSize[addrHex] == 0 && !isPrecompiled(addr) {
+				level.ContractSize[addrHex] = float64(sizes[addr])
+			}
+		}
+
+		if log.Depth == 1 {
+			lastTopLevelOp = log.Op
+			if log.Op == "NUMBER" {
+				newLevel()
+			}
+			lastOp = ""
+			continue
+		}
+
+		if lastOp == "GAS" && !strings.Contains(log.Op, "CALL") {
+			level.Opcodes["GAS"]++
+		}
+		if log.Op != "GAS" && !ignoredOpCodes.MatchString(log.Op) {
+			level.Opcodes[log.Op]++
+		}
+		lastOp = log.Op
+
+		switch {
+		case log.Op == "SLOAD" || log.Op == "SSTORE":
+			slot, err := peek(log, 0)
+			if err != nil {
+				return nil, err
+			}
+			access, ok := level.Access[addrs[i]]
+			if !ok {
+				access = AccessInfo{Reads: Counts{}, Writes: Counts{}}
+				level.Access[addrs[i]] = access
+			}
+			if log.Op == "SLOAD" {
+				access.Reads[fmt.Sprintf("%x", slot)]++
+			} else {
+				access.Writes[fmt.Sprintf("%x", slot)]++
+			}
+
+		case log.Op == "KECCAK256" || log.Op == "SHA3":
+			length, err := peek(log, 1)
+			if err != nil {
+				return nil, err
+			}
+			if length.Cmp(big.NewInt(20)) > 0 && length.Cmp(big.NewInt(512)) < 0 {
+				data, err := memorySlice(log)
+				if err != nil {
+					return nil, err
+				}
+				res.Keccak = append(res.Keccak, hexutil.Encode(data))
+			}
+
+		case strings.HasPrefix(log.Op, "LOG"):
+			li, err := logInfo(log)
+			if err != nil {
+				return nil, err
+			}
+			res.Logs = append(res.Logs, *li)
+		}
+	}
+
+	// The top level exit is reconstructed from the final opcode.
+	if lastTopLevelOp == "RETURN" || lastTopLevelOp == "REVERT" {
+		res.Calls = append(res.Calls, CallInfo{
+			Type: lastTopLevelOp,
+			Data: toHexSlice(traces.Calls.Output, maxCallDataLength),
+		})
+	}
+	return res, nil
+}
+
+type gasItem struct {
+	used     uint64
+	required uint64
+}
+
+// NewBundlerExecutionReturn reconstructs the output of BundlerExecutionTracer.js from the results of the
+// built-in opcode logger and callTracer.
+func NewBundlerExecutionReturn(traces *NativeTraces) (*BundlerExecutionReturn, error) {
+	infos, _, err := matchFrames(traces)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &BundlerExecutionReturn{
+		Reverts: []string{},
+		Output:  hexutil.Encode(traces.Calls.Output),
+	}
+
+	marker := 0
+	logs := traces.StructLogs.StructLogs
+	for i := range logs {
+		log := &logs[i]
+		if log.Depth == 1 && log.Op == "NUMBER" {
+			marker++
+		}
+
+		if log.Depth <= 2 && strings.HasPrefix(log.Op, "LOG") && log.Op != "LOG0" {
+			li, err := logInfo(log)
+			if err != nil {
+				return nil, err
+			}
+			if li.Topics[0] == userOperationEventTopics0 {
+				res.UserOperationEvent = li
+			}
+		}
+
+		if log.Gas < log.GasCost {
+			if marker >= validationMarker && marker < executionMarker {
+				res.ValidationOOG = true
+			} else if marker == executionMarker {
+				res.ExecutionOOG = true
+			}
+		}
+	}
+
+	gasStack := make(map[int]gasItem)
+	var exit func(frame *CallFrame)
+	exit = func(frame *CallFrame) {
+		for i := range frame.Calls {
+			exit(&frame.Calls[i])
+		}
+		if frame == traces.Calls {
+			return
+		}
+
+		info := infos[frame]
+		if info.Marker != executionMarker {
+			return
+		}
+		if frame.Error != "" {
+			res.Reverts = append(res.Reverts, hexutil.Encode(frame.Output))
+		}
+		if info.Depth >= 2 {
+			nested := gasStack[info.Depth+1]
+			gasStack[info.Depth+1] = gasItem{}
+
+			used := uint64(frame.GasUsed)
+			curr := gasStack[info.Depth]
+			curr.used += used
+			curr.required += used - nested.used + (nested.required*64+62)/63
+			gasStack[info.Depth] = curr
+			res.ExecutionGasLimit = float64(curr.required)
+		}
+	}
+	exit(traces.Calls)
+
+	return res, nil
+}
@@ -0,0 +1,113 @@
+package tracer
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	testEntryPoint = common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+	testAccount    = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+)
+
+func testNativeTraces() *NativeTraces {
+	return &NativeTraces{
+		StructLogs: &StructLogReturn{
+			StructLogs: []StructLog{
+				{Op: "PUSH1", Depth: 1, Stack: []string{}},
+				{Op: "NUMBER", Depth: 1, Stack: []string{}},
+				{Op: "CALL", Depth: 1, Gas: 100000, GasCost: 50000, Stack: []string{
+					"0x0", "0x0", "0x0", "0x0", "0x0", testAccount.Hex(), "0x186a0",
+				}},
+				{Op: "SLOAD", Depth: 2, Stack: []string{"0x5"}},
+				{Op: "GAS", Depth: 2, Stack: []string{}},
+				{Op: "TIMESTAMP", Depth: 2, Stack: []string{}},
+				{Op: "RETURN", Depth: 2, Stack: []string{"0x0", "0x0"}},
+				{Op: "RETURN", Depth: 1, Stack: []string{"0x0", "0x0"}},
+			},
+		},
+		Calls: &CallFrame{
+			Type:   "CALL",
+			From:   common.HexToAddress("0x"),
+			To:     testEntryPoint,
+			Output: hexutil.Bytes{0x01},
+			Calls: []CallFrame{
+				{
+					Type:    "CALL",
+					From:    testEntryPoint,
+					To:      testAccount,
+					Gas:     50000,
+					GasUsed: 21000,
+					Input:   hexutil.Bytes{0xde, 0xad, 0xbe, 0xef, 0x01},
+				},
+			},
+		},
+		Prestate: PrestateReturn{
+			testAccount: {Code: hexutil.Bytes{0x60, 0x00, 0x60, 0x00}},
+		},
+	}
+}
+
+// TestNewBundlerCollectorReturn verifies that the output of the BundlerCollectorTracer is correctly
+// reconstructed from the built-in tracers.
+func TestNewBundlerCollectorReturn(t *testing.T) {
+	res, err := NewBundlerCollectorReturn(testNativeTraces())
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	if len(res.NumberLevels) != 2 {
+		t.Fatalf("got %d number levels, want 2", len(res.NumberLevels))
+	}
+	level := res.NumberLevels[1]
+	for _, op := range []string{"SLOAD", "GAS", "TIMESTAMP", "RETURN"} {
+		if level.Opcodes[op] != 1 {
+			t.Fatalf("%s: got %v, want 1", op, level.Opcodes[op])
+		}
+	}
+	if reads := level.Access[testAccount].Reads["5"]; reads != 1 {
+		t.Fatalf("reads: got %v, want 1", reads)
+	}
+	if size := level.ContractSize["0x00000000000000000000000000000000000000aa"]; size != 4 {
+		t.Fatalf("contract size: got %v, want 4", size)
+	}
+
+	if len(res.Calls) != 3 {
+		t.Fatalf("got %d calls, want 3", len(res.Calls))
+	}
+	if res.Calls[0].Method != "0xdeadbeef" {
+		t.Fatalf("method: got %s, want 0xdeadbeef", res.Calls[0].Method)
+	}
+	if res.Calls[1].Type != "RETURN" || res.Calls[2].Type != "RETURN" {
+		t.Fatalf("got %s and %s, want RETURN", res.Calls[1].Type, res.Calls[2].Type)
+	}
+	if res.Calls[2].Data != "0x01" {
+		t.Fatalf("data: got %v, want 0x01", res.Calls[2].Data)
+	}
+}
+
+// TestNewBundlerExecutionReturn verifies that the output of the BundlerExecutionTracer is correctly
+// reconstructed from the built-in tracers.
+func TestNewBundlerExecutionReturn(t *testing.T) {
+	traces := testNativeTraces()
+	traces.Calls.Calls[0].Error = "execution reverted"
+	traces.Calls.Calls[0].Output = hexutil.Bytes{0x02}
+
+	res, err := NewBundlerExecutionReturn(traces)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if res.Output != "0x01" {
+		t.Fatalf("output: got %s, want 0x01", res.Output)
+	}
+
+	// The call happens at the validation marker and is not included in the execution reverts.
+	if len(res.Reverts) != 0 {
+		t.Fatalf("got %d reverts, want 0", len(res.Reverts))
+	}
+	if res.ValidationOOG {
+		t.Fatal("validationOOG: got true, want false")
+	}
+}
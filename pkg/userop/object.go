@@ -16,6 +16,8 @@ var (
 	uint256, _ = abi.NewType("uint256", "", nil)
 	bytes32, _ = abi.NewType("bytes32", "", nil)
 
+	maxUint64 = big.NewInt(0).SetUint64(^uint64(0))
+
 	// UserOpPrimitives is the primitive ABI types for each UserOperation field.
 	UserOpPrimitives = []abi.ArgumentMarshaling{
 		{Name: "sender", InternalType: "Sender", Type: "address"},
@@ -51,6 +53,8 @@ type UserOperation struct {
 	MaxPriorityFeePerGas *big.Int       `json:"maxPriorityFeePerGas" mapstructure:"maxPriorityFeePerGas" validate:"required"`
 	PaymasterAndData     []byte         `json:"paymasterAndData"     mapstructure:"paymasterAndData"     validate:"required"`
 	Signature            []byte         `json:"signature"            mapstructure:"signature"            validate:"required"`
+
+	version Version
 }
 
 // GetPaymaster returns the address portion of PaymasterAndData if applicable. Otherwise it returns the zero
@@ -72,9 +76,29 @@ func (op *UserOperation) GetFactory() common.Address {
 	return common.BytesToAddress(op.InitCode[:common.AddressLength])
 }
 
+// GetNonceKey returns the upper 192 bits of the nonce. A sender can have parallel sequences of nonces, each
+// under a different key.
+func (op *UserOperation) GetNonceKey() *big.Int {
+	return big.NewInt(0).Rsh(op.Nonce, 64)
+}
+
+// GetNonceSequence returns the lower 64 bits of the nonce which is the sequence number under its key.
+func (op *UserOperation) GetNonceSequence() uint64 {
+	return big.NewInt(0).And(op.Nonce, maxUint64).Uint64()
+}
+
 // GetMaxGasAvailable returns the max amount of gas that can be consumed by this UserOperation.
 func (op *UserOperation) GetMaxGasAvailable() *big.Int {
-	// TODO: Multiplier logic might change in v0.7
+	if op.version == V07 {
+		return big.NewInt(0).Add(
+			big.NewInt(0).Add(op.VerificationGasLimit, op.CallGasLimit),
+			big.NewInt(0).Add(
+				op.PreVerificationGas,
+				big.NewInt(0).Add(op.GetPaymasterVerificationGasLimit(), op.GetPaymasterPostOpGasLimit()),
+			),
+		)
+	}
+
 	mul := big.NewInt(1)
 	paymaster := op.GetPaymaster()
 	if paymaster != common.HexToAddress("0x") {
@@ -110,6 +134,10 @@ func (op *UserOperation) GetDynamicGasPrice(basefee *big.Int) *big.Int {
 
 // Pack returns a standard message of the userOp. This cannot be used to generate a userOpHash.
 func (op *UserOperation) Pack() []byte {
+	if op.version == V07 {
+		return op.packV07()
+	}
+
 	args := abi.Arguments{
 		{Name: "UserOp", Type: UserOpType},
 	}
@@ -146,6 +174,10 @@ func (op *UserOperation) Pack() []byte {
 
 // PackForSignature returns a minimal message of the userOp. This can be used to generate a userOpHash.
 func (op *UserOperation) PackForSignature() []byte {
+	if op.version == V07 {
+		return op.packForSignatureV07()
+	}
+
 	args := abi.Arguments{
 		{Name: "sender", Type: address},
 		{Name: "nonce", Type: uint256},
@@ -183,8 +215,12 @@ func (op *UserOperation) GetUserOpHash(entryPoint common.Address, chainID *big.I
 	)
 }
 
-// MarshalJSON returns a JSON encoding of the UserOperation.
+// MarshalJSON returns a JSON encoding of the UserOperation in the RPC format of its EntryPoint version.
 func (op *UserOperation) MarshalJSON() ([]byte, error) {
+	if op.version == V07 {
+		return op.marshalJSONV07()
+	}
+
 	return json.Marshal(&struct {
 		Sender               string `json:"sender"`
 		Nonce                string `json:"nonce"`
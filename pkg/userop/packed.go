@@ -0,0 +1,274 @@
+package userop
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Version represents the EntryPoint release that a UserOperation is formatted for.
+type Version int
+
+const (
+	// V06 is the UserOperation format used by EntryPoint v0.6.
+	V06 Version = iota
+
+	// V07 is the PackedUserOperation format used by EntryPoint v0.7.
+	V07
+)
+
+// String returns the semver representation of the version.
+func (v Version) String() string {
+	switch v {
+	case V07:
+		return "v0.7"
+	default:
+		return "v0.6"
+	}
+}
+
+// ParseVersion returns the Version for a semver string such as "v0.6" or "0.7".
+func ParseVersion(s string) (Version, error) {
+	switch s {
+	case "v0.6", "0.6":
+		return V06, nil
+	case "v0.7", "0.7":
+		return V07, nil
+	default:
+		return V06, fmt.Errorf("userop: unsupported version %s", s)
+	}
+}
+
+const (
+	// packedGasLength is the number of bytes for each gas value that is packed into a single bytes32 word.
+	packedGasLength = 16
+
+	// paymasterDataOffset is the start of paymasterData in a v0.7 paymasterAndData field.
+	paymasterDataOffset = common.AddressLength + (2 * packedGasLength)
+)
+
+var (
+	// PackedUserOpPrimitives is the primitive ABI types for each PackedUserOperation field.
+	PackedUserOpPrimitives = []abi.ArgumentMarshaling{
+		{Name: "sender", InternalType: "Sender", Type: "address"},
+		{Name: "nonce", InternalType: "Nonce", Type: "uint256"},
+		{Name: "initCode", InternalType: "InitCode", Type: "bytes"},
+		{Name: "callData", InternalType: "CallData", Type: "bytes"},
+		{Name: "accountGasLimits", InternalType: "AccountGasLimits", Type: "bytes32"},
+		{Name: "preVerificationGas", InternalType: "PreVerificationGas", Type: "uint256"},
+		{Name: "gasFees", InternalType: "GasFees", Type: "bytes32"},
+		{Name: "paymasterAndData", InternalType: "PaymasterAndData", Type: "bytes"},
+		{Name: "signature", InternalType: "Signature", Type: "bytes"},
+	}
+
+	// PackedUserOpType is the ABI type of a PackedUserOperation.
+	PackedUserOpType, _ = abi.NewType("tuple", "op", PackedUserOpPrimitives)
+
+	// PackedUserOpArr is the ABI type for an array of PackedUserOperations.
+	PackedUserOpArr, _ = abi.NewType("tuple[]", "ops", PackedUserOpPrimitives)
+)
+
+// PackedUserOperation is the EntryPoint v0.7 ABI representation of a UserOperation. Gas limits and fees are
+// packed in pairs of uint128 values into a single bytes32 word.
+type PackedUserOperation struct {
+	Sender             common.Address `json:"sender"`
+	Nonce              *big.Int       `json:"nonce"`
+	InitCode           []byte         `json:"initCode"`
+	CallData           []byte         `json:"callData"`
+	AccountGasLimits   [32]byte       `json:"accountGasLimits"`
+	PreVerificationGas *big.Int       `json:"preVerificationGas"`
+	GasFees            [32]byte       `json:"gasFees"`
+	PaymasterAndData   []byte         `json:"paymasterAndData"`
+	Signature          []byte         `json:"signature"`
+}
+
+func packUint128Pair(high *big.Int, low *big.Int) [32]byte {
+	var out [32]byte
+	copy(out[:packedGasLength], common.LeftPadBytes(high.Bytes(), packedGasLength))
+	copy(out[packedGasLength:], common.LeftPadBytes(low.Bytes(), packedGasLength))
+	return out
+}
+
+func unpackUint128Pair(word [32]byte) (high *big.Int, low *big.Int) {
+	return big.NewInt(0).SetBytes(word[:packedGasLength]), big.NewInt(0).SetBytes(word[packedGasLength:])
+}
+
+// NewFromPacked converts a PackedUserOperation from the v0.7 EntryPoint ABI into a UserOperation.
+func NewFromPacked(p *PackedUserOperation) *UserOperation {
+	vgl, cgl := unpackUint128Pair(p.AccountGasLimits)
+	mpf, mf := unpackUint128Pair(p.GasFees)
+	return &UserOperation{
+		Sender:               p.Sender,
+		Nonce:                p.Nonce,
+		InitCode:             p.InitCode,
+		CallData:             p.CallData,
+		CallGasLimit:         cgl,
+		VerificationGasLimit: vgl,
+		PreVerificationGas:   p.PreVerificationGas,
+		MaxFeePerGas:         mf,
+		MaxPriorityFeePerGas: mpf,
+		PaymasterAndData:     p.PaymasterAndData,
+		Signature:            p.Signature,
+		version:              V07,
+	}
+}
+
+// Version returns the EntryPoint version that the UserOperation is formatted for.
+func (op *UserOperation) Version() Version {
+	return op.version
+}
+
+// GetFactoryData returns the calldata portion of InitCode if applicable. Otherwise it returns an empty byte
+// array.
+func (op *UserOperation) GetFactoryData() []byte {
+	if len(op.InitCode) < common.AddressLength {
+		return []byte{}
+	}
+
+	return op.InitCode[common.AddressLength:]
+}
+
+// GetPaymasterVerificationGasLimit returns the gas limit for the paymaster's validation phase. This is only
+// applicable to v0.7 UserOperations and will return 0 otherwise.
+func (op *UserOperation) GetPaymasterVerificationGasLimit() *big.Int {
+	if op.version != V07 || len(op.PaymasterAndData) < paymasterDataOffset {
+		return big.NewInt(0)
+	}
+
+	return big.NewInt(0).SetBytes(
+		op.PaymasterAndData[common.AddressLength : common.AddressLength+packedGasLength],
+	)
+}
+
+// GetPaymasterPostOpGasLimit returns the gas limit for the paymaster's postOp phase. This is only applicable
+// to v0.7 UserOperations and will return 0 otherwise.
+func (op *UserOperation) GetPaymasterPostOpGasLimit() *big.Int {
+	if op.version != V07 || len(op.PaymasterAndData) < paymasterDataOffset {
+		return big.NewInt(0)
+	}
+
+	return big.NewInt(0).SetBytes(
+		op.PaymasterAndData[common.AddressLength+packedGasLength : paymasterDataOffset],
+	)
+}
+
+// GetPaymasterData returns the data portion of PaymasterAndData that is passed to the paymaster if
+// applicable. Otherwise it returns an empty byte array.
+func (op *UserOperation) GetPaymasterData() []byte {
+	offset := common.AddressLength
+	if op.version == V07 {
+		offset = paymasterDataOffset
+	}
+	if len(op.PaymasterAndData) < offset {
+		return []byte{}
+	}
+
+	return op.PaymasterAndData[offset:]
+}
+
+// ToPacked returns the UserOperation in the PackedUserOperation format expected by EntryPoint v0.7.
+func (op *UserOperation) ToPacked() PackedUserOperation {
+	return PackedUserOperation{
+		Sender:             op.Sender,
+		Nonce:              op.Nonce,
+		InitCode:           op.InitCode,
+		CallData:           op.CallData,
+		AccountGasLimits:   packUint128Pair(op.VerificationGasLimit, op.CallGasLimit),
+		PreVerificationGas: op.PreVerificationGas,
+		GasFees:            packUint128Pair(op.MaxPriorityFeePerGas, op.MaxFeePerGas),
+		PaymasterAndData:   op.PaymasterAndData,
+		Signature:          op.Signature,
+	}
+}
+
+func (op *UserOperation) packV07() []byte {
+	args := abi.Arguments{
+		{Name: "UserOp", Type: PackedUserOpType},
+	}
+	packed, _ := args.Pack(op.ToPacked())
+
+	enc := hexutil.Encode(packed)
+	enc = "0x" + enc[66:]
+	return (hexutil.MustDecode(enc))
+}
+
+func (op *UserOperation) packForSignatureV07() []byte {
+	args := abi.Arguments{
+		{Name: "sender", Type: address},
+		{Name: "nonce", Type: uint256},
+		{Name: "hashInitCode", Type: bytes32},
+		{Name: "hashCallData", Type: bytes32},
+		{Name: "accountGasLimits", Type: bytes32},
+		{Name: "preVerificationGas", Type: uint256},
+		{Name: "gasFees", Type: bytes32},
+		{Name: "hashPaymasterAndData", Type: bytes32},
+	}
+	p := op.ToPacked()
+	packed, _ := args.Pack(
+		p.Sender,
+		p.Nonce,
+		crypto.Keccak256Hash(p.InitCode),
+		crypto.Keccak256Hash(p.CallData),
+		p.AccountGasLimits,
+		p.PreVerificationGas,
+		p.GasFees,
+		crypto.Keccak256Hash(p.PaymasterAndData),
+	)
+
+	return packed
+}
+
+func (op *UserOperation) marshalJSONV07() ([]byte, error) {
+	var factory, factoryData string
+	if f := op.GetFactory(); f != common.HexToAddress("0x") {
+		factory = f.String()
+		factoryData = hexutil.Encode(op.GetFactoryData())
+	}
+
+	var paymaster, paymasterVerificationGasLimit, paymasterPostOpGasLimit, paymasterData string
+	if pm := op.GetPaymaster(); pm != common.HexToAddress("0x") {
+		paymaster = pm.String()
+		paymasterVerificationGasLimit = hexutil.EncodeBig(op.GetPaymasterVerificationGasLimit())
+		paymasterPostOpGasLimit = hexutil.EncodeBig(op.GetPaymasterPostOpGasLimit())
+		paymasterData = hexutil.Encode(op.GetPaymasterData())
+	}
+
+	return json.Marshal(&struct {
+		Sender                        string `json:"sender"`
+		Nonce                         string `json:"nonce"`
+		Factory                       string `json:"factory,omitempty"`
+		FactoryData                   string `json:"factoryData,omitempty"`
+		CallData                      string `json:"callData"`
+		CallGasLimit                  string `json:"callGasLimit"`
+		VerificationGasLimit          string `json:"verificationGasLimit"`
+		PreVerificationGas            string `json:"preVerificationGas"`
+		MaxFeePerGas                  string `json:"maxFeePerGas"`
+		MaxPriorityFeePerGas          string `json:"maxPriorityFeePerGas"`
+		Paymaster                     string `json:"paymaster,omitempty"`
+		PaymasterVerificationGasLimit string `json:"paymasterVerificationGasLimit,omitempty"`
+		PaymasterPostOpGasLimit       string `json:"paymasterPostOpGasLimit,omitempty"`
+		PaymasterData                 string `json:"paymasterData,omitempty"`
+		Signature                     string `json:"signature"`
+	}{
+		Sender:                        op.Sender.String(),
+		Nonce:                         hexutil.EncodeBig(op.Nonce),
+		Factory:                       factory,
+		FactoryData:                   factoryData,
+		CallData:                      hexutil.Encode(op.CallData),
+		CallGasLimit:                  hexutil.EncodeBig(op.CallGasLimit),
+		VerificationGasLimit:          hexutil.EncodeBig(op.VerificationGasLimit),
+		PreVerificationGas:            hexutil.EncodeBig(op.PreVerificationGas),
+		MaxFeePerGas:                  hexutil.EncodeBig(op.MaxFeePerGas),
+		MaxPriorityFeePerGas:          hexutil.EncodeBig(op.MaxPriorityFeePerGas),
+		Paymaster:                     paymaster,
+		PaymasterVerificationGasLimit: paymasterVerificationGasLimit,
+		PaymasterPostOpGasLimit:       paymasterPostOpGasLimit,
+		PaymasterData:                 paymasterData,
+		Signature:                     hexutil.Encode(op.Signature),
+	})
+}
@@ -0,0 +1,97 @@
+package userop_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stackup-wallet/stackup-bundler/internal/testutils"
+	"github.com/stackup-wallet/stackup-bundler/pkg/userop"
+)
+
+// TestNewPackedUserOperation verifies that a UserOperation in the v0.7 RPC format is decoded with the
+// factory and paymaster fields combined into InitCode and PaymasterAndData.
+func TestNewPackedUserOperation(t *testing.T) {
+	op := testutils.MockValidPackedUserOp()
+	if op == nil {
+		t.Fatal("got nil op, want valid v0.7 op")
+	}
+
+	if op.Version() != userop.V07 {
+		t.Fatalf("got %s, want %s", op.Version(), userop.V07)
+	}
+	if op.GetFactory() != common.HexToAddress(testutils.MockPackedUserOpData["factory"].(string)) {
+		t.Fatalf("got %s, want %s", op.GetFactory(), testutils.MockPackedUserOpData["factory"])
+	}
+	if op.GetPaymaster() != testutils.ValidAddress3 {
+		t.Fatalf("got %s, want %s", op.GetPaymaster(), testutils.ValidAddress3)
+	}
+	if op.GetPaymasterVerificationGasLimit().Cmp(big.NewInt(100000)) != 0 {
+		t.Fatalf("got %d, want %d", op.GetPaymasterVerificationGasLimit(), 100000)
+	}
+	if op.GetPaymasterPostOpGasLimit().Cmp(big.NewInt(50000)) != 0 {
+		t.Fatalf("got %d, want %d", op.GetPaymasterPostOpGasLimit(), 50000)
+	}
+	if common.Bytes2Hex(op.GetPaymasterData()) != "1234" {
+		t.Fatalf("got %x, want %s", op.GetPaymasterData(), "1234")
+	}
+}
+
+// TestPackedUserOperationRoundTrip verifies that a v0.7 UserOperation is unchanged after being encoded to a
+// map and decoded again.
+func TestPackedUserOperationRoundTrip(t *testing.T) {
+	op := testutils.MockValidPackedUserOp()
+	data, err := op.ToMap()
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	got, err := userop.New(data)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if !testutils.IsOpsEqual(got, op) {
+		t.Fatal(testutils.GetOpsDiff(got, op))
+	}
+}
+
+// TestPackedUserOperationGetMaxGasAvailable verifies that (*UserOperation).GetMaxGasAvailable includes the
+// paymaster gas limits for a v0.7 UserOperation.
+func TestPackedUserOperationGetMaxGasAvailable(t *testing.T) {
+	op := testutils.MockValidPackedUserOp()
+	want := big.NewInt(0).Add(op.VerificationGasLimit, op.CallGasLimit)
+	want.Add(want, op.PreVerificationGas)
+	want.Add(want, big.NewInt(150000))
+	if op.GetMaxGasAvailable().Cmp(want) != 0 {
+		t.Fatalf("got %d, want %d", op.GetMaxGasAvailable(), want)
+	}
+}
+
+// TestNewPackedUserOperationGasOverflow verifies that a v0.7 UserOperation is rejected if a gas value does
+// not fit into a uint128.
+func TestNewPackedUserOperationGasOverflow(t *testing.T) {
+	data := map[string]any{}
+	for k, v := range testutils.MockPackedUserOpData {
+		data[k] = v
+	}
+	data["callGasLimit"] = "0x100000000000000000000000000000000"
+
+	if _, err := userop.New(data); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
+
+// TestParseVersion verifies that known EntryPoint versions are parsed and an unknown version is rejected.
+func TestParseVersion(t *testing.T) {
+	for s, want := range map[string]userop.Version{"v0.6": userop.V06, "0.7": userop.V07} {
+		if v, err := userop.ParseVersion(s); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		} else if v != want {
+			t.Fatalf("got %s, want %s", v, want)
+		}
+	}
+
+	if _, err := userop.ParseVersion("v0.8"); err == nil {
+		t.Fatal("got nil, want err")
+	}
+}
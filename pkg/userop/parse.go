@@ -3,6 +3,7 @@ package userop
 import (
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"math/big"
 	"reflect"
 	"sync"
@@ -84,9 +85,147 @@ func validateBigIntType(field reflect.Value) interface{} {
 	return field
 }
 
-// New decodes a map into a UserOperation object and validates all the fields are correctly typed.
+// rpcUserOperation is the v0.6 RPC representation of a UserOperation.
+type rpcUserOperation struct {
+	Sender               common.Address `mapstructure:"sender"               validate:"required"`
+	Nonce                *big.Int       `mapstructure:"nonce"                validate:"required"`
+	InitCode             []byte         `mapstructure:"initCode"             validate:"required"`
+	CallData             []byte         `mapstructure:"callData"             validate:"required"`
+	CallGasLimit         *big.Int       `mapstructure:"callGasLimit"         validate:"required"`
+	VerificationGasLimit *big.Int       `mapstructure:"verificationGasLimit" validate:"required"`
+	PreVerificationGas   *big.Int       `mapstructure:"preVerificationGas"   validate:"required"`
+	MaxFeePerGas         *big.Int       `mapstructure:"maxFeePerGas"         validate:"required"`
+	MaxPriorityFeePerGas *big.Int       `mapstructure:"maxPriorityFeePerGas" validate:"required"`
+	PaymasterAndData     []byte         `mapstructure:"paymasterAndData"     validate:"required"`
+	Signature            []byte         `mapstructure:"signature"            validate:"required"`
+}
+
+// packedRPCUserOperation is the v0.7 RPC representation of a UserOperation where the factory and paymaster
+// fields are given separately.
+type packedRPCUserOperation struct {
+	Sender                        common.Address `mapstructure:"sender"                        validate:"required"`
+	Nonce                         *big.Int       `mapstructure:"nonce"                         validate:"required"`
+	Factory                       common.Address `mapstructure:"factory"`
+	FactoryData                   []byte         `mapstructure:"factoryData"`
+	CallData                      []byte         `mapstructure:"callData"                      validate:"required"`
+	CallGasLimit                  *big.Int       `mapstructure:"callGasLimit"                  validate:"required"`
+	VerificationGasLimit          *big.Int       `mapstructure:"verificationGasLimit"          validate:"required"`
+	PreVerificationGas            *big.Int       `mapstructure:"preVerificationGas"            validate:"required"`
+	MaxFeePerGas                  *big.Int       `mapstructure:"maxFeePerGas"                  validate:"required"`
+	MaxPriorityFeePerGas          *big.Int       `mapstructure:"maxPriorityFeePerGas"          validate:"required"`
+	Paymaster                     common.Address `mapstructure:"paymaster"`
+	PaymasterVerificationGasLimit *big.Int       `mapstructure:"paymasterVerificationGasLimit"`
+	PaymasterPostOpGasLimit       *big.Int       `mapstructure:"paymasterPostOpGasLimit"`
+	PaymasterData                 []byte         `mapstructure:"paymasterData"`
+	Signature                     []byte         `mapstructure:"signature"                     validate:"required"`
+}
+
+func registerCustomTypes() {
+	onlyOnce.Do(func() {
+		validate.RegisterCustomTypeFunc(validateAddressType, common.Address{})
+		validate.RegisterCustomTypeFunc(validateBigIntType, big.Int{})
+	})
+}
+
+// isPackedFormat returns true if the map is in the v0.7 RPC format. A v0.6 UserOperation will always
+// include the initCode and paymasterAndData fields.
+func isPackedFormat(data map[string]any) bool {
+	_, hasInitCode := data["initCode"]
+	_, hasPaymasterAndData := data["paymasterAndData"]
+	return !hasInitCode && !hasPaymasterAndData
+}
+
+func toPackedGasBytes(field string, value *big.Int) ([]byte, error) {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	if value.BitLen() > packedGasLength*8 {
+		return nil, fmt.Errorf("%s: value exceeds uint128", field)
+	}
+	return common.LeftPadBytes(value.Bytes(), packedGasLength), nil
+}
+
+func newPacked(data map[string]any) (*UserOperation, error) {
+	var pop packedRPCUserOperation
+
+	// Convert map to struct. Optional fields are allowed to be unset.
+	config := &mapstructure.DecoderConfig{
+		DecodeHook: decodeOpTypes,
+		Result:     &pop,
+		MatchName:  exactFieldMatch,
+	}
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(data); err != nil {
+		return nil, err
+	}
+
+	// Validate struct
+	registerCustomTypes()
+	if err := validate.Struct(pop); err != nil {
+		return nil, err
+	}
+
+	// Values packed into a bytes32 word must fit into a uint128.
+	for field, value := range map[string]*big.Int{
+		"callGasLimit":         pop.CallGasLimit,
+		"verificationGasLimit": pop.VerificationGasLimit,
+		"maxFeePerGas":         pop.MaxFeePerGas,
+		"maxPriorityFeePerGas": pop.MaxPriorityFeePerGas,
+	} {
+		if _, err := toPackedGasBytes(field, value); err != nil {
+			return nil, err
+		}
+	}
+
+	initCode := []byte{}
+	if pop.Factory != common.HexToAddress("0x") {
+		initCode = append(pop.Factory.Bytes(), pop.FactoryData...)
+	}
+
+	paymasterAndData := []byte{}
+	if pop.Paymaster != common.HexToAddress("0x") {
+		pvgl, err := toPackedGasBytes("paymasterVerificationGasLimit", pop.PaymasterVerificationGasLimit)
+		if err != nil {
+			return nil, err
+		}
+		ppogl, err := toPackedGasBytes("paymasterPostOpGasLimit", pop.PaymasterPostOpGasLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		paymasterAndData = append(paymasterAndData, pop.Paymaster.Bytes()...)
+		paymasterAndData = append(paymasterAndData, pvgl...)
+		paymasterAndData = append(paymasterAndData, ppogl...)
+		paymasterAndData = append(paymasterAndData, pop.PaymasterData...)
+	}
+
+	return &UserOperation{
+		Sender:               pop.Sender,
+		Nonce:                pop.Nonce,
+		InitCode:             initCode,
+		CallData:             pop.CallData,
+		CallGasLimit:         pop.CallGasLimit,
+		VerificationGasLimit: pop.VerificationGasLimit,
+		PreVerificationGas:   pop.PreVerificationGas,
+		MaxFeePerGas:         pop.MaxFeePerGas,
+		MaxPriorityFeePerGas: pop.MaxPriorityFeePerGas,
+		PaymasterAndData:     paymasterAndData,
+		Signature:            pop.Signature,
+		version:              V07,
+	}, nil
+}
+
+// New decodes a map into a UserOperation object and validates all the fields are correctly typed. The map
+// can either be in the v0.6 format or the v0.7 format with separate factory and paymaster fields.
 func New(data map[string]any) (*UserOperation, error) {
-	var op UserOperation
+	if isPackedFormat(data) {
+		return newPacked(data)
+	}
+
+	var op rpcUserOperation
 
 	// Convert map to struct
 	config := &mapstructure.DecoderConfig{
@@ -104,14 +243,24 @@ func New(data map[string]any) (*UserOperation, error) {
 	}
 
 	// Validate struct
-	onlyOnce.Do(func() {
-		validate.RegisterCustomTypeFunc(validateAddressType, common.Address{})
-		validate.RegisterCustomTypeFunc(validateBigIntType, big.Int{})
-	})
+	registerCustomTypes()
 	err = validate.Struct(op)
 	if err != nil {
 		return nil, err
 	}
 
-	return &op, nil
+	return &UserOperation{
+		Sender:               op.Sender,
+		Nonce:                op.Nonce,
+		InitCode:             op.InitCode,
+		CallData:             op.CallData,
+		CallGasLimit:         op.CallGasLimit,
+		VerificationGasLimit: op.VerificationGasLimit,
+		PreVerificationGas:   op.PreVerificationGas,
+		MaxFeePerGas:         op.MaxFeePerGas,
+		MaxPriorityFeePerGas: op.MaxPriorityFeePerGas,
+		PaymasterAndData:     op.PaymasterAndData,
+		Signature:            op.Signature,
+		version:              V06,
+	}, nil
 }
@@ -17,10 +17,11 @@ func main() {
 		panic(fmt.Errorf("fatal error config file: %w", err))
 	}
 
-	s, err := signer.New(viper.GetString("erc4337_bundler_private_key"))
+	pk, err := crypto.HexToECDSA(viper.GetString("erc4337_bundler_private_key"))
 	if err != nil {
 		panic(fmt.Errorf("fatal signer error: %w", err))
 	}
-	fmt.Printf("Public key: %s\n", hexutil.Encode(crypto.FromECDSAPub(s.PublicKey))[4:])
-	fmt.Printf("Address: %s\n", s.Address)
+	s := signer.NewLocalSigner(pk)
+	fmt.Printf("Public key: %s\n", hexutil.Encode(crypto.FromECDSAPub(&pk.PublicKey))[4:])
+	fmt.Printf("Address: %s\n", s.Address())
 }